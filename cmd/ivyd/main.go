@@ -10,19 +10,24 @@ import (
 	"net"
 	"net/http"
 	"sync"
-	"syscall"
 	"time"
 
+	"landzero.net/x/io/ioext"
 	"landzero.net/x/net/netext"
 
 	"landzero.net/x/net/ivy"
 	"landzero.net/x/os/osext"
+	"landzero.net/x/os/systemd"
 )
 
 var errIvyConnectionNotFound = errors.New("ivy connection not found")
 
 var httpAddr string
+var httpMaxConns int
 var ivyAddr string
+var logFile string
+var useSystemd bool
+var shutdownTimeout time.Duration
 
 type registry struct {
 	conns *list.List
@@ -129,20 +134,91 @@ func (h *ivyHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 }
 
 func main() {
+	defer osext.DoExit()
+
 	reg := &registry{conns: list.New(), r: &sync.Mutex{}}
 
 	flag.StringVar(&httpAddr, "http.addr", "0.0.0.0:8080", "listening address for http")
+	flag.IntVar(&httpMaxConns, "http.max-conns", 0, "max concurrent connections for http, 0 means unlimited")
 	flag.StringVar(&ivyAddr, "ivy.addr", "127.0.0.1:8090", "listening address for ivy")
+	flag.BoolVar(&useSystemd, "systemd", false, "use sockets named \"http\" and \"ivy\" passed by systemd socket activation, addresses are listened if not passed, and notify systemd")
+	flag.DurationVar(&shutdownTimeout, "shutdown-timeout", osext.DefaultStopTimeout, "timeout of draining connections on shutdown")
+	flag.StringVar(&logFile, "log.file", "", "file to write logs, rotated daily and reopened on SIGHUP, stderr if empty")
 	flag.Parse()
 
+	if len(logFile) > 0 {
+		w := ioext.NewRotatingFileWriter(logFile, ioext.RotateOptions{Interval: time.Hour * 24, MaxBackups: 7, Compress: true})
+		w.ReopenOnSignal()
+		defer w.Close()
+		log.SetOutput(w)
+	}
+
 	hs := &http.Server{Handler: &httpHandler{reg}, Addr: httpAddr}
 	is := &http.Server{Handler: &ivyHandler{reg}, Addr: ivyAddr}
 
-	go hs.ListenAndServe()
-	go is.ListenAndServe()
-
-	osext.WaitSignals(syscall.SIGINT, syscall.SIGTERM)
+	listen := func(name, address string) (net.Listener, error) {
+		if useSystemd {
+			return systemd.Listen(name, "tcp", address)
+		}
+		return net.Listen("tcp", address)
+	}
 
-	hs.Shutdown(context.Background())
-	is.Shutdown(context.Background())
+	hl, err := listen("http", httpAddr)
+	if err != nil {
+		log.Println("Failed to listen", httpAddr, err)
+		osext.WillExit(1)
+		return
+	}
+	il, err := listen("ivy", ivyAddr)
+	if err != nil {
+		log.Println("Failed to listen", ivyAddr, err)
+		osext.WillExit(1)
+		return
+	}
+	hl = netext.WrapListener(hl, netext.ListenerOptions{
+		MaxConns: httpMaxConns,
+		Hooks: netext.ConnHooks{
+			OnEnd: func(c *netext.Conn) {
+				st := c.Stats()
+				log.Debugf("http: connection from %v ended, read %d bytes, written %d bytes", c.RemoteAddr(), st.BytesRead, st.BytesWritten)
+			},
+		},
+	})
+
+	lc := osext.NewLifecycle()
+	serve := func(name string, s *http.Server, l net.Listener) osext.Component {
+		return osext.Component{
+			Name: name,
+			Start: func(ctx context.Context) error {
+				go func() {
+					if err := s.Serve(l); err != http.ErrServerClosed {
+						lc.Fail(name, err)
+					}
+				}()
+				return nil
+			},
+			Stop:        s.Shutdown,
+			StopTimeout: shutdownTimeout,
+		}
+	}
+	lc.Add(serve("http", hs, hl))
+	lc.Add(serve("ivy", is, il))
+	if useSystemd {
+		done := make(chan struct{})
+		lc.Add(osext.Component{
+			Name:      "systemd",
+			DependsOn: []string{"http", "ivy"},
+			Start: func(ctx context.Context) (err error) {
+				_, err = systemd.Notify(systemd.Ready)
+				systemd.StartWatchdog(done)
+				return
+			},
+			Stop: func(ctx context.Context) (err error) {
+				close(done)
+				_, err = systemd.Notify(systemd.Stopping)
+				return
+			},
+		})
+	}
+	lc.Run()
 }
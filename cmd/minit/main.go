@@ -1,35 +1,84 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"landzero.net/x/log"
 	"net"
 	"os"
 	"path/filepath"
+	"syscall"
+	"time"
 
+	"landzero.net/x/io/ioext"
+	"landzero.net/x/net/graceful"
 	"landzero.net/x/os/minit"
+	"landzero.net/x/os/osext"
+	"landzero.net/x/os/systemd"
 )
 
 var sock string
+var logFile string
+var useSystemd bool
 
 func main() {
 	// parse flags
 	flag.StringVar(&sock, "L", "/var/run/minit/minit.sock", "socket file to listen")
+	flag.BoolVar(&useSystemd, "systemd", false, "use the socket passed by systemd socket activation instead of -L, and notify systemd")
+	flag.StringVar(&logFile, "log", "", "file to write logs, rotated daily and reopened on SIGHUP, stderr if empty")
 	flag.Parse()
-	// try remove existing sock file
-	os.Remove(sock)
-	// try create parrent directory
-	os.MkdirAll(filepath.Dir(sock), os.FileMode(0755))
-	// listen sock file
+	// setup log file
+	if len(logFile) > 0 {
+		w := ioext.NewRotatingFileWriter(logFile, ioext.RotateOptions{Interval: time.Hour * 24, MaxBackups: 7, Compress: true})
+		w.ReopenOnSignal()
+		defer w.Close()
+		log.SetOutput(w)
+	}
 	var err error
 	var l net.Listener
-	if l, err = net.Listen("unix", sock); err != nil {
-		log.Println("Failed to listen", sock, err)
-		return
+	var wait func()
+	srv := minit.NewServer()
+	if useSystemd {
+		// take the socket passed by systemd socket activation
+		if l, err = systemd.Listener(""); err != nil {
+			log.Println("Failed to take systemd socket", err)
+			return
+		}
+		wait = func() {
+			osext.WaitSignals(syscall.SIGINT, syscall.SIGTERM)
+			ctx, cancel := context.WithTimeout(context.Background(), graceful.DefaultTimeout)
+			defer cancel()
+			srv.Shutdown(ctx)
+		}
+	} else {
+		// try create parrent directory
+		os.MkdirAll(filepath.Dir(sock), os.FileMode(0755))
+		// listen sock file, inherit it on graceful restart, stale sock file is removed otherwise
+		g := graceful.New(graceful.Options{})
+		if l, err = g.Listen("unix", sock); err != nil {
+			log.Println("Failed to listen", sock, err)
+			return
+		}
+		// SIGUSR2 restarts, SIGINT or SIGTERM exits, both drain existing sessions
+		g.Add(srv)
+		wait = func() {
+			g.Ready()
+			g.Wait()
+		}
 	}
-	log.Println("Listening on", sock)
+	log.Println("Listening on", l.Addr())
 	// the listen loop
-	minit.Serve(l)
+	go srv.Serve(l)
+	done := make(chan struct{})
+	if useSystemd {
+		systemd.Notify(systemd.Ready)
+		systemd.StartWatchdog(done)
+	}
+	wait()
+	close(done)
+	if useSystemd {
+		systemd.Notify(systemd.Stopping)
+	}
 }
 
 func printHelp() {
@@ -3,11 +3,8 @@ package main
 import (
 	"flag"
 	"os"
-	"os/signal"
 	"strings"
-	"syscall"
 
-	"golang.org/x/crypto/ssh/terminal"
 	"landzero.net/x/io/pty"
 	"landzero.net/x/os/minit"
 )
@@ -50,21 +47,18 @@ func main() {
 		panic(err)
 	}
 	// stream winsize
-	ch := make(chan os.Signal, 1)
-	signal.Notify(ch, syscall.SIGWINCH)
+	sizes, stop := pty.WatchSize(os.Stdin)
+	defer stop()
 	go func() {
-		for range ch {
-			if hi, wi, err := pty.Getsize(os.Stdin); err == nil {
-				c.SetWinsize(uint16(wi), uint16(hi))
-			}
+		for ws := range sizes {
+			c.SetWinsize(ws.Cols, ws.Rows)
 		}
 	}()
-	ch <- syscall.SIGWINCH // Initial resize.
-	oldState, err := terminal.MakeRaw(int(os.Stdin.Fd()))
+	oldState, err := pty.MakeRaw(os.Stdin)
 	if err != nil {
 		panic(err)
 	}
-	defer func() { _ = terminal.Restore(int(os.Stdin.Fd()), oldState) }() // Best effort.
+	defer func() { _ = pty.Restore(os.Stdin, oldState) }() // Best effort.
 	// stream stdout
 	// stream stdin
 	go c.ReadFrom(os.Stdin)
@@ -0,0 +1,231 @@
+package com
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	// StreamChunkSize size of plaintext chunks of NewEncryptWriter
+	StreamChunkSize = 64 << 10
+
+	streamMaxChunkSize = 16 << 20
+	streamSaltSize     = 16
+)
+
+var errStreamTooLong = errors.New("com: stream too long")
+
+// streamAEAD derives a per-stream key from key and salt, so that nonces can
+// be a plain chunk counter
+func streamAEAD(key, salt []byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(salt)
+	return newGCM(mac.Sum(nil)[:len(key)])
+}
+
+// streamNonce is 7 zero bytes, 4 bytes of big endian counter and the last
+// chunk flag, which prevents reordering and truncation of chunks
+func streamNonce(nonce []byte, counter uint32, last bool) {
+	binary.BigEndian.PutUint32(nonce[7:11], counter)
+	nonce[11] = 0
+	if last {
+		nonce[11] = 1
+	}
+}
+
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	nonce   []byte
+	buf     []byte
+	out     []byte
+	counter uint32
+	err     error
+	closed  bool
+}
+
+// NewEncryptWriter returns a writer encrypting everything written to it into
+// w with the primary key, in chunks of StreamChunkSize, each sealed with AES
+// in GCM mode, so large files can be encrypted without loading them into
+// memory. Close must be called to write the last chunk, it does not close w.
+// The header is written to w immediately.
+func (kr *Keyring) NewEncryptWriter(w io.Writer) (io.WriteCloser, error) {
+	return kr.newEncryptWriter(w, StreamChunkSize)
+}
+
+func (kr *Keyring) newEncryptWriter(w io.Writer, chunkSize int) (io.WriteCloser, error) {
+	id, key, err := kr.primaryKey()
+	if err != nil {
+		return nil, err
+	}
+	header := keyringHeader(keyringVersionStream, id)
+	header = append(header, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(header[len(header)-4:], uint32(chunkSize))
+	salt := make([]byte, streamSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	header = append(header, salt...)
+	aead, err := streamAEAD(key, salt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:      w,
+		aead:   aead,
+		header: header,
+		nonce:  make([]byte, aead.NonceSize()),
+		buf:    make([]byte, 0, chunkSize),
+		out:    make([]byte, 0, chunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (ew *encryptWriter) seal(last bool) error {
+	streamNonce(ew.nonce, ew.counter, last)
+	ew.out = ew.aead.Seal(ew.out[:0], ew.nonce, ew.buf, ew.header)
+	ew.buf = ew.buf[:0]
+	if _, err := ew.w.Write(ew.out); err != nil {
+		return err
+	}
+	if ew.counter++; ew.counter == 0 && !last {
+		return errStreamTooLong
+	}
+	return nil
+}
+
+func (ew *encryptWriter) Write(p []byte) (n int, err error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+	if ew.closed {
+		return 0, errors.New("com: write to closed encrypt writer")
+	}
+	for len(p) > 0 {
+		// a full chunk is sealed only when more data follows, the last
+		// chunk is sealed by Close
+		if len(ew.buf) == cap(ew.buf) {
+			if ew.err = ew.seal(false); ew.err != nil {
+				return n, ew.err
+			}
+		}
+		c := copy(ew.buf[len(ew.buf):cap(ew.buf)], p)
+		ew.buf = ew.buf[:len(ew.buf)+c]
+		p = p[c:]
+		n += c
+	}
+	return
+}
+
+func (ew *encryptWriter) Close() error {
+	if ew.err != nil || ew.closed {
+		return ew.err
+	}
+	ew.closed = true
+	ew.err = ew.seal(true)
+	return ew.err
+}
+
+type decryptReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	header  []byte
+	nonce   []byte
+	buf     []byte
+	out     []byte
+	plain   []byte
+	counter uint32
+	done    bool
+	err     error
+}
+
+// NewDecryptReader returns a reader decrypting a stream created by
+// NewEncryptWriter with the key it names. The header is read immediately.
+// Read returns ErrInvalidCiphertext if the stream is tampered, reordered or
+// truncated, data of a chunk is only returned after it is authenticated.
+func (kr *Keyring) NewDecryptReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, 2, 2+255+4+streamSaltSize)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	if header[0] != keyringVersionStream || header[1] == 0 {
+		return nil, ErrInvalidCiphertext
+	}
+	header = header[:2+int(header[1])+4+streamSaltSize]
+	if _, err := io.ReadFull(br, header[2:]); err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	id, n, _ := parseKeyringHeader(keyringVersionStream, header)
+	chunkSize := binary.BigEndian.Uint32(header[n : n+4])
+	if chunkSize == 0 || chunkSize > streamMaxChunkSize {
+		return nil, ErrInvalidCiphertext
+	}
+	key, err := kr.key(id)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := streamAEAD(key, header[n+4:])
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{
+		r:      br,
+		aead:   aead,
+		header: header,
+		nonce:  make([]byte, aead.NonceSize()),
+		buf:    make([]byte, int(chunkSize)+aead.Overhead()),
+	}, nil
+}
+
+func (dr *decryptReader) open() error {
+	n, err := io.ReadFull(dr.r, dr.buf)
+	last := false
+	switch err {
+	case nil:
+		// a full chunk is the last one if nothing follows
+		if _, err = dr.r.Peek(1); err == io.EOF {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+	default:
+		return err
+	}
+	streamNonce(dr.nonce, dr.counter, last)
+	if dr.out, err = dr.aead.Open(dr.out[:0], dr.nonce, dr.buf[:n], dr.header); err != nil {
+		return ErrInvalidCiphertext
+	}
+	dr.plain = dr.out
+	if dr.counter++; dr.counter == 0 && !last {
+		return errStreamTooLong
+	}
+	dr.done = last
+	return nil
+}
+
+func (dr *decryptReader) Read(p []byte) (n int, err error) {
+	for len(dr.plain) == 0 {
+		if dr.err != nil {
+			return 0, dr.err
+		}
+		if dr.done {
+			return 0, io.EOF
+		}
+		dr.err = dr.open()
+	}
+	n = copy(p, dr.plain)
+	dr.plain = dr.plain[n:]
+	return
+}
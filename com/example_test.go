@@ -15,9 +15,11 @@
 package com_test
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"landzero.net/x/com"
 )
@@ -228,35 +230,15 @@ func ExampleFetchFiles() {
 	fmt.Println(err, len(files[0].Data()), len(files[1].Data()))
 }
 
-func ExampleFetchFilesCurl() {
-	// Code that should be outside of your function body.
-	// type rawFile struct {
-	// name   string
-	// 	rawURL string
-	// 	data   []byte
-	// }
-
-	// func (rf *rawFile) Name() string {
-	// 	return rf.name
-	// }
-
-	// func (rf *rawFile) RawUrl() string {
-	// 	return rf.rawURL
-	// }
-
-	// func (rf *rawFile) Data() []byte {
-	// 	return rf.data
-	// }
-
-	// func (rf *rawFile) SetData(p []byte) {
-	// 	rf.data = p
-	// }
-
+func ExampleHTTPClient_FetchFiles() {
 	files := []com.RawFile{
 		&rawFile{rawURL: "http://example.com"},
 		&rawFile{rawURL: "http://example.com/foo"},
 	}
-	err := com.FetchFilesCurl(files)
+	c := &com.HTTPClient{MaxRetries: 3, Concurrency: 2}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	err := c.FetchFiles(ctx, files)
 	fmt.Println(err, len(files[0].Data()), len(files[1].Data()))
 }
 
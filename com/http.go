@@ -45,6 +45,7 @@ func (e *RemoteError) Error() string {
 var UserAgent = "Mozilla/5.0 (Windows NT 6.1; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/29.0.1541.0 Safari/537.36"
 
 // HttpCall makes HTTP method call.
+// See HTTPClient for context, retries and response size limit.
 func HttpCall(client *http.Client, method, url string, header http.Header, body io.Reader) (io.ReadCloser, error) {
 	req, err := http.NewRequest(method, url, body)
 	if err != nil {
@@ -158,6 +159,7 @@ type RawFile interface {
 }
 
 // FetchFiles fetches files specified by the rawURL field in parallel.
+// See HTTPClient.FetchFiles for context, retries and checksum verification.
 func FetchFiles(client *http.Client, files []RawFile, header http.Header) error {
 	ch := make(chan error, len(files))
 	for i := range files {
@@ -179,29 +181,6 @@ func FetchFiles(client *http.Client, files []RawFile, header http.Header) error
 	return nil
 }
 
-// FetchFiles uses command `curl` to fetch files specified by the rawURL field in parallel.
-func FetchFilesCurl(files []RawFile, curlOptions ...string) error {
-	ch := make(chan error, len(files))
-	for i := range files {
-		go func(i int) {
-			stdout, _, err := ExecCmd("curl", append(curlOptions, files[i].RawUrl())...)
-			if err != nil {
-				ch <- err
-				return
-			}
-
-			files[i].SetData([]byte(stdout))
-			ch <- nil
-		}(i)
-	}
-	for range files {
-		if err := <-ch; err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 // HTTPPipeResponse pipe a http.Response to a http.ResponseWriter, and close http.Response
 func HTTPPipeResponse(rw http.ResponseWriter, resp *http.Response) error {
 	defer resp.Body.Close()
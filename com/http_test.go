@@ -94,18 +94,3 @@ func TestFetchFiles(t *testing.T) {
 		t.Errorf("FetchFiles:\n Expect => %d\n Got => %d\n", 1270, len(files[1].Data()))
 	}
 }
-
-func TestFetchFilesCurl(t *testing.T) {
-	files := []RawFile{
-		&rawFile{rawURL: "http://example.com"},
-		&rawFile{rawURL: "http://example.com"},
-	}
-	err := FetchFilesCurl(files)
-	if err != nil {
-		t.Errorf("FetchFilesCurl:\n Expect => %v\n Got => %s\n", nil, err)
-	} else if len(files[0].Data()) != 1270 {
-		t.Errorf("FetchFilesCurl:\n Expect => %d\n Got => %d\n", 1270, len(files[0].Data()))
-	} else if len(files[1].Data()) != 1270 {
-		t.Errorf("FetchFilesCurl:\n Expect => %d\n Got => %d\n", 1270, len(files[1].Data()))
-	}
-}
@@ -0,0 +1,429 @@
+package com
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrBodyTooLarge response body exceeds HTTPClient.MaxBodySize
+	ErrBodyTooLarge = errors.New("com: response body too large")
+	// ErrChecksumMismatch downloaded content does not match the checksum
+	ErrChecksumMismatch = errors.New("com: checksum mismatch")
+)
+
+const (
+	// DefaultRetryWait default initial wait between retries
+	DefaultRetryWait = 500 * time.Millisecond
+	// DefaultMaxRetryWait default max wait between retries
+	DefaultMaxRetryWait = 30 * time.Second
+	// DefaultMaxBodySize default max size of response body read into memory
+	DefaultMaxBodySize = 10 << 20
+	// DefaultConcurrency default max concurrent downloads of FetchFiles
+	DefaultConcurrency = 4
+)
+
+// HTTPError a response with non 2xx status
+type HTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	// Message message decoded from a JSON error body, like {"error": "..."} or {"message": "..."}
+	Message string
+	// Body leading bytes of the response body
+	Body []byte
+}
+
+func (e *HTTPError) Error() string {
+	if len(e.Message) > 0 {
+		return fmt.Sprintf("%s %s -> %d: %s", e.Method, e.URL, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s %s -> %d", e.Method, e.URL, e.StatusCode)
+}
+
+// HTTPClient a http client with context, retries and response size limit, the zero value is ready to use
+type HTTPClient struct {
+	// Client the underlying client, default http.DefaultClient
+	Client *http.Client
+	// Header default headers of requests, User-Agent is UserAgent if not set
+	Header http.Header
+	// MaxRetries max retries of idempotent requests on network errors, 429 and 5xx, default 0
+	MaxRetries int
+	// RetryWait initial wait between retries, doubled every retry, default DefaultRetryWait,
+	// Retry-After of response is respected
+	RetryWait time.Duration
+	// MaxRetryWait max wait between retries, default DefaultMaxRetryWait
+	MaxRetryWait time.Duration
+	// MaxBodySize max size of response body read into memory, default DefaultMaxBodySize, -1 for no limit
+	MaxBodySize int64
+	// Concurrency max concurrent downloads of FetchFiles, default DefaultConcurrency
+	Concurrency int
+}
+
+func (c *HTTPClient) client() *http.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+	return http.DefaultClient
+}
+
+// isIdempotent requests safe to retry, a body must be rewindable by GetBody
+func isIdempotent(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return true
+	}
+	return len(req.Header.Get("Idempotency-Key")) > 0
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusInternalServerError ||
+		code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+// backoff returns the wait before the n-th retry, starting from 0
+func (c *HTTPClient) backoff(n int, resp *http.Response) time.Duration {
+	max := c.MaxRetryWait
+	if max <= 0 {
+		max = DefaultMaxRetryWait
+	}
+	if resp != nil {
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s >= 0 {
+			if d := time.Duration(s) * time.Second; d < max {
+				return d
+			}
+			return max
+		}
+	}
+	d := c.RetryWait
+	if d <= 0 {
+		d = DefaultRetryWait
+	}
+	for i := 0; i < n && d < max; i++ {
+		d *= 2
+	}
+	// jitter of +-25%
+	d = d*3/4 + time.Duration(rand.Int63n(int64(d/2)+1))
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// Do sends the request with default headers, idempotent requests are retried on network errors,
+// 429 and 5xx, the response of the last attempt is returned as is
+func (c *HTTPClient) Do(ctx context.Context, req *http.Request) (resp *http.Response, err error) {
+	req = req.WithContext(ctx)
+	// copy headers, the request of caller is not modified
+	header := http.Header{}
+	for k, vs := range c.Header {
+		header[k] = vs
+	}
+	for k, vs := range req.Header {
+		header[k] = vs
+	}
+	req.Header = header
+	if len(req.Header.Get("User-Agent")) == 0 {
+		req.Header.Set("User-Agent", UserAgent)
+	}
+	retries := 0
+	if isIdempotent(req) {
+		retries = c.MaxRetries
+	}
+	for n := 0; ; n++ {
+		if n > 0 && req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return
+			}
+		}
+		resp, err = c.client().Do(req)
+		if n >= retries || ctx.Err() != nil {
+			return
+		}
+		if err == nil && !isRetryableStatus(resp.StatusCode) {
+			return
+		}
+		wait := c.backoff(n, resp)
+		if resp != nil {
+			// drain for connection reuse
+			io.CopyN(ioutil.Discard, resp.Body, 4096)
+			resp.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// readBody reads the body with MaxBodySize
+func (c *HTTPClient) readBody(r io.Reader) ([]byte, error) {
+	max := c.MaxBodySize
+	if max == 0 {
+		max = DefaultMaxBodySize
+	}
+	if max < 0 {
+		return ioutil.ReadAll(r)
+	}
+	buf, err := ioutil.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(buf)) > max {
+		return nil, ErrBodyTooLarge
+	}
+	return buf, nil
+}
+
+// newHTTPError creates a HTTPError, and decodes message from a JSON body
+func newHTTPError(req *http.Request, resp *http.Response) *HTTPError {
+	e := &HTTPError{Method: req.Method, URL: req.URL.String(), StatusCode: resp.StatusCode}
+	e.Body, _ = ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+	if !strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		return e
+	}
+	var m map[string]interface{}
+	if json.Unmarshal(e.Body, &m) != nil {
+		return e
+	}
+	for _, k := range []string{"message", "error_description", "error", "msg", "detail"} {
+		switch v := m[k].(type) {
+		case string:
+			if len(v) > 0 {
+				e.Message = v
+				return e
+			}
+		case map[string]interface{}:
+			// {"error": {"message": "..."}}
+			if s, ok := v["message"].(string); ok {
+				e.Message = s
+				return e
+			}
+		}
+	}
+	return e
+}
+
+// Call makes a request, returns the response body, non 2xx responses are returned as *HTTPError
+func (c *HTTPClient) Call(ctx context.Context, method, url string, header http.Header, body []byte) ([]byte, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, r)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	resp, err := c.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, newHTTPError(req, resp)
+	}
+	return c.readBody(resp.Body)
+}
+
+// Get gets the specified resource
+func (c *HTTPClient) Get(ctx context.Context, url string) ([]byte, error) {
+	return c.Call(ctx, "GET", url, nil, nil)
+}
+
+// GetJSON gets the specified resource and decodes it into v
+func (c *HTTPClient) GetJSON(ctx context.Context, url string, v interface{}) error {
+	buf, err := c.Call(ctx, "GET", url, http.Header{"Accept": {"application/json"}}, nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, v)
+}
+
+// PostJSON posts body encoded as JSON, and decodes the response into v if not nil
+func (c *HTTPClient) PostJSON(ctx context.Context, url string, body, v interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	header := http.Header{"Content-Type": {"application/json"}, "Accept": {"application/json"}}
+	buf, err := c.Call(ctx, "POST", url, header, data)
+	if err != nil || v == nil {
+		return err
+	}
+	return json.Unmarshal(buf, v)
+}
+
+// newChecksum parses checksum in form "algorithm:hex", like "sha256:2cf24d...",
+// algorithms are md5, sha1, sha256 and sha512
+func newChecksum(checksum string) (h hash.Hash, sum []byte, err error) {
+	i := strings.Index(checksum, ":")
+	if i < 0 {
+		err = fmt.Errorf("com: invalid checksum %q", checksum)
+		return
+	}
+	switch strings.ToLower(checksum[:i]) {
+	case "md5":
+		h = md5.New()
+	case "sha1":
+		h = sha1.New()
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		err = fmt.Errorf("com: unsupported checksum algorithm %q", checksum[:i])
+		return
+	}
+	if sum, err = hex.DecodeString(checksum[i+1:]); err != nil || len(sum) != h.Size() {
+		err = fmt.Errorf("com: invalid checksum %q", checksum)
+	}
+	return
+}
+
+// Download downloads the url to file, the checksum in form "sha256:hex" is verified if not empty,
+// content is written to a temporary file in the same directory and renamed when complete,
+// MaxBodySize does not apply
+func (c *HTTPClient) Download(ctx context.Context, url, fileName, checksum string) (err error) {
+	var h hash.Hash
+	var sum []byte
+	if len(checksum) > 0 {
+		if h, sum, err = newChecksum(checksum); err != nil {
+			return
+		}
+	}
+	var req *http.Request
+	if req, err = http.NewRequest("GET", url, nil); err != nil {
+		return
+	}
+	var resp *http.Response
+	if resp, err = c.Do(ctx, req); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newHTTPError(req, resp)
+	}
+	if err = os.MkdirAll(filepath.Dir(fileName), os.ModePerm); err != nil {
+		return
+	}
+	var f *os.File
+	if f, err = ioutil.TempFile(filepath.Dir(fileName), "."+filepath.Base(fileName)+".*"); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	var w io.Writer = f
+	if h != nil {
+		w = io.MultiWriter(f, h)
+	}
+	if _, err = io.Copy(w, resp.Body); err != nil {
+		return
+	}
+	if h != nil && !bytes.Equal(h.Sum(nil), sum) {
+		err = ErrChecksumMismatch
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	err = os.Rename(f.Name(), fileName)
+	return
+}
+
+// A ChecksumFile is a RawFile with the expected checksum, verified by FetchFiles
+type ChecksumFile interface {
+	RawFile
+	// Checksum returns the checksum in form "algorithm:hex", like "sha256:2cf24d...",
+	// algorithms are md5, sha1, sha256 and sha512
+	Checksum() string
+}
+
+// FetchFiles fetches files specified by the rawURL field concurrently, checksums of ChecksumFile
+// are verified, the first error cancels the others
+func (c *HTTPClient) FetchFiles(ctx context.Context, files []RawFile) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	n := c.Concurrency
+	if n <= 0 {
+		n = DefaultConcurrency
+	}
+	sem := make(chan struct{}, n)
+	wg := &sync.WaitGroup{}
+	once := &sync.Once{}
+	var first error
+
+	for _, file := range files {
+		wg.Add(1)
+		go func(file RawFile) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+			if err := c.fetchFile(ctx, file); err != nil {
+				once.Do(func() {
+					first = fmt.Errorf("com: failed to fetch %s: %v", file.RawUrl(), err)
+					cancel()
+				})
+			}
+		}(file)
+	}
+	wg.Wait()
+	if first == nil {
+		first = ctx.Err()
+	}
+	return first
+}
+
+func (c *HTTPClient) fetchFile(ctx context.Context, file RawFile) error {
+	buf, err := c.Get(ctx, file.RawUrl())
+	if err != nil {
+		return err
+	}
+	if cf, ok := file.(ChecksumFile); ok && len(cf.Checksum()) > 0 {
+		h, sum, err := newChecksum(cf.Checksum())
+		if err != nil {
+			return err
+		}
+		h.Write(buf)
+		if !bytes.Equal(h.Sum(nil), sum) {
+			return ErrChecksumMismatch
+		}
+	}
+	file.SetData(buf)
+	return nil
+}
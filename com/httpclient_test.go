@@ -0,0 +1,186 @@
+package com
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type checksumFile struct {
+	rawFile
+	checksum string
+}
+
+func (cf *checksumFile) Checksum() string {
+	return cf.checksum
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestHTTPClientRetry(t *testing.T) {
+	var hits int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		if r.Method == "POST" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if n < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(r.Header.Get("X-Test")))
+	}))
+	defer s.Close()
+
+	c := &HTTPClient{MaxRetries: 3, RetryWait: time.Millisecond, Header: http.Header{"X-Test": {"ok"}}}
+	buf, err := c.Get(context.Background(), s.URL)
+	if err != nil || string(buf) != "ok" || hits != 3 {
+		t.Fatalf("Get:\n Expect => ok after 3 attempts\n Got => %q %v after %d\n", buf, err, hits)
+	}
+
+	// POST is not idempotent
+	atomic.StoreInt32(&hits, 0)
+	_, err = c.Call(context.Background(), "POST", s.URL, nil, []byte("x"))
+	if he, ok := err.(*HTTPError); !ok || he.StatusCode != 503 || hits != 1 {
+		t.Errorf("Call:\n Expect => 503 after 1 attempt\n Got => %v after %d\n", err, hits)
+	}
+
+	// retried with Idempotency-Key
+	atomic.StoreInt32(&hits, 0)
+	c.Call(context.Background(), "POST", s.URL, http.Header{"Idempotency-Key": {"k"}}, []byte("x"))
+	if hits != 4 {
+		t.Errorf("Call:\n Expect => 4 attempts\n Got => %d\n", hits)
+	}
+}
+
+func TestHTTPClientContext(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer s.Close()
+
+	c := &HTTPClient{MaxRetries: 10, RetryWait: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.Get(ctx, s.URL); err != context.DeadlineExceeded {
+		t.Errorf("Get:\n Expect => %v\n Got => %v\n", context.DeadlineExceeded, err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Get should be canceled by context")
+	}
+}
+
+func TestHTTPClientJSON(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/echo":
+			buf, _ := ioutil.ReadAll(r.Body)
+			w.Write(buf)
+		case "/large":
+			w.Write([]byte(`"` + strings.Repeat("a", 100) + `"`))
+		case "/nested":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"code": 1, "message": "bad thing"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "not_found", "error_description": "no such thing"}`))
+		}
+	}))
+	defer s.Close()
+
+	c := &HTTPClient{MaxBodySize: 64}
+	var out struct{ A int }
+	if err := c.PostJSON(context.Background(), s.URL+"/echo", map[string]int{"A": 1}, &out); err != nil || out.A != 1 {
+		t.Errorf("PostJSON:\n Expect => 1\n Got => %d %v\n", out.A, err)
+	}
+
+	err := c.GetJSON(context.Background(), s.URL+"/missing", &out)
+	if he, ok := err.(*HTTPError); !ok || he.StatusCode != 404 || he.Message != "no such thing" {
+		t.Errorf("GetJSON:\n Expect => 404 no such thing\n Got => %v\n", err)
+	}
+
+	err = c.GetJSON(context.Background(), s.URL+"/nested", &out)
+	if he, ok := err.(*HTTPError); !ok || he.Message != "bad thing" {
+		t.Errorf("GetJSON:\n Expect => bad thing\n Got => %v\n", err)
+	}
+
+	var str string
+	if err := c.GetJSON(context.Background(), s.URL+"/large", &str); err != ErrBodyTooLarge {
+		t.Errorf("GetJSON:\n Expect => %v\n Got => %v\n", ErrBodyTooLarge, err)
+	}
+}
+
+func TestHTTPClientFetchFiles(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer s.Close()
+
+	c := &HTTPClient{Concurrency: 2}
+	files := []RawFile{
+		&rawFile{rawURL: s.URL + "/a"},
+		&checksumFile{rawFile: rawFile{rawURL: s.URL + "/b"}, checksum: sha256Hex("/b")},
+		&rawFile{rawURL: s.URL + "/c"},
+	}
+	if err := c.FetchFiles(context.Background(), files); err != nil {
+		t.Fatalf("FetchFiles:\n Expect => %v\n Got => %v\n", nil, err)
+	}
+	for i, p := range []string{"/a", "/b", "/c"} {
+		if string(files[i].Data()) != p {
+			t.Errorf("FetchFiles:\n Expect => %s\n Got => %s\n", p, files[i].Data())
+		}
+	}
+
+	files = []RawFile{
+		&checksumFile{rawFile: rawFile{rawURL: s.URL + "/b"}, checksum: sha256Hex("/x")},
+	}
+	if err := c.FetchFiles(context.Background(), files); err == nil || !strings.Contains(err.Error(), ErrChecksumMismatch.Error()) {
+		t.Errorf("FetchFiles:\n Expect => %v\n Got => %v\n", ErrChecksumMismatch, err)
+	}
+}
+
+func TestHTTPClientDownload(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := &HTTPClient{}
+	name := filepath.Join(dir, "sub", "hello.txt")
+	if err := c.Download(context.Background(), s.URL, name, sha256Hex("hello")); err != nil {
+		t.Fatalf("Download:\n Expect => %v\n Got => %v\n", nil, err)
+	}
+	if buf, _ := ioutil.ReadFile(name); string(buf) != "hello" {
+		t.Errorf("Download:\n Expect => hello\n Got => %s\n", buf)
+	}
+
+	bad := filepath.Join(dir, "bad.txt")
+	if err := c.Download(context.Background(), s.URL, bad, sha256Hex("world")); err != ErrChecksumMismatch {
+		t.Errorf("Download:\n Expect => %v\n Got => %v\n", ErrChecksumMismatch, err)
+	}
+	if entries, _ := ioutil.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Download:\n Expect => no file left on failure\n Got => %d entries\n", len(entries))
+	}
+}
@@ -0,0 +1,281 @@
+package com
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrInvalidKey key is not a valid AES key, or key ID is empty or too long
+	ErrInvalidKey = errors.New("com: invalid key")
+	// ErrUnknownKey ciphertext is encrypted with a key not in the keyring
+	ErrUnknownKey = errors.New("com: unknown key")
+	// ErrNoPrimaryKey keyring has no primary key to encrypt with
+	ErrNoPrimaryKey = errors.New("com: no primary key")
+	// ErrInvalidCiphertext ciphertext is malformed, truncated or tampered
+	ErrInvalidCiphertext = errors.New("com: invalid ciphertext")
+)
+
+const (
+	keyringVersionMessage byte = 1
+	keyringVersionStream  byte = 2
+)
+
+// Keyring a set of AES keys identified by key IDs, for AES-GCM encryption
+// with key rotation. The primary key encrypts, all keys decrypt, the key ID
+// is embedded in the ciphertext header.
+//
+// To rotate, add the new key, make it primary, re-encrypt or let data expire,
+// then remove the old key.
+type Keyring struct {
+	mtx     *sync.RWMutex
+	keys    map[string][]byte
+	primary string
+}
+
+// NewKeyring create a new empty keyring
+func NewKeyring() *Keyring {
+	return &Keyring{
+		mtx:  &sync.RWMutex{},
+		keys: map[string][]byte{},
+	}
+}
+
+// ParseKeyring parses a keyring from comma separated "id:base64key" pairs,
+// the first key is the primary, ie: "2:base64key,1:base64key". Standard and
+// URL base64 with or without padding are accepted.
+func ParseKeyring(s string) (kr *Keyring, err error) {
+	kr = NewKeyring()
+	for i, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		j := strings.IndexByte(pair, ':')
+		if j < 0 {
+			return nil, ErrInvalidKey
+		}
+		var key []byte
+		if key, err = decodeBase64Key(pair[j+1:]); err != nil {
+			return nil, ErrInvalidKey
+		}
+		if err = kr.AddKey(pair[:j], key); err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			kr.SetPrimary(pair[:j])
+		}
+	}
+	return
+}
+
+func decodeBase64Key(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "-_") {
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+// AddKey adds a decrypt-only key, key must be 16, 24 or 32 bytes to select
+// AES-128, AES-192 or AES-256, id must be 1 to 255 bytes. Adding an existing
+// id replaces the key.
+func (kr *Keyring) AddKey(id string, key []byte) error {
+	if len(id) == 0 || len(id) > 255 {
+		return ErrInvalidKey
+	}
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return ErrInvalidKey
+	}
+	kr.mtx.Lock()
+	defer kr.mtx.Unlock()
+	kr.keys[id] = append([]byte(nil), key...)
+	return nil
+}
+
+// SetPrimary makes the key of id the primary key for encryption
+func (kr *Keyring) SetPrimary(id string) error {
+	kr.mtx.Lock()
+	defer kr.mtx.Unlock()
+	if _, ok := kr.keys[id]; !ok {
+		return ErrUnknownKey
+	}
+	kr.primary = id
+	return nil
+}
+
+// RemoveKey removes a key, data encrypted with it can no longer be
+// decrypted. The primary key can not be removed.
+func (kr *Keyring) RemoveKey(id string) error {
+	kr.mtx.Lock()
+	defer kr.mtx.Unlock()
+	if _, ok := kr.keys[id]; !ok {
+		return ErrUnknownKey
+	}
+	if id == kr.primary {
+		return errors.New("com: can not remove primary key")
+	}
+	delete(kr.keys, id)
+	return nil
+}
+
+// Primary returns the id of primary key, empty if not set
+func (kr *Keyring) Primary() string {
+	kr.mtx.RLock()
+	defer kr.mtx.RUnlock()
+	return kr.primary
+}
+
+// KeyIDs returns sorted ids of all keys
+func (kr *Keyring) KeyIDs() []string {
+	kr.mtx.RLock()
+	defer kr.mtx.RUnlock()
+	ids := make([]string, 0, len(kr.keys))
+	for id := range kr.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (kr *Keyring) primaryKey() (id string, key []byte, err error) {
+	kr.mtx.RLock()
+	defer kr.mtx.RUnlock()
+	if len(kr.primary) == 0 {
+		err = ErrNoPrimaryKey
+		return
+	}
+	id, key = kr.primary, kr.keys[kr.primary]
+	return
+}
+
+func (kr *Keyring) key(id string) ([]byte, error) {
+	kr.mtx.RLock()
+	defer kr.mtx.RUnlock()
+	key, ok := kr.keys[id]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// keyringHeader returns version, length of key id and key id
+func keyringHeader(version byte, id string) []byte {
+	h := make([]byte, 2, 2+len(id))
+	h[0], h[1] = version, byte(len(id))
+	return append(h, id...)
+}
+
+// parseKeyringHeader returns the key id and the length of header
+func parseKeyringHeader(version byte, data []byte) (id string, n int, err error) {
+	if len(data) < 2 || data[0] != version || data[1] == 0 || len(data) < 2+int(data[1]) {
+		err = ErrInvalidCiphertext
+		return
+	}
+	n = 2 + int(data[1])
+	id = string(data[2:n])
+	return
+}
+
+// keyringAD returns the header followed by additional data of caller
+func keyringAD(header, ad []byte) []byte {
+	if len(ad) == 0 {
+		return header
+	}
+	return append(append(make([]byte, 0, len(header)+len(ad)), header...), ad...)
+}
+
+// Encrypt encrypts plaintext with the primary key using AES in GCM mode. The
+// output is version, key id, nonce and sealed plaintext, the header and ad
+// are authenticated as additional data. ad binds the ciphertext to its
+// context, ie: the key it is stored under, it is not included in the output
+// and the same ad must be given to Decrypt.
+func (kr *Keyring) Encrypt(plaintext, ad []byte) ([]byte, error) {
+	id, key, err := kr.primaryKey()
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	header := keyringHeader(keyringVersionMessage, id)
+	out := make([]byte, len(header)+gcm.NonceSize(), len(header)+gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	copy(out, header)
+	if _, err := rand.Read(out[len(header):]); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(out, out[len(header):], plaintext, keyringAD(header, ad)), nil
+}
+
+// Decrypt decrypts ciphertext created by Encrypt with the key it names and
+// the same ad
+func (kr *Keyring) Decrypt(ciphertext, ad []byte) ([]byte, error) {
+	id, n, err := parseKeyringHeader(keyringVersionMessage, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	key, err := kr.key(id)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < n+gcm.NonceSize()+gcm.Overhead() {
+		return nil, ErrInvalidCiphertext
+	}
+	nonce := ciphertext[n : n+gcm.NonceSize()]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext[n+gcm.NonceSize():], keyringAD(ciphertext[:n], ad))
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
+
+// KeyID returns the id of key which ciphertext created by Encrypt or
+// NewEncryptWriter is encrypted with
+func KeyID(ciphertext []byte) (string, error) {
+	if len(ciphertext) > 0 && ciphertext[0] == keyringVersionStream {
+		id, _, err := parseKeyringHeader(keyringVersionStream, ciphertext)
+		return id, err
+	}
+	id, _, err := parseKeyringHeader(keyringVersionMessage, ciphertext)
+	return id, err
+}
+
+// Rotate re-encrypts ciphertext created by Encrypt with ad with the primary
+// key if it is encrypted with another key, rotated reports whether it did.
+func (kr *Keyring) Rotate(ciphertext, ad []byte) (out []byte, rotated bool, err error) {
+	var id string
+	if id, _, err = parseKeyringHeader(keyringVersionMessage, ciphertext); err != nil {
+		return
+	}
+	if id == kr.Primary() {
+		out = ciphertext
+		return
+	}
+	var plaintext []byte
+	if plaintext, err = kr.Decrypt(ciphertext, ad); err != nil {
+		return
+	}
+	if out, err = kr.Encrypt(plaintext, ad); err != nil {
+		return
+	}
+	rotated = true
+	return
+}
@@ -0,0 +1,208 @@
+package com
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func newTestKeyring(t *testing.T, ids ...string) *Keyring {
+	kr := NewKeyring()
+	for _, id := range ids {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			t.Fatal(err)
+		}
+		if err := kr.AddKey(id, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(ids) > 0 {
+		kr.SetPrimary(ids[0])
+	}
+	return kr
+}
+
+func TestKeyring(t *testing.T) {
+	kr := NewKeyring()
+	if _, err := kr.Encrypt([]byte("x"), nil); err != ErrNoPrimaryKey {
+		t.Errorf("Encrypt:\n Expect => %v\n Got => %v\n", ErrNoPrimaryKey, err)
+	}
+	if err := kr.AddKey("1", make([]byte, 10)); err != ErrInvalidKey {
+		t.Errorf("AddKey:\n Expect => %v\n Got => %v\n", ErrInvalidKey, err)
+	}
+	if err := kr.SetPrimary("1"); err != ErrUnknownKey {
+		t.Errorf("SetPrimary:\n Expect => %v\n Got => %v\n", ErrUnknownKey, err)
+	}
+
+	kr = newTestKeyring(t, "1")
+	plaintext := []byte("this will be encrypted")
+	c1, err := kr.Encrypt(plaintext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := KeyID(c1); id != "1" {
+		t.Errorf("KeyID:\n Expect => 1\n Got => %s\n", id)
+	}
+
+	// rotate, old key becomes decrypt-only
+	key := make([]byte, 16)
+	rand.Read(key)
+	kr.AddKey("2", key)
+	kr.SetPrimary("2")
+	c2, _ := kr.Encrypt(plaintext, nil)
+	if id, _ := KeyID(c2); id != "2" {
+		t.Errorf("KeyID:\n Expect => 2\n Got => %s\n", id)
+	}
+	for _, c := range [][]byte{c1, c2} {
+		if p, err := kr.Decrypt(c, nil); err != nil || !bytes.Equal(p, plaintext) {
+			t.Errorf("Decrypt:\n Expect => %s\n Got => %s %v\n", plaintext, p, err)
+		}
+	}
+
+	r, rotated, err := kr.Rotate(c1, nil)
+	if err != nil || !rotated {
+		t.Fatalf("Rotate:\n Expect => rotated\n Got => %v %v\n", rotated, err)
+	}
+	if _, rotated, _ = kr.Rotate(r, nil); rotated {
+		t.Errorf("Rotate:\n Expect => not rotated\n Got => rotated\n")
+	}
+
+	if err := kr.RemoveKey("2"); err == nil {
+		t.Errorf("RemoveKey:\n Expect => error removing primary\n Got => %v\n", err)
+	}
+	kr.RemoveKey("1")
+	if _, err := kr.Decrypt(c1, nil); err != ErrUnknownKey {
+		t.Errorf("Decrypt:\n Expect => %v\n Got => %v\n", ErrUnknownKey, err)
+	}
+	if p, err := kr.Decrypt(r, nil); err != nil || !bytes.Equal(p, plaintext) {
+		t.Errorf("Decrypt:\n Expect => %s\n Got => %s %v\n", plaintext, p, err)
+	}
+
+	// ciphertext is bound to ad
+	c3, _ := kr.Encrypt(plaintext, []byte("a"))
+	if p, err := kr.Decrypt(c3, []byte("a")); err != nil || !bytes.Equal(p, plaintext) {
+		t.Errorf("Decrypt:\n Expect => %s\n Got => %s %v\n", plaintext, p, err)
+	}
+	for _, ad := range [][]byte{nil, []byte("b")} {
+		if _, err := kr.Decrypt(c3, ad); err != ErrInvalidCiphertext {
+			t.Errorf("Decrypt(%q):\n Expect => %v\n Got => %v\n", ad, ErrInvalidCiphertext, err)
+		}
+	}
+
+	// the key id is authenticated
+	c2[len(c2)-1] ^= 1
+	if _, err := kr.Decrypt(c2, nil); err != ErrInvalidCiphertext {
+		t.Errorf("Decrypt:\n Expect => %v\n Got => %v\n", ErrInvalidCiphertext, err)
+	}
+	for _, c := range [][]byte{nil, {1}, {1, 1, '2'}, {2, 1, '2', 0}} {
+		if _, err := kr.Decrypt(c, nil); err != ErrInvalidCiphertext {
+			t.Errorf("Decrypt:\n Expect => %v\n Got => %v\n", ErrInvalidCiphertext, err)
+		}
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	k1 := base64.StdEncoding.EncodeToString(make([]byte, 16))
+	k2 := base64.RawURLEncoding.EncodeToString(bytes.Repeat([]byte{0xff}, 32))
+	kr, err := ParseKeyring("b:" + k2 + ", a:" + k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kr.Primary() != "b" || len(kr.KeyIDs()) != 2 {
+		t.Errorf("ParseKeyring:\n Expect => b of [a b]\n Got => %s of %v\n", kr.Primary(), kr.KeyIDs())
+	}
+	for _, s := range []string{"", "a", "a:x", "a:" + base64.StdEncoding.EncodeToString(make([]byte, 8))} {
+		if _, err := ParseKeyring(s); err != ErrInvalidKey {
+			t.Errorf("ParseKeyring(%q):\n Expect => %v\n Got => %v\n", s, ErrInvalidKey, err)
+		}
+	}
+}
+
+func encryptStream(t *testing.T, kr *Keyring, plaintext []byte, chunkSize int) []byte {
+	var buf bytes.Buffer
+	w, err := kr.newEncryptWriter(&buf, chunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// odd sized writes across chunk boundaries
+	for p := plaintext; len(p) > 0; {
+		n := 7
+		if n > len(p) {
+			n = len(p)
+		}
+		w.Write(p[:n])
+		p = p[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decryptStream(kr *Keyring, ciphertext []byte) ([]byte, error) {
+	r, err := kr.NewDecryptReader(bytes.NewReader(ciphertext))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+func TestEncryptStream(t *testing.T) {
+	kr := newTestKeyring(t, "1")
+	for _, size := range []int{0, 1, 15, 16, 17, 32, 100} {
+		plaintext := make([]byte, size)
+		rand.Read(plaintext)
+		c := encryptStream(t, kr, plaintext, 16)
+		if id, _ := KeyID(c); id != "1" {
+			t.Errorf("KeyID:\n Expect => 1\n Got => %s\n", id)
+		}
+		p, err := decryptStream(kr, c)
+		if err != nil || !bytes.Equal(p, plaintext) {
+			t.Errorf("decrypt %d bytes:\n Expect => %x\n Got => %x %v\n", size, plaintext, p, err)
+		}
+	}
+
+	// default chunk size
+	plaintext := make([]byte, StreamChunkSize*2+10)
+	rand.Read(plaintext)
+	var buf bytes.Buffer
+	w, _ := kr.NewEncryptWriter(&buf)
+	io.Copy(w, bytes.NewReader(plaintext))
+	w.Close()
+	if p, err := decryptStream(kr, buf.Bytes()); err != nil || !bytes.Equal(p, plaintext) {
+		t.Errorf("decrypt:\n Expect => %d bytes\n Got => %d bytes %v\n", len(plaintext), len(p), err)
+	}
+}
+
+func TestDecryptStreamTampered(t *testing.T) {
+	kr := newTestKeyring(t, "1")
+	plaintext := make([]byte, 40)
+	c := encryptStream(t, kr, plaintext, 16)
+	header := 2 + 1 + 4 + streamSaltSize
+	chunk := 16 + 16
+
+	cases := map[string][]byte{
+		"truncated at chunk boundary": c[:header+chunk],
+		"truncated in chunk":          c[:len(c)-1],
+		"last chunk dropped":          c[:header+2*chunk],
+		"chunks reordered":            append(append(append([]byte{}, c[:header]...), c[header+chunk:header+2*chunk]...), c[header:header+chunk]...),
+		"flipped bit":                 append(append([]byte{}, c[:header+3]...), append([]byte{c[header+3] ^ 1}, c[header+4:]...)...),
+		"trailing data":               append(append([]byte{}, c...), 0),
+	}
+	for name, tc := range cases {
+		if _, err := decryptStream(kr, tc); err != ErrInvalidCiphertext {
+			t.Errorf("%s:\n Expect => %v\n Got => %v\n", name, ErrInvalidCiphertext, err)
+		}
+	}
+
+	if _, err := NewKeyring().NewDecryptReader(bytes.NewReader(c)); err != ErrUnknownKey {
+		t.Errorf("NewDecryptReader:\n Expect => %v\n Got => %v\n", ErrUnknownKey, err)
+	}
+	if _, err := kr.NewDecryptReader(bytes.NewReader(c[:5])); err != ErrInvalidCiphertext {
+		t.Errorf("NewDecryptReader:\n Expect => %v\n Got => %v\n", ErrInvalidCiphertext, err)
+	}
+}
@@ -35,6 +35,7 @@ func init() {
 }
 
 // AESGCMEncrypt encrypts plaintext with the given key using AES in GCM mode.
+// See Keyring for key rotation and streaming encryption.
 func AESGCMEncrypt(key, plaintext []byte) ([]byte, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
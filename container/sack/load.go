@@ -0,0 +1,90 @@
+package sack
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"landzero.net/x/encoding/toml"
+	"landzero.net/x/encoding/yaml"
+)
+
+// KeySeparator separator of nested keys, nested maps are flattened with it when loading
+const KeySeparator = "."
+
+// FromMap create a Sack from a nested map, keys of nested maps are joined with KeySeparator,
+// e.g. {"db": {"host": "x"}} becomes {"db.host": "x"}
+func FromMap(m map[string]interface{}) Sack {
+	s := Sack{}
+	flattenInto(s, "", m)
+	return s
+}
+
+func flattenInto(s Sack, prefix string, v interface{}) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		for k, v := range m {
+			flattenInto(s, prefix+k+KeySeparator, v)
+		}
+	case map[interface{}]interface{}:
+		for k, v := range m {
+			flattenInto(s, prefix+fmt.Sprint(k)+KeySeparator, v)
+		}
+	default:
+		s[strings.TrimSuffix(prefix, KeySeparator)] = v
+	}
+}
+
+// FromYAML create a Sack from a YAML document, see FromMap
+func FromYAML(data []byte) (s Sack, err error) {
+	m := map[string]interface{}{}
+	if err = yaml.Unmarshal(data, &m); err != nil {
+		return
+	}
+	s = FromMap(m)
+	return
+}
+
+// FromTOML create a Sack from a TOML document, see FromMap
+func FromTOML(data []byte) (s Sack, err error) {
+	m := map[string]interface{}{}
+	if err = toml.Unmarshal(data, &m); err != nil {
+		return
+	}
+	s = FromMap(m)
+	return
+}
+
+// FromEnv create a Sack from environment variables with the given prefix,
+// the prefix is trimmed, the rest is lower cased, and "__" is replaced with KeySeparator,
+// e.g. with prefix "APP_", APP_DB__MAX_CONNS=10 becomes {"db.max_conns": "10"}
+func FromEnv(prefix string) Sack {
+	s := Sack{}
+	for _, kv := range os.Environ() {
+		i := strings.Index(kv, "=")
+		if i < 0 || !strings.HasPrefix(kv[:i], prefix) {
+			continue
+		}
+		k := strings.ToLower(strings.TrimPrefix(kv[:i], prefix))
+		if len(k) == 0 {
+			continue
+		}
+		s[strings.Replace(k, "__", KeySeparator, -1)] = kv[i+1:]
+	}
+	return s
+}
+
+// Layers chain Sacks as parents in order, the first one is the root, the last one is returned,
+// e.g. Layers(defaults, fileSack, envSack) lets environment variables override file and defaults
+func Layers(layers ...Sack) (s Sack) {
+	for _, l := range layers {
+		if l == nil {
+			continue
+		}
+		if s != nil {
+			l.SetParent(s)
+		}
+		s = l
+	}
+	return
+}
@@ -0,0 +1,39 @@
+package sack
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFromYAML(t *testing.T) {
+	s, err := FromYAML([]byte("db:\n  host: localhost\n  port: 3306\nname: app\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.String("db.host", "") != "localhost" || s.Int("db.port", 0) != 3306 || s.String("name", "") != "app" {
+		t.Errorf("bad yaml sack %v", s)
+	}
+}
+
+func TestFromTOML(t *testing.T) {
+	s, err := FromTOML([]byte("name = \"app\"\n[db]\nhost = \"localhost\"\nport = 3306\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.String("db.host", "") != "localhost" || s.Int("db.port", 0) != 3306 || s.String("name", "") != "app" {
+		t.Errorf("bad toml sack %v", s)
+	}
+}
+
+func TestFromEnvLayers(t *testing.T) {
+	os.Setenv("SACKTEST_DB__MAX_CONNS", "20")
+	defer os.Unsetenv("SACKTEST_DB__MAX_CONNS")
+	env := FromEnv("SACKTEST_")
+	if env.Int("db.max_conns", 0) != 20 {
+		t.Errorf("bad env sack %v", env)
+	}
+	s := Layers(Sack{"db.max_conns": 10, "db.host": "localhost"}, nil, env)
+	if s.Int("db.max_conns", 0) != 20 || s.String("db.host", "") != "localhost" {
+		t.Errorf("bad layers %v", s.Flatten())
+	}
+}
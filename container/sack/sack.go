@@ -1,10 +1,24 @@
 package sack
 
+import (
+	"sort"
+	"time"
+)
+
 // ParentKey the key in sack indicate it's parent, don't use it directly
 const ParentKey = "+_-PARENT-_+"
 
+type tombstone struct{}
+
+// Tombstone the value marks a key as deleted, it hides the value of parents, don't use it directly
+var Tombstone interface{} = tombstone{}
+
 // Sack is basically a map from string to anything
 // it's nil-safe, means all function call won't panic if s == nil
+//
+// A key present in Sack overrides the same key in parents, even if the value is nil,
+// use Delete to hide a key of parents, and Unset to fallback to parents again.
+// Sack is not safe for concurrent use, see SyncSack.
 type Sack map[string]interface{}
 
 // Parent get the parent
@@ -26,21 +40,26 @@ func (s Sack) SetParent(p Sack) {
 	s[ParentKey] = p
 }
 
-// Value get a value from Sack, if not found, find in it's parent
-func (s Sack) Value(k string) (v interface{}) {
-	if s == nil {
-		return nil
-	}
-	v = s[k]
-	if v == nil {
-		p := s.Parent()
-		if p != nil {
-			v = p.Value(k)
+// Lookup get a value from Sack, if not found, find in it's parent,
+// ok is false if the key is not found or deleted
+func (s Sack) Lookup(k string) (v interface{}, ok bool) {
+	for ; s != nil; s = s.Parent() {
+		if v, ok = s[k]; ok {
+			if v == Tombstone {
+				return nil, false
+			}
+			return
 		}
 	}
 	return
 }
 
+// Value get a value from Sack, if not found, find in it's parent
+func (s Sack) Value(k string) (v interface{}) {
+	v, _ = s.Lookup(k)
+	return
+}
+
 // Set set a value for key
 func (s Sack) Set(k string, v interface{}) {
 	if s == nil {
@@ -48,3 +67,101 @@ func (s Sack) Set(k string, v interface{}) {
 	}
 	s[k] = v
 }
+
+// Delete mark a key as deleted, the value in parents is hidden as well
+func (s Sack) Delete(k string) {
+	if s == nil {
+		return
+	}
+	s[k] = Tombstone
+}
+
+// Unset remove a key from Sack, the value in parents becomes visible again
+func (s Sack) Unset(k string) {
+	if s == nil {
+		return
+	}
+	delete(s, k)
+}
+
+// Flatten returns all visible key-values of Sack and it's parents in a single map,
+// values in Sack override values in parents, deleted keys are omitted
+func (s Sack) Flatten() map[string]interface{} {
+	if s == nil {
+		return map[string]interface{}{}
+	}
+	m := s.Parent().Flatten()
+	for k, v := range s {
+		if k == ParentKey {
+			continue
+		}
+		if v == Tombstone {
+			delete(m, k)
+		} else {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// Keys returns sorted visible keys of Sack and it's parents
+func (s Sack) Keys() []string {
+	m := s.Flatten()
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String get a value as string, def is returned if not found or not convertible
+func (s Sack) String(k string, def string) string {
+	if v, ok := s.Lookup(k); ok {
+		if r, ok := asString(v); ok {
+			return r
+		}
+	}
+	return def
+}
+
+// Int get a value as int, def is returned if not found or not convertible
+func (s Sack) Int(k string, def int) int {
+	if v, ok := s.Lookup(k); ok {
+		if r, ok := asInt(v); ok {
+			return r
+		}
+	}
+	return def
+}
+
+// Float64 get a value as float64, def is returned if not found or not convertible
+func (s Sack) Float64(k string, def float64) float64 {
+	if v, ok := s.Lookup(k); ok {
+		if r, ok := asFloat64(v); ok {
+			return r
+		}
+	}
+	return def
+}
+
+// Bool get a value as bool, def is returned if not found or not convertible
+func (s Sack) Bool(k string, def bool) bool {
+	if v, ok := s.Lookup(k); ok {
+		if r, ok := asBool(v); ok {
+			return r
+		}
+	}
+	return def
+}
+
+// Duration get a value as time.Duration, strings are parsed by time.ParseDuration,
+// numbers are treated as nanoseconds, def is returned if not found or not convertible
+func (s Sack) Duration(k string, def time.Duration) time.Duration {
+	if v, ok := s.Lookup(k); ok {
+		if r, ok := asDuration(v); ok {
+			return r
+		}
+	}
+	return def
+}
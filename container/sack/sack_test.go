@@ -1,6 +1,11 @@
 package sack
 
-import "testing"
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
 
 func TestSack_ParentGetParent(t *testing.T) {
 	p := Sack{"a": "b"}
@@ -33,3 +38,73 @@ func TestSack_SetValue(t *testing.T) {
 	n.Set("e", "f") // should not PANIC
 	n.Value("h")    // should not PANIC
 }
+
+func TestSack_NilOverrideDelete(t *testing.T) {
+	p := Sack{"a": "b", "c": "d", "e": "f"}
+	s := Sack{"a": nil}
+	s.SetParent(p)
+	if v, ok := s.Lookup("a"); !ok || v != nil {
+		t.Errorf("nil should override parent value")
+	}
+	s.Delete("c")
+	if _, ok := s.Lookup("c"); ok {
+		t.Errorf("deleted key should be hidden")
+	}
+	s.Unset("a")
+	if s.Value("a") != "b" {
+		t.Errorf("unset key should fallback to parent")
+	}
+	keys := s.Keys()
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "e" {
+		t.Errorf("bad keys %v", keys)
+	}
+	if f := s.Flatten(); len(f) != 2 || f["e"] != "f" {
+		t.Errorf("bad flatten %v", f)
+	}
+	var n Sack
+	n.Delete("a") // should not PANIC
+	n.Unset("a")  // should not PANIC
+	if len(n.Keys()) != 0 {
+		t.Errorf("nil sack should have no keys")
+	}
+}
+
+func TestSack_Typed(t *testing.T) {
+	s := Sack{"s": "str", "i": "10", "f": 1.5, "b": "true", "d": "1m", "n": 3}
+	if s.String("s", "") != "str" || s.String("n", "") != "3" || s.String("x", "def") != "def" {
+		t.Errorf("bad String")
+	}
+	if s.Int("i", 0) != 10 || s.Int("n", 0) != 3 || s.Int("s", 7) != 7 {
+		t.Errorf("bad Int")
+	}
+	if s.Float64("f", 0) != 1.5 || s.Float64("i", 0) != 10 {
+		t.Errorf("bad Float64")
+	}
+	if !s.Bool("b", false) || !s.Bool("n", false) || !s.Bool("s", true) {
+		t.Errorf("bad Bool")
+	}
+	if s.Duration("d", 0) != time.Minute || s.Duration("n", 0) != 3 || s.Duration("x", time.Second) != time.Second {
+		t.Errorf("bad Duration")
+	}
+}
+
+func TestSyncSack(t *testing.T) {
+	ss := NewSyncSack(Sack{"a": "b"})
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ss.Set(strconv.Itoa(i), i)
+			ss.Int(strconv.Itoa(i), 0)
+			ss.Keys()
+		}(i)
+	}
+	wg.Wait()
+	if len(ss.Keys()) != 11 || ss.Value("a") != "b" || ss.Int("3", 0) != 3 {
+		t.Errorf("bad sync sack %v", ss.Flatten())
+	}
+	if ss.Snapshot().Parent().Value("a") != "b" {
+		t.Errorf("snapshot should share parent")
+	}
+}
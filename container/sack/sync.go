@@ -0,0 +1,130 @@
+package sack
+
+import (
+	"sync"
+	"time"
+)
+
+// SyncSack a Sack safe for concurrent use, parent is only read, it should not be modified concurrently
+type SyncSack struct {
+	s   Sack
+	mtx *sync.RWMutex
+}
+
+// NewSyncSack create a new SyncSack with the given parent, parent can be nil
+func NewSyncSack(parent Sack) *SyncSack {
+	s := Sack{}
+	if parent != nil {
+		s.SetParent(parent)
+	}
+	return &SyncSack{s: s, mtx: &sync.RWMutex{}}
+}
+
+// Parent get the parent
+func (ss *SyncSack) Parent() Sack {
+	ss.mtx.RLock()
+	defer ss.mtx.RUnlock()
+	return ss.s.Parent()
+}
+
+// SetParent set the parent
+func (ss *SyncSack) SetParent(p Sack) {
+	ss.mtx.Lock()
+	defer ss.mtx.Unlock()
+	ss.s.SetParent(p)
+}
+
+// Lookup see Sack.Lookup
+func (ss *SyncSack) Lookup(k string) (interface{}, bool) {
+	ss.mtx.RLock()
+	defer ss.mtx.RUnlock()
+	return ss.s.Lookup(k)
+}
+
+// Value see Sack.Value
+func (ss *SyncSack) Value(k string) interface{} {
+	ss.mtx.RLock()
+	defer ss.mtx.RUnlock()
+	return ss.s.Value(k)
+}
+
+// Set see Sack.Set
+func (ss *SyncSack) Set(k string, v interface{}) {
+	ss.mtx.Lock()
+	defer ss.mtx.Unlock()
+	ss.s.Set(k, v)
+}
+
+// Delete see Sack.Delete
+func (ss *SyncSack) Delete(k string) {
+	ss.mtx.Lock()
+	defer ss.mtx.Unlock()
+	ss.s.Delete(k)
+}
+
+// Unset see Sack.Unset
+func (ss *SyncSack) Unset(k string) {
+	ss.mtx.Lock()
+	defer ss.mtx.Unlock()
+	ss.s.Unset(k)
+}
+
+// Flatten see Sack.Flatten
+func (ss *SyncSack) Flatten() map[string]interface{} {
+	ss.mtx.RLock()
+	defer ss.mtx.RUnlock()
+	return ss.s.Flatten()
+}
+
+// Keys see Sack.Keys
+func (ss *SyncSack) Keys() []string {
+	ss.mtx.RLock()
+	defer ss.mtx.RUnlock()
+	return ss.s.Keys()
+}
+
+// String see Sack.String
+func (ss *SyncSack) String(k string, def string) string {
+	ss.mtx.RLock()
+	defer ss.mtx.RUnlock()
+	return ss.s.String(k, def)
+}
+
+// Int see Sack.Int
+func (ss *SyncSack) Int(k string, def int) int {
+	ss.mtx.RLock()
+	defer ss.mtx.RUnlock()
+	return ss.s.Int(k, def)
+}
+
+// Float64 see Sack.Float64
+func (ss *SyncSack) Float64(k string, def float64) float64 {
+	ss.mtx.RLock()
+	defer ss.mtx.RUnlock()
+	return ss.s.Float64(k, def)
+}
+
+// Bool see Sack.Bool
+func (ss *SyncSack) Bool(k string, def bool) bool {
+	ss.mtx.RLock()
+	defer ss.mtx.RUnlock()
+	return ss.s.Bool(k, def)
+}
+
+// Duration see Sack.Duration
+func (ss *SyncSack) Duration(k string, def time.Duration) time.Duration {
+	ss.mtx.RLock()
+	defer ss.mtx.RUnlock()
+	return ss.s.Duration(k, def)
+}
+
+// Snapshot returns a copy of own entries as a Sack, sharing the same parent
+func (ss *SyncSack) Snapshot() Sack {
+	ss.mtx.RLock()
+	defer ss.mtx.RUnlock()
+	s := make(Sack, len(ss.s))
+	for k, v := range ss.s {
+		s[k] = v
+	}
+	return s
+}
@@ -1,12 +1,16 @@
 package orm
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 	"time"
+
+	"landzero.net/x/log"
+	"landzero.net/x/text/inflection"
 )
 
 // DB contains information for current db connection
@@ -28,6 +32,8 @@ type DB struct {
 	callbacks     *Callback
 	dialect       Dialect
 	singularTable bool
+	inflector     *inflection.Inflector
+	tableNames    *safeMap
 }
 
 // Open initialize a new db connection, need to import driver first, e.g:
@@ -135,6 +141,18 @@ func (s *DB) SetLogger(log logger) {
 	s.logger = log
 }
 
+// WithContext clone a new db with the request-scoped logger carried by ctx (see log.NewContext),
+// so that logged SQL can be correlated with the request, e.g:
+//     db.WithContext(ctx.Req.Context()).Find(&users)
+// the logger of current db is kept if ctx carries no logger
+func (s *DB) WithContext(ctx context.Context) *DB {
+	clone := s.clone()
+	if l, ok := log.FromContext(ctx); ok {
+		clone.logger = Logger{l}
+	}
+	return clone
+}
+
 // LogMode set log mode, `true` for detailed logs, `false` for no log, default, will only print error logs
 func (s *DB) LogMode(enable bool) *DB {
 	if enable {
@@ -163,6 +181,20 @@ func (s *DB) SingularTable(enable bool) {
 	s.parent.singularTable = enable
 }
 
+// SetInflector use the inflector for table names of this connection instead of inflection.Default
+func (s *DB) SetInflector(i *inflection.Inflector) {
+	s.parent.inflector = i
+	s.parent.tableNames = newSafeMap()
+}
+
+// Inflector get the inflector for table names of this connection
+func (s *DB) Inflector() *inflection.Inflector {
+	if s.parent.inflector != nil {
+		return s.parent.inflector
+	}
+	return inflection.Default
+}
+
 // NewScope create a scope for current operation
 func (s *DB) NewScope(value interface{}) *Scope {
 	dbClone := s.clone()
@@ -18,6 +18,7 @@ import (
 	_ "landzero.net/x/database/orm/dialects/mysql"
 	"landzero.net/x/database/orm/dialects/postgres"
 	_ "landzero.net/x/database/orm/dialects/sqlite3"
+	"landzero.net/x/text/inflection"
 	"landzero.net/x/time/now"
 )
 
@@ -277,6 +278,36 @@ func TestTableName(t *testing.T) {
 	DB.SingularTable(false)
 }
 
+func TestInflector(t *testing.T) {
+	db, err := orm.Open(DB.Dialect().GetName(), DB.DB())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := inflection.New("en")
+	in.AddIrregular("order", "order_archive")
+	db.SetInflector(in)
+	if db.Inflector() != in {
+		t.Errorf("Inflector should be the one set")
+	}
+
+	if name := db.NewScope(&Order{}).TableName(); name != "order_archive" {
+		t.Errorf("Order's table name should be order_archive, got %v", name)
+	}
+
+	if DB.NewScope(&Order{}).TableName() != "orders" {
+		t.Errorf("Inflector of a connection should not affect others")
+	}
+
+	if db.NewScope(&Cart{}).TableName() != "shopping_cart" {
+		t.Errorf("Cart's table name should be shopping_cart")
+	}
+
+	if DB.Inflector() != inflection.Default {
+		t.Errorf("Inflector should be inflection.Default by default")
+	}
+}
+
 func TestNullValues(t *testing.T) {
 	DB.DropTable(&NullValue{})
 	DB.AutoMigrate(&NullValue{})
@@ -50,6 +50,13 @@ type ModelStruct struct {
 
 // TableName get model's table name
 func (s *ModelStruct) TableName(db *DB) string {
+	if db != nil && db.parent.inflector != nil && s.ModelType != nil {
+		// model structs are shared by connections, table names by a connection's own inflector are cached by the connection
+		if _, ok := reflect.New(s.ModelType).Interface().(tabler); !ok {
+			return DefaultTableNameHandler(db, db.parent.pluralTableName(ToDBName(s.ModelType.Name())))
+		}
+	}
+
 	if s.defaultTableName == "" && db != nil && s.ModelType != nil {
 		// Set default table name
 		if tabler, ok := reflect.New(s.ModelType).Interface().(tabler); ok {
@@ -66,6 +73,18 @@ func (s *ModelStruct) TableName(db *DB) string {
 	return DefaultTableNameHandler(db, s.defaultTableName)
 }
 
+func (s *DB) pluralTableName(name string) string {
+	if s.singularTable {
+		return name
+	}
+	if v := s.tableNames.Get(name); v != "" {
+		return v
+	}
+	v := s.inflector.Plural(name)
+	s.tableNames.Set(name, v)
+	return v
+}
+
 // StructField model field's struct definition
 type StructField struct {
 	DBName          string
@@ -23,6 +23,28 @@ func SetLogger(logger *log.Logger) {
 	internal.Logger = logger
 }
 
+// ProcessLogger returns a function for WrapProcess that logs every command with its
+// duration to logger, failed commands are logged at log.LevelError, others at log.LevelDebug.
+// Combined with a request-scoped logger it correlates commands with the request, e.g:
+//     c := client.WithContext(ctx)
+//     if l, ok := log.FromContext(ctx); ok {
+//         c.WrapProcess(redis.ProcessLogger(l))
+//     }
+func ProcessLogger(logger *log.Logger) func(oldProcess func(cmd Cmder) error) func(cmd Cmder) error {
+	return func(oldProcess func(cmd Cmder) error) func(cmd Cmder) error {
+		return func(cmd Cmder) error {
+			start := time.Now()
+			err := oldProcess(cmd)
+			if err != nil && err != Nil {
+				logger.Errorf("redis: %v %vms: %s", cmd.Args(), int64(time.Since(start)/time.Millisecond), err)
+			} else {
+				logger.Debugf("redis: %v %vms", cmd.Args(), int64(time.Since(start)/time.Millisecond))
+			}
+			return err
+		}
+	}
+}
+
 type baseClient struct {
 	opt      *Options
 	connPool pool.Pooler
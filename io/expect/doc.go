@@ -0,0 +1,8 @@
+// Package expect scripts interactions with commands running in a pty, like expect(1)
+//
+//	e := expect.Test(t, exec.Command("ftp"))
+//	defer e.Close()
+//	e.Expect("Name:")
+//	e.SendLine("anonymous")
+//	e.ExpectEOF()
+package expect
@@ -0,0 +1,394 @@
+// +build !windows
+
+package expect
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"landzero.net/x/encoding/rec"
+	"landzero.net/x/io/pty"
+)
+
+var (
+	// DefaultTimeout default timeout of Expect
+	DefaultTimeout = 10 * time.Second
+	// DefaultSize default terminal size
+	DefaultSize = pty.Winsize{Rows: 24, Cols: 80}
+
+	// ErrClosed the Expecter is closed
+	ErrClosed = errors.New("expect: closed")
+)
+
+// Options options of Spawn
+type Options struct {
+	// Timeout timeout of Expect, default DefaultTimeout
+	Timeout time.Duration
+	// Size terminal size, default DefaultSize
+	Size *pty.Winsize
+	// Transcript receives a copy of all output
+	Transcript io.Writer
+	// Recorder records output and window size as rec frames, activated by Spawn, not closed by Close
+	Recorder rec.Writer
+}
+
+// Case a pattern of ExpectAny
+type Case struct {
+	// String matches a substring
+	String string
+	// Regexp matches a regular expression, used if not nil
+	Regexp *regexp.Regexp
+}
+
+func (c Case) describe() string {
+	if c.Regexp != nil {
+		return "/" + c.Regexp.String() + "/"
+	}
+	return fmt.Sprintf("%q", c.String)
+}
+
+// find returns the submatches and end offset in buf, or -1
+func (c Case) find(buf []byte) (m []string, end int) {
+	if c.Regexp != nil {
+		loc := c.Regexp.FindSubmatchIndex(buf)
+		if loc == nil {
+			return nil, -1
+		}
+		for i := 0; i < len(loc); i += 2 {
+			if loc[i] < 0 {
+				m = append(m, "")
+			} else {
+				m = append(m, string(buf[loc[i]:loc[i+1]]))
+			}
+		}
+		return m, loc[1]
+	}
+	i := bytes.Index(buf, []byte(c.String))
+	if i < 0 {
+		return nil, -1
+	}
+	return []string{c.String}, i + len(c.String)
+}
+
+// Expecter a command running in a pty
+type Expecter struct {
+	Cmd *exec.Cmd
+
+	pty     *os.File
+	opts    Options
+	timeout time.Duration
+
+	mtx    *sync.Mutex
+	buf    []byte // unconsumed output
+	all    bytes.Buffer
+	eof    bool
+	closed bool
+	notify chan struct{}
+	done   chan struct{}
+	werr   error
+}
+
+// Spawn starts the command in a new pty
+func Spawn(c *exec.Cmd, options ...Options) (e *Expecter, err error) {
+	var opts Options
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Size == nil {
+		sz := DefaultSize
+		opts.Size = &sz
+	}
+	var p *os.File
+	if p, err = pty.StartWithSize(c, opts.Size); err != nil {
+		return
+	}
+	e = &Expecter{
+		Cmd:     c,
+		pty:     p,
+		opts:    opts,
+		timeout: opts.Timeout,
+		mtx:     &sync.Mutex{},
+		notify:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	if opts.Recorder != nil {
+		opts.Recorder.Activate()
+		opts.Recorder.WriteWindowSize(uint32(opts.Size.Cols), uint32(opts.Size.Rows))
+	}
+	go e.read()
+	go func() {
+		err := c.Wait()
+		e.mtx.Lock()
+		e.werr = err
+		e.mtx.Unlock()
+		close(e.done)
+	}()
+	return
+}
+
+// Command spawns the named program with arguments
+func Command(name string, args ...string) (*Expecter, error) {
+	return Spawn(exec.Command(name, args...))
+}
+
+func (e *Expecter) read() {
+	b := make([]byte, 4096)
+	for {
+		n, err := e.pty.Read(b)
+		if n > 0 {
+			e.mtx.Lock()
+			e.buf = append(e.buf, b[:n]...)
+			e.all.Write(b[:n])
+			e.mtx.Unlock()
+			if e.opts.Transcript != nil {
+				e.opts.Transcript.Write(b[:n])
+			}
+			if e.opts.Recorder != nil {
+				e.opts.Recorder.WriteStdout(b[:n])
+			}
+		}
+		if err != nil {
+			e.mtx.Lock()
+			e.eof = true
+			e.mtx.Unlock()
+		}
+		select {
+		case e.notify <- struct{}{}:
+		default:
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// SetTimeout sets timeout of following Expect calls
+func (e *Expecter) SetTimeout(d time.Duration) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.timeout = d
+}
+
+// Expect waits for a substring in output, output through the match is consumed
+func (e *Expecter) Expect(s string) error {
+	_, _, err := e.ExpectAny(Case{String: s})
+	return err
+}
+
+// ExpectRegexp waits for a regular expression in output, returns the submatches
+func (e *Expecter) ExpectRegexp(re *regexp.Regexp) ([]string, error) {
+	_, m, err := e.ExpectAny(Case{Regexp: re})
+	return m, err
+}
+
+// ExpectAny waits for the first of cases matched, earliest match in output wins,
+// returns the index of the case and its submatches
+func (e *Expecter) ExpectAny(cases ...Case) (index int, m []string, err error) {
+	e.mtx.Lock()
+	timer := time.NewTimer(e.timeout)
+	timeout := e.timeout
+	e.mtx.Unlock()
+	defer timer.Stop()
+	for {
+		e.mtx.Lock()
+		best := -1
+		index = -1
+		for i, c := range cases {
+			if cm, end := c.find(e.buf); end >= 0 && (best < 0 || end < best) {
+				index, m, best = i, cm, end
+			}
+		}
+		if index >= 0 {
+			e.buf = e.buf[best:]
+			e.mtx.Unlock()
+			return
+		}
+		eof := e.eof
+		e.mtx.Unlock()
+		if eof {
+			err = e.mismatch(cases, false, timeout)
+			return
+		}
+		select {
+		case <-e.notify:
+		case <-timer.C:
+			err = e.mismatch(cases, true, timeout)
+			return
+		}
+	}
+}
+
+// ExpectEOF waits for the command to exit and close the pty, returns the error of exec.Cmd.Wait
+func (e *Expecter) ExpectEOF() error {
+	e.mtx.Lock()
+	timeout := e.timeout
+	e.mtx.Unlock()
+	select {
+	case <-e.done:
+	case <-time.After(timeout):
+		return e.mismatch(nil, true, timeout)
+	}
+	// drain output
+	for {
+		e.mtx.Lock()
+		eof := e.eof
+		e.mtx.Unlock()
+		if eof {
+			break
+		}
+		select {
+		case <-e.notify:
+		case <-time.After(timeout):
+			return e.mismatch(nil, true, timeout)
+		}
+	}
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	return e.werr
+}
+
+func (e *Expecter) mismatch(cases []Case, timedOut bool, timeout time.Duration) error {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	return &MismatchError{
+		Cases:   cases,
+		Output:  string(e.buf),
+		Timeout: timedOut,
+		After:   timeout,
+	}
+}
+
+// Send writes s to the command
+func (e *Expecter) Send(s string) error {
+	e.mtx.Lock()
+	closed := e.closed
+	e.mtx.Unlock()
+	if closed {
+		return ErrClosed
+	}
+	_, err := io.WriteString(e.pty, s)
+	return err
+}
+
+// SendLine writes s and a carriage return, like pressing enter
+func (e *Expecter) SendLine(s string) error {
+	return e.Send(s + "\r")
+}
+
+// SendControl writes a control character, like SendControl('c') for ^C
+func (e *Expecter) SendControl(c byte) error {
+	return e.Send(string([]byte{c & 0x1f}))
+}
+
+// Resize resizes the terminal
+func (e *Expecter) Resize(ws *pty.Winsize) error {
+	if e.opts.Recorder != nil {
+		e.opts.Recorder.WriteWindowSize(uint32(ws.Cols), uint32(ws.Rows))
+	}
+	return pty.Setsize(e.pty, ws)
+}
+
+// Transcript returns all output so far
+func (e *Expecter) Transcript() string {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	return e.all.String()
+}
+
+// Close kills the command if still running, and closes the pty
+func (e *Expecter) Close() error {
+	e.mtx.Lock()
+	if e.closed {
+		e.mtx.Unlock()
+		return nil
+	}
+	e.closed = true
+	e.mtx.Unlock()
+	select {
+	case <-e.done:
+	default:
+		e.Cmd.Process.Kill()
+		<-e.done
+	}
+	return e.pty.Close()
+}
+
+// MismatchError expected output not found before timeout or EOF
+type MismatchError struct {
+	// Cases expected cases, empty for ExpectEOF
+	Cases []Case
+	// Output unconsumed output
+	Output string
+	// Timeout true if timed out, otherwise EOF reached
+	Timeout bool
+	After   time.Duration
+}
+
+func (e *MismatchError) Error() string {
+	w := &bytes.Buffer{}
+	var expected []string
+	for _, c := range e.Cases {
+		expected = append(expected, c.describe())
+	}
+	if len(expected) == 0 {
+		expected = append(expected, "EOF")
+	}
+	if e.Timeout {
+		fmt.Fprintf(w, "expect: timed out after %v waiting for %s\n", e.After, strings.Join(expected, " or "))
+	} else {
+		fmt.Fprintf(w, "expect: EOF while waiting for %s\n", strings.Join(expected, " or "))
+	}
+	for _, c := range e.Cases {
+		if c.Regexp != nil || len(c.String) == 0 {
+			continue
+		}
+		// show the longest partial match
+		if n, at := closest(e.Output, c.String); n > 0 {
+			fmt.Fprintf(w, "closest match of %q at offset %d: %q matched, then expected %q, got %q\n",
+				c.String, at, c.String[:n], c.String[n:], truncate(e.Output[at+n:], len(c.String)-n))
+		}
+	}
+	w.WriteString("--- unmatched output\n")
+	if len(e.Output) == 0 {
+		w.WriteString("(empty)\n")
+	}
+	for _, l := range strings.SplitAfter(e.Output, "\n") {
+		if len(l) > 0 {
+			q := fmt.Sprintf("%q", l)
+			fmt.Fprintf(w, "| %s\n", q[1:len(q)-1])
+		}
+	}
+	return w.String()
+}
+
+// closest finds the longest prefix of s in out, returns the prefix length and offset
+func closest(out string, s string) (n int, at int) {
+	for i := range out {
+		j := 0
+		for j < len(s) && i+j < len(out) && out[i+j] == s[j] {
+			j++
+		}
+		if j > n {
+			n, at = j, i
+		}
+	}
+	return
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,98 @@
+// +build linux
+
+package expect
+
+import (
+	"bytes"
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"landzero.net/x/encoding/rec"
+)
+
+const script = `printf "name? "; read n; echo "hello, $n"; printf "age? "; read a; echo "age=$a"`
+
+func TestExpect(t *testing.T) {
+	var transcript bytes.Buffer
+	var recording bytes.Buffer
+	w := rec.NewWriter(&recording)
+	e := Test(t, exec.Command("sh", "-c", script), Options{Transcript: &transcript, Recorder: w})
+	defer e.Close()
+	e.Expect("name? ")
+	e.SendLine("world")
+	e.Expect("hello, world")
+	e.Expect("age? ")
+	e.SendLine("42")
+	if m := e.ExpectRegexp(regexp.MustCompile(`age=(\d+)`)); m[1] != "42" {
+		t.Error("unexpected submatch", m)
+	}
+	e.ExpectEOF()
+	if !strings.Contains(transcript.String(), "hello, world\r\n") || transcript.String() != e.Transcript() {
+		t.Errorf("unexpected transcript %q", transcript.String())
+	}
+	w.Close()
+	r := rec.NewFrameReader(&recording)
+	var f rec.Frame
+	if err := r.ReadFrame(&f); err != nil || f.Type != rec.FrameWindowSize {
+		t.Fatal("first frame should be window size", err)
+	}
+	if cols, rows := f.DecodeWindowSize(); cols != 80 || rows != 24 {
+		t.Error("unexpected window size", cols, rows)
+	}
+	var out bytes.Buffer
+	for r.ReadFrame(&f) == nil {
+		out.Write(f.Payload)
+	}
+	if out.String() != e.Transcript() {
+		t.Errorf("unexpected recording %q", out.String())
+	}
+}
+
+func TestExpectAny(t *testing.T) {
+	e, err := Command("sh", "-c", `echo "b a"; sleep 10`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	i, _, err := e.ExpectAny(Case{String: "a"}, Case{Regexp: regexp.MustCompile(`b`)})
+	if err != nil || i != 1 {
+		t.Error("earliest match should win", i, err)
+	}
+	e.SetTimeout(100 * time.Millisecond)
+	err = e.Expect("hello")
+	me, ok := err.(*MismatchError)
+	if !ok || !me.Timeout || !strings.Contains(me.Error(), `timed out after 100ms waiting for "hello"`) {
+		t.Fatal("expected timeout", err)
+	}
+	e.SendControl('c')
+	e.SetTimeout(5 * time.Second)
+	if err = e.ExpectEOF(); err == nil {
+		t.Error("^C should interrupt the command")
+	}
+}
+
+func TestMismatchError(t *testing.T) {
+	e, err := Command("echo", "hello world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	err = e.Expect("hello wood")
+	me, ok := err.(*MismatchError)
+	if !ok || me.Timeout {
+		t.Fatal("expected EOF mismatch", err)
+	}
+	msg := me.Error()
+	for _, s := range []string{
+		`expect: EOF while waiting for "hello wood"`,
+		`"hello wo" matched, then expected "od", got "rl"`,
+		`| hello world\r\n`,
+	} {
+		if !strings.Contains(msg, s) {
+			t.Errorf("error should contain %q:\n%s", s, msg)
+		}
+	}
+}
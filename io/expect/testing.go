@@ -0,0 +1,86 @@
+// +build !windows
+
+package expect
+
+import (
+	"os/exec"
+	"regexp"
+)
+
+// TB the subset of testing.TB used by T
+type TB interface {
+	Helper()
+	Fatal(args ...interface{})
+	Logf(format string, args ...interface{})
+}
+
+// T an Expecter fails the test on errors, for use in go test
+type T struct {
+	*Expecter
+	tb TB
+}
+
+// Test spawns the command for a test, the test fails if spawning fails, call Close when done
+func Test(tb TB, c *exec.Cmd, options ...Options) *T {
+	tb.Helper()
+	e, err := Spawn(c, options...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return &T{Expecter: e, tb: tb}
+}
+
+func (t *T) check(err error) {
+	t.tb.Helper()
+	if err != nil {
+		t.tb.Logf("transcript:\n%s", t.Transcript())
+		t.Expecter.Close()
+		t.tb.Fatal(err)
+	}
+}
+
+// Expect waits for a substring in output, fails the test if not found
+func (t *T) Expect(s string) {
+	t.tb.Helper()
+	t.check(t.Expecter.Expect(s))
+}
+
+// ExpectRegexp waits for a regular expression in output, fails the test if not found
+func (t *T) ExpectRegexp(re *regexp.Regexp) []string {
+	t.tb.Helper()
+	m, err := t.Expecter.ExpectRegexp(re)
+	t.check(err)
+	return m
+}
+
+// ExpectAny waits for the first of cases matched, fails the test if none found
+func (t *T) ExpectAny(cases ...Case) (int, []string) {
+	t.tb.Helper()
+	i, m, err := t.Expecter.ExpectAny(cases...)
+	t.check(err)
+	return i, m
+}
+
+// ExpectEOF waits for the command to exit, fails the test if it does not exit, or exits with error
+func (t *T) ExpectEOF() {
+	t.tb.Helper()
+	t.check(t.Expecter.ExpectEOF())
+}
+
+// Send writes s to the command, fails the test on error
+func (t *T) Send(s string) {
+	t.tb.Helper()
+	t.check(t.Expecter.Send(s))
+}
+
+// SendLine writes s and a carriage return, fails the test on error
+func (t *T) SendLine(s string) {
+	t.tb.Helper()
+	t.check(t.Expecter.SendLine(s))
+}
+
+// SendControl writes a control character, fails the test on error
+func (t *T) SendControl(c byte) {
+	t.tb.Helper()
+	t.check(t.Expecter.SendControl(c))
+}
@@ -1,75 +1,344 @@
 package ioext
 
 import (
+	"bufio"
 	"errors"
-	"io"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 )
 
 const (
-	// LazyFileWriterMaxTry max time a lazy file writer tries to create the file
+	// LazyFileWriterMaxTry max consecutive times a lazy file writer tries to create the file before backing off
 	LazyFileWriterMaxTry = 3
+
+	// DefaultLazyCheckInterval default interval of checking whether the file is removed or replaced
+	DefaultLazyCheckInterval = time.Second
+	// DefaultLazyRetryBackoff default initial backoff after LazyFileWriterMaxTry failures
+	DefaultLazyRetryBackoff = time.Second
+	// DefaultLazyMaxRetryBackoff default max backoff, backoff doubles on every failure after backing off
+	DefaultLazyMaxRetryBackoff = time.Minute
+	// DefaultLazyFlushInterval default interval of flushing buffered writes
+	DefaultLazyFlushInterval = time.Second
+	// DefaultLazySyncPeriod default period of SyncPeriodic
+	DefaultLazySyncPeriod = time.Second
 )
 
 var (
-	// ErrTooManyCreationFailure too many creation failure occured
+	// ErrTooManyCreationFailure too many creation failure occured, writes fail until backoff elapsed
 	ErrTooManyCreationFailure = errors.New("LazyFileWriter: too many creation failure")
 )
 
-type lazyFilerWriter struct {
+// SyncPolicy when a LazyFileWriter fsyncs the file
+type SyncPolicy int
+
+const (
+	// SyncNone never fsync, leave it to the operating system
+	SyncNone SyncPolicy = iota
+	// SyncEveryWrite fsync after every write, buffered writes are flushed first
+	SyncEveryWrite
+	// SyncPeriodic fsync every SyncPeriod if anything is written
+	SyncPeriodic
+)
+
+// LazyFileOptions options of a lazy file writer
+type LazyFileOptions struct {
+	// CheckInterval interval of checking whether the file is removed or replaced, for example by logrotate,
+	// the file is reopened if so, defaults to DefaultLazyCheckInterval, negative disables checking
+	CheckInterval time.Duration
+	// RetryBackoff initial backoff after LazyFileWriterMaxTry consecutive failures of creating the file,
+	// defaults to DefaultLazyRetryBackoff
+	RetryBackoff time.Duration
+	// MaxRetryBackoff max backoff, defaults to DefaultLazyMaxRetryBackoff
+	MaxRetryBackoff time.Duration
+	// Sync fsync policy
+	Sync SyncPolicy
+	// SyncPeriod period of SyncPeriodic, defaults to DefaultLazySyncPeriod
+	SyncPeriod time.Duration
+	// BufferSize size of write buffer, zero disables buffering
+	BufferSize int
+	// FlushInterval interval of flushing buffered writes, defaults to DefaultLazyFlushInterval
+	FlushInterval time.Duration
+	// OnReopen called with the filename after the file is closed for reopening, because it's removed
+	// or replaced, or by Reopen, for example to compress or ship the rotated file, the new file is
+	// opened on next write. It's called without the writer locked, in the goroutine writing or reopening.
+	OnReopen func(filename string)
+}
+
+// LazyFileWriter a io.WriteCloser creates the file on first write
+//
+// The file is reopened when it's removed or replaced, or on Reopen, failures of creating the file
+// are retried with backoff. It is safe for concurrent use.
+type LazyFileWriter struct {
 	filename string
-	w        io.WriteCloser
-	f        int
+	opts     LazyFileOptions
+
 	mtx      *sync.Mutex
+	f        *os.File
+	bw       *bufio.Writer
+	checked  time.Time
+	synced   time.Time
+	dirty    bool
+	failures int
+	backoff  time.Duration
+	retryAt  time.Time
+
+	sigCh  chan os.Signal
+	done   chan struct{}
+	closed bool
 }
 
-func (lfw *lazyFilerWriter) ensureFile() (w io.WriteCloser, err error) {
-	if lfw.f > LazyFileWriterMaxTry {
-		err = ErrTooManyCreationFailure
+// NewLazyFileWriter lazy file writer, create file on first write
+func NewLazyFileWriter(filename string) *LazyFileWriter {
+	return NewLazyFileWriterWithOptions(filename, LazyFileOptions{})
+}
+
+// NewLazyFileWriterWithOptions lazy file writer with options, create file on first write
+func NewLazyFileWriterWithOptions(filename string, opts LazyFileOptions) *LazyFileWriter {
+	if opts.CheckInterval == 0 {
+		opts.CheckInterval = DefaultLazyCheckInterval
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = DefaultLazyRetryBackoff
+	}
+	if opts.MaxRetryBackoff <= 0 {
+		opts.MaxRetryBackoff = DefaultLazyMaxRetryBackoff
+	}
+	if opts.SyncPeriod <= 0 {
+		opts.SyncPeriod = DefaultLazySyncPeriod
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultLazyFlushInterval
+	}
+	lfw := &LazyFileWriter{
+		filename: filename,
+		opts:     opts,
+		mtx:      &sync.Mutex{},
+		backoff:  opts.RetryBackoff,
+		done:     make(chan struct{}),
+	}
+	// background flushing and syncing
+	var interval time.Duration
+	if opts.BufferSize > 0 {
+		interval = opts.FlushInterval
+	}
+	if opts.Sync == SyncPeriodic && (interval == 0 || opts.SyncPeriod < interval) {
+		interval = opts.SyncPeriod
+	}
+	if interval > 0 {
+		go lfw.loop(interval)
+	}
+	return lfw
+}
+
+// Write implements io.Writer, the file is created or reopened if needed
+func (lfw *LazyFileWriter) Write(p []byte) (n int, err error) {
+	var reopened bool
+	defer lfw.notifyReopened(&reopened)
+	lfw.mtx.Lock()
+	defer lfw.mtx.Unlock()
+	if lfw.closed {
+		err = os.ErrClosed
 		return
 	}
+	if lfw.f != nil && lfw.opts.CheckInterval > 0 && time.Since(lfw.checked) >= lfw.opts.CheckInterval {
+		if lfw.replaced() {
+			lfw.closeFile()
+			reopened = true
+		}
+	}
+	if lfw.f == nil {
+		if err = lfw.openFile(); err != nil {
+			return
+		}
+	}
+	if lfw.bw != nil {
+		n, err = lfw.bw.Write(p)
+	} else {
+		n, err = lfw.f.Write(p)
+	}
+	if err != nil {
+		// the file is broken, try a new one on next write
+		lfw.closeFile()
+		return
+	}
+	lfw.dirty = true
+	if lfw.opts.Sync == SyncEveryWrite {
+		err = lfw.sync()
+	}
+	return
+}
+
+// Flush writes buffered data to the file
+func (lfw *LazyFileWriter) Flush() error {
+	lfw.mtx.Lock()
+	defer lfw.mtx.Unlock()
+	if lfw.bw == nil {
+		return nil
+	}
+	return lfw.bw.Flush()
+}
+
+// Sync flushes buffered data and fsyncs the file
+func (lfw *LazyFileWriter) Sync() error {
 	lfw.mtx.Lock()
 	defer lfw.mtx.Unlock()
-	if lfw.w != nil {
-		w = lfw.w
+	return lfw.sync()
+}
+
+// Reopen closes the current file, the filename is opened again on next write,
+// this should be used after the file is moved by an external tool like logrotate
+func (lfw *LazyFileWriter) Reopen() (err error) {
+	var reopened bool
+	defer lfw.notifyReopened(&reopened)
+	lfw.mtx.Lock()
+	defer lfw.mtx.Unlock()
+	if lfw.closed {
+		return os.ErrClosed
+	}
+	// reopening also resets the backoff
+	lfw.failures = 0
+	lfw.backoff = lfw.opts.RetryBackoff
+	lfw.retryAt = time.Time{}
+	reopened = lfw.f != nil
+	return lfw.closeFile()
+}
+
+// notifyReopened calls OnReopen if reopened, it's deferred before locking
+func (lfw *LazyFileWriter) notifyReopened(reopened *bool) {
+	if *reopened && lfw.opts.OnReopen != nil {
+		lfw.opts.OnReopen(lfw.filename)
+	}
+}
+
+// ReopenOnSignal reopens the file every time one of the signals arrives, SIGHUP if no signal is given,
+// signal handling stops when the writer is closed
+func (lfw *LazyFileWriter) ReopenOnSignal(sigs ...os.Signal) {
+	lfw.mtx.Lock()
+	defer lfw.mtx.Unlock()
+	if lfw.closed || lfw.sigCh != nil {
+		return
+	}
+	lfw.sigCh = notifyReopen(lfw.Reopen, sigs)
+}
+
+// Close implements io.Closer, flushes buffered data and closes the file
+func (lfw *LazyFileWriter) Close() (err error) {
+	lfw.mtx.Lock()
+	defer lfw.mtx.Unlock()
+	if lfw.closed {
+		return
+	}
+	lfw.closed = true
+	close(lfw.done)
+	stopReopen(lfw.sigCh)
+	if lfw.opts.Sync != SyncNone {
+		lfw.sync()
+	}
+	return lfw.closeFile()
+}
+
+func (lfw *LazyFileWriter) loop(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-lfw.done:
+			return
+		}
+		lfw.mtx.Lock()
+		if lfw.bw != nil {
+			lfw.bw.Flush()
+		}
+		if lfw.opts.Sync == SyncPeriodic && time.Since(lfw.synced) >= lfw.opts.SyncPeriod {
+			lfw.sync()
+		}
+		lfw.mtx.Unlock()
+	}
+}
+
+// replaced returns true if the filename no longer points to the opened file
+func (lfw *LazyFileWriter) replaced() bool {
+	lfw.checked = time.Now()
+	fi, err := os.Stat(lfw.filename)
+	if err != nil {
+		return true
+	}
+	cur, err := lfw.f.Stat()
+	if err != nil {
+		return true
+	}
+	return !os.SameFile(fi, cur)
+}
+
+func (lfw *LazyFileWriter) openFile() (err error) {
+	now := time.Now()
+	if now.Before(lfw.retryAt) {
+		err = ErrTooManyCreationFailure
 		return
 	}
+	defer func() {
+		if err == nil {
+			lfw.failures = 0
+			lfw.backoff = lfw.opts.RetryBackoff
+			lfw.retryAt = time.Time{}
+			return
+		}
+		lfw.failures++
+		if lfw.failures >= LazyFileWriterMaxTry {
+			lfw.retryAt = now.Add(lfw.backoff)
+			if lfw.backoff *= 2; lfw.backoff > lfw.opts.MaxRetryBackoff {
+				lfw.backoff = lfw.opts.MaxRetryBackoff
+			}
+		}
+	}()
 	// ensure directory
-	d := filepath.Dir(lfw.filename)
-	if err = os.MkdirAll(d, os.FileMode(0750)); err != nil {
+	if err = os.MkdirAll(filepath.Dir(lfw.filename), os.FileMode(0750)); err != nil {
 		return
 	}
 	var f *os.File
 	if f, err = os.OpenFile(lfw.filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, os.FileMode(0640)); err != nil {
-		lfw.f++
 		return
 	}
-	lfw.f = 0
-	lfw.w = f
-	w = f
+	lfw.f = f
+	lfw.checked = now
+	if lfw.opts.BufferSize > 0 {
+		lfw.bw = bufio.NewWriterSize(f, lfw.opts.BufferSize)
+	}
 	return
 }
 
-func (lfw *lazyFilerWriter) Write(p []byte) (n int, err error) {
-	var w io.WriteCloser
-	if w, err = lfw.ensureFile(); err != nil {
+func (lfw *LazyFileWriter) sync() (err error) {
+	if lfw.f == nil || !lfw.dirty {
 		return
 	}
-	return w.Write(p)
-}
-
-func (lfw *lazyFilerWriter) Close() error {
-	lfw.mtx.Lock()
-	defer lfw.mtx.Unlock()
-	if lfw.w != nil {
-		return lfw.Close()
+	if lfw.bw != nil {
+		if err = lfw.bw.Flush(); err != nil {
+			return
+		}
+	}
+	if err = lfw.f.Sync(); err != nil {
+		return
 	}
-	return nil
+	lfw.dirty = false
+	lfw.synced = time.Now()
+	return
 }
 
-// NewLazyFileWriter lazy file writer, create file on first write
-func NewLazyFileWriter(filename string) io.WriteCloser {
-	return &lazyFilerWriter{filename: filename, mtx: &sync.Mutex{}}
+func (lfw *LazyFileWriter) closeFile() (err error) {
+	if lfw.f == nil {
+		return
+	}
+	if lfw.bw != nil {
+		err = lfw.bw.Flush()
+		lfw.bw = nil
+	}
+	if cerr := lfw.f.Close(); err == nil {
+		err = cerr
+	}
+	lfw.f = nil
+	lfw.dirty = false
+	return
 }
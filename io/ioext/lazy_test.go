@@ -1,11 +1,155 @@
 package ioext
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLazyFileWriter(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "ioext-lazy")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "sub", "test.log")
+	w := NewLazyFileWriter(filename)
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Fatal("file should not be created before first write")
+	}
+	w.Write([]byte("hello\n"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal("close twice should not fail", err)
+	}
+	if _, err := w.Write([]byte("x")); err != os.ErrClosed {
+		t.Error("write after close should fail, got", err)
+	}
+	if buf, _ := ioutil.ReadFile(filename); string(buf) != "hello\n" {
+		t.Errorf("unexpected file %q", buf)
+	}
 }
 
 func TestLazyFileWriterMaxFailure(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "ioext-lazy")
+	defer os.RemoveAll(dir)
+	// a directory at filename makes creation fail
+	filename := filepath.Join(dir, "test.log")
+	os.Mkdir(filename, 0750)
+	w := NewLazyFileWriterWithOptions(filename, LazyFileOptions{RetryBackoff: time.Millisecond * 50})
+	defer w.Close()
+	for i := 0; i < LazyFileWriterMaxTry; i++ {
+		if _, err := w.Write([]byte("x")); err == nil || err == ErrTooManyCreationFailure {
+			t.Fatal("expected creation failure, got", err)
+		}
+	}
+	if _, err := w.Write([]byte("x")); err != ErrTooManyCreationFailure {
+		t.Fatal("expected backing off, got", err)
+	}
+	os.Remove(filename)
+	time.Sleep(time.Millisecond * 60)
+	if _, err := w.Write([]byte("recovered")); err != nil {
+		t.Fatal("should recover after backoff", err)
+	}
+	w.Flush()
+	if buf, _ := ioutil.ReadFile(filename); string(buf) != "recovered" {
+		t.Errorf("unexpected file %q", buf)
+	}
+}
+
+func TestLazyFileWriterReplaced(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "ioext-lazy")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "test.log")
+	w := NewLazyFileWriterWithOptions(filename, LazyFileOptions{CheckInterval: time.Nanosecond})
+	defer w.Close()
+	w.Write([]byte("a\n"))
+	// moved by logrotate
+	os.Rename(filename, filename+".1")
+	time.Sleep(time.Millisecond)
+	w.Write([]byte("b\n"))
+	if buf, _ := ioutil.ReadFile(filename); string(buf) != "b\n" {
+		t.Errorf("unexpected new file %q", buf)
+	}
+	if buf, _ := ioutil.ReadFile(filename + ".1"); string(buf) != "a\n" {
+		t.Errorf("unexpected old file %q", buf)
+	}
+}
+
+func TestLazyFileWriterReopen(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "ioext-lazy")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "test.log")
+	w := NewLazyFileWriterWithOptions(filename, LazyFileOptions{CheckInterval: -1})
+	defer w.Close()
+	w.Write([]byte("a\n"))
+	os.Rename(filename, filename+".1")
+	w.Write([]byte("b\n"))
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("c\n"))
+	if buf, _ := ioutil.ReadFile(filename + ".1"); string(buf) != "a\nb\n" {
+		t.Errorf("unexpected old file %q", buf)
+	}
+	if buf, _ := ioutil.ReadFile(filename); string(buf) != "c\n" {
+		t.Errorf("unexpected new file %q", buf)
+	}
+}
+
+func TestLazyFileWriterOnReopen(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "ioext-lazy")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "test.log")
+	var reopened []string
+	var w *LazyFileWriter
+	w = NewLazyFileWriterWithOptions(filename, LazyFileOptions{
+		CheckInterval: time.Nanosecond,
+		OnReopen: func(name string) {
+			reopened = append(reopened, name)
+			// called unlocked
+			w.Write([]byte("reopened\n"))
+		},
+	})
+	defer w.Close()
+	// nothing to reopen
+	w.Reopen()
+	w.Write([]byte("a\n"))
+	os.Rename(filename, filename+".1")
+	time.Sleep(time.Millisecond)
+	w.Write([]byte("b\n"))
+	w.Reopen()
+	if len(reopened) != 2 || reopened[0] != filename || reopened[1] != filename {
+		t.Errorf("unexpected reopens %q", reopened)
+	}
+	if buf, _ := ioutil.ReadFile(filename); string(buf) != "b\nreopened\nreopened\n" {
+		t.Errorf("unexpected new file %q", buf)
+	}
+}
+
+func TestLazyFileWriterBuffer(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "ioext-lazy")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "test.log")
+	w := NewLazyFileWriterWithOptions(filename, LazyFileOptions{
+		BufferSize:    1024,
+		FlushInterval: time.Millisecond * 20,
+		Sync:          SyncPeriodic,
+	})
+	w.Write([]byte("hello\n"))
+	if buf, _ := ioutil.ReadFile(filename); len(buf) != 0 {
+		t.Errorf("write should be buffered, got %q", buf)
+	}
+	time.Sleep(time.Millisecond * 100)
+	if buf, _ := ioutil.ReadFile(filename); string(buf) != "hello\n" {
+		t.Errorf("write should be flushed in background, got %q", buf)
+	}
+	w.Write([]byte("world\n"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if buf, _ := ioutil.ReadFile(filename); string(buf) != "hello\nworld\n" {
+		t.Errorf("close should flush, got %q", buf)
+	}
 }
@@ -0,0 +1,315 @@
+package ioext
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// RotateTimeFormat time format embedded in names of rotated files
+	RotateTimeFormat = "2006-01-02T15-04-05.000"
+
+	rotateCompressSuffix = ".gz"
+)
+
+// RotateOptions options of a rotating file writer, zero value disables the corresponding feature
+type RotateOptions struct {
+	// MaxSize rotate before a write would make the file larger than MaxSize bytes
+	MaxSize int64
+	// Interval rotate when the current time crosses an Interval boundary, boundaries are aligned as time.Truncate
+	Interval time.Duration
+	// MaxBackups max count of rotated files to retain
+	MaxBackups int
+	// MaxAge max age of rotated files to retain, judged by the time in file name
+	MaxAge time.Duration
+	// Compress gzip rotated files
+	Compress bool
+}
+
+// RotatingFileWriter a io.WriteCloser writes to a file, and rotates the file by size or time
+//
+// The current file is always written at the given filename, rotated files are renamed to
+// "name-RotateTimeFormat.ext" in the same directory, and optionally compressed with gzip.
+// It is safe for concurrent use.
+type RotatingFileWriter struct {
+	filename string
+	opts     RotateOptions
+
+	mtx    *sync.Mutex
+	f      *os.File
+	size   int64
+	opened time.Time
+
+	millCh   chan struct{}
+	millDone chan struct{}
+	sigCh    chan os.Signal
+	closed   bool
+}
+
+// NewRotatingFileWriter create a new rotating file writer, file is created on first write
+func NewRotatingFileWriter(filename string, opts RotateOptions) *RotatingFileWriter {
+	rfw := &RotatingFileWriter{
+		filename: filename,
+		opts:     opts,
+		mtx:      &sync.Mutex{},
+		millCh:   make(chan struct{}, 1),
+		millDone: make(chan struct{}),
+	}
+	go rfw.millLoop()
+	return rfw
+}
+
+// Write implements io.Writer, rotates the file if needed before writing
+func (rfw *RotatingFileWriter) Write(p []byte) (n int, err error) {
+	rfw.mtx.Lock()
+	defer rfw.mtx.Unlock()
+	if rfw.closed {
+		err = os.ErrClosed
+		return
+	}
+	if rfw.f == nil {
+		if err = rfw.openExisting(); err != nil {
+			return
+		}
+	}
+	if rfw.shouldRotate(int64(len(p)), time.Now()) {
+		if err = rfw.rotate(); err != nil {
+			return
+		}
+	}
+	n, err = rfw.f.Write(p)
+	rfw.size += int64(n)
+	return
+}
+
+// Rotate closes the current file, renames it as a rotated file and opens a new one
+func (rfw *RotatingFileWriter) Rotate() error {
+	rfw.mtx.Lock()
+	defer rfw.mtx.Unlock()
+	if rfw.closed {
+		return os.ErrClosed
+	}
+	return rfw.rotate()
+}
+
+// Reopen closes the current file and opens the filename again, without renaming,
+// this should be used after the file is moved by an external tool like logrotate
+func (rfw *RotatingFileWriter) Reopen() error {
+	rfw.mtx.Lock()
+	defer rfw.mtx.Unlock()
+	if rfw.closed {
+		return os.ErrClosed
+	}
+	if err := rfw.closeFile(); err != nil {
+		return err
+	}
+	return rfw.openExisting()
+}
+
+// ReopenOnSignal reopens the file every time one of the signals arrives, SIGHUP if no signal is given,
+// signal handling stops when the writer is closed
+func (rfw *RotatingFileWriter) ReopenOnSignal(sigs ...os.Signal) {
+	rfw.mtx.Lock()
+	defer rfw.mtx.Unlock()
+	if rfw.closed || rfw.sigCh != nil {
+		return
+	}
+	rfw.sigCh = notifyReopen(rfw.Reopen, sigs)
+}
+
+// Close implements io.Closer, closes the current file and waits for pending compression and removal
+func (rfw *RotatingFileWriter) Close() (err error) {
+	rfw.mtx.Lock()
+	if rfw.closed {
+		rfw.mtx.Unlock()
+		return
+	}
+	rfw.closed = true
+	stopReopen(rfw.sigCh)
+	err = rfw.closeFile()
+	close(rfw.millCh)
+	rfw.mtx.Unlock()
+	<-rfw.millDone
+	return
+}
+
+func (rfw *RotatingFileWriter) shouldRotate(n int64, now time.Time) bool {
+	if rfw.opts.MaxSize > 0 && rfw.size > 0 && rfw.size+n > rfw.opts.MaxSize {
+		return true
+	}
+	if rfw.opts.Interval > 0 && !now.Truncate(rfw.opts.Interval).Equal(rfw.opened.Truncate(rfw.opts.Interval)) {
+		return true
+	}
+	return false
+}
+
+// openExisting opens the filename for appending, size and open time are taken from the existing file
+func (rfw *RotatingFileWriter) openExisting() (err error) {
+	if err = os.MkdirAll(filepath.Dir(rfw.filename), os.FileMode(0750)); err != nil {
+		return
+	}
+	var f *os.File
+	if f, err = os.OpenFile(rfw.filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, os.FileMode(0640)); err != nil {
+		return
+	}
+	var fi os.FileInfo
+	if fi, err = f.Stat(); err != nil {
+		f.Close()
+		return
+	}
+	rfw.f = f
+	rfw.size = fi.Size()
+	rfw.opened = time.Now()
+	if rfw.size > 0 {
+		rfw.opened = fi.ModTime()
+	}
+	return
+}
+
+func (rfw *RotatingFileWriter) closeFile() (err error) {
+	if rfw.f == nil {
+		return
+	}
+	err = rfw.f.Close()
+	rfw.f = nil
+	rfw.size = 0
+	return
+}
+
+func (rfw *RotatingFileWriter) rotate() (err error) {
+	if err = rfw.closeFile(); err != nil {
+		return
+	}
+	if _, err = os.Stat(rfw.filename); err == nil {
+		if err = os.Rename(rfw.filename, rfw.backupName(time.Now())); err != nil {
+			return
+		}
+	} else if !os.IsNotExist(err) {
+		return
+	}
+	if err = rfw.openExisting(); err != nil {
+		return
+	}
+	// notify the mill goroutine without blocking
+	select {
+	case rfw.millCh <- struct{}{}:
+	default:
+	}
+	return
+}
+
+func (rfw *RotatingFileWriter) splitName() (prefix, ext string) {
+	base := filepath.Base(rfw.filename)
+	ext = filepath.Ext(base)
+	prefix = strings.TrimSuffix(base, ext) + "-"
+	return
+}
+
+// backupName returns a non-existing name for a rotated file, t is advanced on conflict
+func (rfw *RotatingFileWriter) backupName(t time.Time) string {
+	prefix, ext := rfw.splitName()
+	for {
+		name := filepath.Join(filepath.Dir(rfw.filename), prefix+t.Format(RotateTimeFormat)+ext)
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			if _, err = os.Stat(name + rotateCompressSuffix); os.IsNotExist(err) {
+				return name
+			}
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+func (rfw *RotatingFileWriter) millLoop() {
+	defer close(rfw.millDone)
+	for range rfw.millCh {
+		rfw.mill()
+	}
+}
+
+type rotatedFile struct {
+	name string
+	t    time.Time
+}
+
+// rotatedFiles lists rotated files, newest first
+func (rfw *RotatingFileWriter) rotatedFiles() (files []rotatedFile, err error) {
+	dir := filepath.Dir(rfw.filename)
+	var f *os.File
+	if f, err = os.Open(dir); err != nil {
+		return
+	}
+	var names []string
+	names, err = f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		return
+	}
+	prefix, ext := rfw.splitName()
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimPrefix(name, prefix)
+		ts = strings.TrimSuffix(ts, rotateCompressSuffix)
+		if !strings.HasSuffix(ts, ext) {
+			continue
+		}
+		var t time.Time
+		if t, err = time.ParseInLocation(RotateTimeFormat, strings.TrimSuffix(ts, ext), time.Local); err != nil {
+			err = nil
+			continue
+		}
+		files = append(files, rotatedFile{name: filepath.Join(dir, name), t: t})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].t.After(files[j].t) })
+	return
+}
+
+// mill removes expired rotated files and compresses the remaining ones
+func (rfw *RotatingFileWriter) mill() {
+	files, err := rfw.rotatedFiles()
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	for i, rf := range files {
+		if (rfw.opts.MaxBackups > 0 && i >= rfw.opts.MaxBackups) ||
+			(rfw.opts.MaxAge > 0 && now.Sub(rf.t) > rfw.opts.MaxAge) {
+			os.Remove(rf.name)
+			continue
+		}
+		if rfw.opts.Compress && !strings.HasSuffix(rf.name, rotateCompressSuffix) {
+			compressFile(rf.name)
+		}
+	}
+}
+
+// compressFile gzips the file to name.gz and removes the original
+func compressFile(name string) (err error) {
+	var src, dst *os.File
+	if src, err = os.Open(name); err != nil {
+		return
+	}
+	defer src.Close()
+	if dst, err = os.OpenFile(name+rotateCompressSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(0640)); err != nil {
+		return
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err == nil {
+		err = zw.Close()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(name + rotateCompressSuffix)
+		return
+	}
+	return os.Remove(name)
+}
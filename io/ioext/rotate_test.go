@@ -0,0 +1,133 @@
+package ioext
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileWriterSize(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "ioext-rotate")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "sub", "test.log")
+	w := NewRotatingFileWriter(filename, RotateOptions{MaxSize: 10, MaxBackups: 2})
+	for i := 0; i < 5; i++ {
+		if _, err := w.Write([]byte("0123456\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("x")); err != os.ErrClosed {
+		t.Error("write after close should fail, got", err)
+	}
+	buf, _ := ioutil.ReadFile(filename)
+	if string(buf) != "0123456\n" {
+		t.Errorf("unexpected current file %q", buf)
+	}
+	names, _ := filepath.Glob(filepath.Join(dir, "sub", "test-*.log"))
+	if len(names) != 2 {
+		t.Errorf("expected 2 rotated files, got %v", names)
+	}
+}
+
+func TestRotatingFileWriterCompress(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "ioext-rotate")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "test.log")
+	w := NewRotatingFileWriter(filename, RotateOptions{Compress: true})
+	w.Write([]byte("hello\n"))
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("world\n"))
+	w.Close()
+	names, _ := filepath.Glob(filepath.Join(dir, "test-*.log.gz"))
+	if len(names) != 1 {
+		t.Fatalf("expected 1 compressed file, got %v", names)
+	}
+	f, _ := os.Open(names[0])
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, _ := ioutil.ReadAll(zr)
+	if string(buf) != "hello\n" {
+		t.Errorf("unexpected compressed content %q", buf)
+	}
+}
+
+func TestRotatingFileWriterMaxAge(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "ioext-rotate")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "test.log")
+	old := filepath.Join(dir, "test-"+time.Now().Add(-48*time.Hour).Format(RotateTimeFormat)+".log")
+	ioutil.WriteFile(old, []byte("old\n"), 0640)
+	w := NewRotatingFileWriter(filename, RotateOptions{MaxAge: 24 * time.Hour})
+	w.Write([]byte("new\n"))
+	w.Rotate()
+	w.Close()
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("expired rotated file should be removed")
+	}
+	names, _ := filepath.Glob(filepath.Join(dir, "test-*.log"))
+	if len(names) != 1 {
+		t.Errorf("expected 1 rotated file, got %v", names)
+	}
+}
+
+func TestRotatingFileWriterReopen(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "ioext-rotate")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "test.log")
+	w := NewRotatingFileWriter(filename, RotateOptions{})
+	defer w.Close()
+	w.Write([]byte("hello\n"))
+	os.Rename(filename, filename+".1")
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("world\n"))
+	buf, _ := ioutil.ReadFile(filename)
+	if string(buf) != "world\n" {
+		t.Errorf("unexpected reopened file %q", buf)
+	}
+}
+
+func TestRotatingFileWriterConcurrent(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "ioext-rotate")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "test.log")
+	w := NewRotatingFileWriter(filename, RotateOptions{MaxSize: 100})
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				w.Write([]byte("0123456789\n"))
+			}
+		}()
+	}
+	wg.Wait()
+	w.Close()
+	names, _ := filepath.Glob(filepath.Join(dir, "test*.log"))
+	var total int
+	for _, name := range names {
+		buf, _ := ioutil.ReadFile(name)
+		if len(buf) > 100 {
+			t.Errorf("file %s exceeds max size", name)
+		}
+		total += strings.Count(string(buf), "\n")
+	}
+	if total != 200 {
+		t.Errorf("expected 200 lines, got %d", total)
+	}
+}
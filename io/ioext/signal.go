@@ -0,0 +1,32 @@
+package ioext
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyReopen calls reopen every time one of the signals arrives, SIGHUP if no signal is given,
+// until the returned channel is passed to stopReopen
+func notifyReopen(reopen func() error, sigs []os.Signal) chan os.Signal {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sigs...)
+	go func() {
+		for range c {
+			reopen()
+		}
+	}()
+	return c
+}
+
+// stopReopen stops signal handling started by notifyReopen, c can be nil
+func stopReopen(c chan os.Signal) {
+	if c == nil {
+		return
+	}
+	signal.Stop(c)
+	close(c)
+}
@@ -0,0 +1,49 @@
+// +build !windows
+
+package pty
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// WatchSize sends the size of terminal t on the returned channel, once immediately and after
+// every SIGWINCH, stale sizes not yet received are replaced, call stop to stop watching,
+// the channel is closed then.
+func WatchSize(t *os.File) (sizes <-chan *Winsize, stop func()) {
+	ch := make(chan *Winsize, 1)
+	sig := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sig, syscall.SIGWINCH)
+	send := func() {
+		ws, err := GetsizeFull(t)
+		if err != nil {
+			return
+		}
+		// replace the stale size
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- ws
+	}
+	go func() {
+		defer close(ch)
+		defer signal.Stop(sig)
+		send()
+		for {
+			select {
+			case <-sig:
+				send()
+			case <-done:
+				return
+			}
+		}
+	}()
+	once := &sync.Once{}
+	return ch, func() {
+		once.Do(func() { close(done) })
+	}
+}
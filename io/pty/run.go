@@ -12,21 +12,58 @@ import (
 // and c.Stderr, calls c.Start, and returns the File of the tty's
 // corresponding pty.
 func Start(c *exec.Cmd) (pty *os.File, err error) {
+	return StartWithSize(c, nil)
+}
+
+// StartWithSize assigns a pseudo-terminal tty os.File to c.Stdin, c.Stdout,
+// and c.Stderr, calls c.Start, and returns the File of the tty's
+// corresponding pty.
+//
+// This will resize the pty to the specified size before starting the command,
+// the tty becomes the controlling terminal of a new session.
+func StartWithSize(c *exec.Cmd, sz *Winsize) (pty *os.File, err error) {
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.SysProcAttr.Setsid = true
+	c.SysProcAttr.Setctty = true
+	return start(c, sz, c.SysProcAttr, true)
+}
+
+// StartWithAttrs assigns a pseudo-terminal tty os.File to c.Stdin, c.Stdout,
+// and c.Stderr if they are not set, calls c.Start, and returns the File of the
+// tty's corresponding pty.
+//
+// This will resize the pty to the specified size before starting the command if a size is provided,
+// attrs is used as c.SysProcAttr as is, set Setsid and Setctty to make the tty the controlling terminal.
+func StartWithAttrs(c *exec.Cmd, sz *Winsize, attrs *syscall.SysProcAttr) (pty *os.File, err error) {
+	return start(c, sz, attrs, false)
+}
+
+// start assigns the tty to all of c.Stdin, c.Stdout and c.Stderr if all is true, otherwise to the unset ones
+func start(c *exec.Cmd, sz *Winsize, attrs *syscall.SysProcAttr, all bool) (pty *os.File, err error) {
 	pty, tty, err := Open()
 	if err != nil {
 		return nil, err
 	}
 	defer tty.Close()
-	c.Stdout = tty
-	c.Stdin = tty
-	c.Stderr = tty
-	if c.SysProcAttr == nil {
-		c.SysProcAttr = &syscall.SysProcAttr{}
+	if sz != nil {
+		if err = Setsize(pty, sz); err != nil {
+			pty.Close()
+			return nil, err
+		}
 	}
-	c.SysProcAttr.Setctty = true
-	c.SysProcAttr.Setsid = true
-	err = c.Start()
-	if err != nil {
+	if all || c.Stdout == nil {
+		c.Stdout = tty
+	}
+	if all || c.Stderr == nil {
+		c.Stderr = tty
+	}
+	if all || c.Stdin == nil {
+		c.Stdin = tty
+	}
+	c.SysProcAttr = attrs
+	if err = c.Start(); err != nil {
 		pty.Close()
 		return nil, err
 	}
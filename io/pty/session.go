@@ -0,0 +1,133 @@
+// +build !windows
+
+package pty
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+)
+
+// Session a command running in a pty, with copy loops between the pty and the other side
+//
+// The session ends when the command exits and its output is drained, or when the other
+// side closes, in which case the command is hung up with SIGHUP like a closed terminal.
+type Session struct {
+	Cmd *exec.Cmd
+	Pty *os.File
+
+	mtx      *sync.Mutex
+	closed   bool
+	attached bool
+	exited   bool
+	outDone  chan struct{}
+	done     chan struct{}
+	err      error
+}
+
+// StartSession starts the command in a new pty with the size, sz can be nil
+func StartSession(c *exec.Cmd, sz *Winsize) (s *Session, err error) {
+	var p *os.File
+	if p, err = StartWithSize(c, sz); err != nil {
+		return
+	}
+	s = &Session{
+		Cmd:     c,
+		Pty:     p,
+		mtx:     &sync.Mutex{},
+		outDone: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go s.wait()
+	return
+}
+
+// wait waits for the command, then the output to be drained
+func (s *Session) wait() {
+	err := s.Cmd.Wait()
+	s.mtx.Lock()
+	s.err = err
+	s.exited = true
+	attached := s.attached
+	s.mtx.Unlock()
+	if attached {
+		<-s.outDone
+	}
+	s.Pty.Close()
+	close(s.done)
+}
+
+// Attach starts copying in to the pty and the pty to out, only the first call takes effect,
+// the session is closed when in reaches EOF or writing to out fails
+func (s *Session) Attach(in io.Reader, out io.Writer) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.attached || s.exited {
+		return
+	}
+	s.attached = true
+	go func() {
+		io.Copy(s.Pty, in)
+		s.Close()
+	}()
+	go func() {
+		defer close(s.outDone)
+		// reading a pty fails with EIO once the command and all its children exit
+		if _, err := io.Copy(out, s.Pty); err != nil && !isEIO(err) {
+			s.Close()
+		}
+	}()
+}
+
+// Resize resizes the pty
+func (s *Session) Resize(ws *Winsize) error {
+	return Setsize(s.Pty, ws)
+}
+
+// Done returns a channel closed when the session ends
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Wait waits for the session to end, returns the error of exec.Cmd.Wait
+func (s *Session) Wait() error {
+	<-s.done
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.err
+}
+
+// Run attaches in and out, and waits for the session to end
+func (s *Session) Run(in io.Reader, out io.Writer) error {
+	s.Attach(in, out)
+	return s.Wait()
+}
+
+// Close hangs up the command with SIGHUP, the session ends after the command exits
+func (s *Session) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	select {
+	case <-s.done:
+		return nil
+	default:
+	}
+	// the command leads a new process group by Setsid
+	if err := syscall.Kill(-s.Cmd.Process.Pid, syscall.SIGHUP); err != nil && err != syscall.ESRCH {
+		return err
+	}
+	return nil
+}
+
+func isEIO(err error) bool {
+	if pe, ok := err.(*os.PathError); ok {
+		err = pe.Err
+	}
+	return err == syscall.EIO
+}
@@ -0,0 +1,132 @@
+// +build linux
+
+package pty
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.String()
+}
+
+func TestStartWithSize(t *testing.T) {
+	out := &syncBuffer{}
+	s, err := StartSession(exec.Command("stty", "size"), &Winsize{Rows: 30, Cols: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	if err = s.Run(pr, out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out.String()) != "30 100" {
+		t.Errorf("unexpected size %q", out.String())
+	}
+}
+
+func TestStartAssignsTTY(t *testing.T) {
+	c := exec.Command("tty")
+	c.Stdin = strings.NewReader("")
+	p, err := Start(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	buf, _ := ioutil.ReadAll(p) // EIO once the command exits
+	c.Wait()
+	if !strings.HasPrefix(string(buf), "/dev/") {
+		t.Errorf("stdin should be the tty, got %q", buf)
+	}
+}
+
+func TestSessionInputClosed(t *testing.T) {
+	out := &syncBuffer{}
+	s, err := StartSession(exec.Command("cat"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, pw := io.Pipe()
+	s.Attach(pr, out)
+	pw.Write([]byte("hello\n"))
+	for i := 0; i < 100 && !strings.Contains(out.String(), "hello\r\nhello"); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !strings.Contains(out.String(), "hello\r\nhello") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+	pw.Close()
+	select {
+	case <-s.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("session should end after input closed")
+	}
+	if err = s.Wait(); err == nil {
+		t.Error("cat should be killed by SIGHUP")
+	}
+}
+
+func TestMakeRaw(t *testing.T) {
+	p, tty, err := Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	defer tty.Close()
+	if !IsTerminal(tty) {
+		t.Fatal("tty should be a terminal")
+	}
+	old, err := MakeRaw(tty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := GetState(tty)
+	if raw.termios.Lflag&0x2 != 0 || old.termios.Lflag&0x2 == 0 {
+		t.Error("ICANON should be cleared")
+	}
+	if err = Restore(tty, old); err != nil {
+		t.Fatal(err)
+	}
+	if cur, _ := GetState(tty); cur.termios != old.termios {
+		t.Error("state should be restored")
+	}
+}
+
+func TestWatchSize(t *testing.T) {
+	p, tty, err := Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	defer tty.Close()
+	Setsize(tty, &Winsize{Rows: 10, Cols: 20})
+	sizes, stop := WatchSize(tty)
+	if ws := <-sizes; ws.Rows != 10 || ws.Cols != 20 {
+		t.Errorf("unexpected size %+v", ws)
+	}
+	stop()
+	stop()
+	for range sizes {
+	}
+}
@@ -0,0 +1,54 @@
+// +build linux darwin freebsd dragonfly openbsd netbsd
+
+package pty
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// State the terminal state, restored by Restore
+type State struct {
+	termios syscall.Termios
+}
+
+func getTermios(t *os.File) (s *State, err error) {
+	s = &State{}
+	err = ioctl(t.Fd(), ioctlReadTermios, uintptr(unsafe.Pointer(&s.termios)))
+	return
+}
+
+// IsTerminal returns true if t is a terminal
+func IsTerminal(t *os.File) bool {
+	_, err := getTermios(t)
+	return err == nil
+}
+
+// GetState returns the current state of terminal t
+func GetState(t *os.File) (*State, error) {
+	return getTermios(t)
+}
+
+// MakeRaw puts terminal t into raw mode, like cfmakeraw(3), and returns the previous state,
+// which should be restored by Restore
+func MakeRaw(t *os.File) (old *State, err error) {
+	if old, err = getTermios(t); err != nil {
+		return
+	}
+	raw := old.termios
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	err = ioctl(t.Fd(), ioctlWriteTermios, uintptr(unsafe.Pointer(&raw)))
+	return
+}
+
+// Restore restores terminal t to a previous state
+func Restore(t *os.File, s *State) error {
+	return ioctl(t.Fd(), ioctlWriteTermios, uintptr(unsafe.Pointer(&s.termios)))
+}
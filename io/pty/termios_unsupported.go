@@ -0,0 +1,28 @@
+// +build !linux,!darwin,!freebsd,!dragonfly,!openbsd,!netbsd
+
+package pty
+
+import "os"
+
+// State the terminal state, restored by Restore
+type State struct{}
+
+// IsTerminal returns true if t is a terminal
+func IsTerminal(t *os.File) bool {
+	return false
+}
+
+// GetState returns the current state of terminal t
+func GetState(t *os.File) (*State, error) {
+	return nil, ErrUnsupported
+}
+
+// MakeRaw puts terminal t into raw mode, and returns the previous state
+func MakeRaw(t *os.File) (*State, error) {
+	return nil, ErrUnsupported
+}
+
+// Restore restores terminal t to a previous state
+func Restore(t *os.File, s *State) error {
+	return ErrUnsupported
+}
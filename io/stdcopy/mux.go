@@ -0,0 +1,462 @@
+package stdcopy
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// StreamID identifies a stream of a Mux.
+// IDs below 256 are encoded exactly as StdType in the header,
+// so the standard streams stay wire-compatible with StdCopy and NewStdWriter.
+type StreamID uint32
+
+// MaxStreamID is the largest StreamID a frame header can carry.
+const MaxStreamID StreamID = 1<<24 - 1
+
+// Frame types, stored in the second byte of the 8-byte header,
+// which is always zero in frames written by NewStdWriter.
+const (
+	// FrameData carries payload of a stream.
+	FrameData byte = iota
+	// FrameWindow grants the peer more bytes to send, the payload is a big endian uint32 increment.
+	FrameWindow
+	// FrameClose half-closes a stream, the sender will send no more data on it.
+	FrameClose
+)
+
+const (
+	muxTypeIndex = 1
+
+	// DefaultMaxFrameSize is the default limit of payload size of a frame.
+	DefaultMaxFrameSize = 32 * 1024
+)
+
+var (
+	// ErrFrameTooLarge is returned when the peer sends a frame exceeding MaxFrameSize.
+	ErrFrameTooLarge = errors.New("stdcopy: frame too large")
+	// ErrMuxClosed is returned when using a closed Mux.
+	ErrMuxClosed = errors.New("stdcopy: mux closed")
+	// ErrStreamClosed is returned when writing to a half-closed stream.
+	ErrStreamClosed = errors.New("stdcopy: stream closed")
+)
+
+// MuxOptions holds the options of a Mux.
+type MuxOptions struct {
+	// MaxFrameSize limits the payload size of frames, larger writes are split,
+	// larger incoming frames are rejected with ErrFrameTooLarge. Defaults to DefaultMaxFrameSize.
+	MaxFrameSize int
+	// Window is the initial per-stream window in bytes, both sides must use the same value.
+	// A stream never buffers more than Window unread bytes, so a slow consumer only blocks its own stream.
+	// Zero disables flow control, no FrameWindow is sent and a full stream blocks the whole Mux,
+	// which is required when the peer is a plain NewStdWriter or StdCopy.
+	Window int
+}
+
+// Mux multiplexes an arbitrary number of streams over a single io.ReadWriter,
+// using the 8-byte header of StdCopy extended with frame types.
+// The header is laid out as:
+//
+//	[0] stream id bits 0-7, [1] frame type, [2-3] stream id bits 8-23, [4-7] payload size
+//
+// Mux starts reading from the underlying reader as soon as it's created.
+// A stream is forgotten once both sides closed it, the id can then be reused.
+type Mux struct {
+	rw   io.ReadWriter
+	opts MuxOptions
+
+	wmtx *sync.Mutex // serializes frames written to rw
+
+	mtx     *sync.Mutex // protects the following fields
+	streams map[StreamID]*Stream
+	windows map[StreamID]int // window increments queued for windowLoop
+	err     error
+	done    chan struct{}
+
+	wake chan struct{} // signals windowLoop
+}
+
+// NewMux creates a Mux over rw and starts the read loop.
+func NewMux(rw io.ReadWriter, opts MuxOptions) *Mux {
+	if opts.MaxFrameSize <= 0 {
+		opts.MaxFrameSize = DefaultMaxFrameSize
+	}
+	m := &Mux{
+		rw:      rw,
+		opts:    opts,
+		wmtx:    &sync.Mutex{},
+		mtx:     &sync.Mutex{},
+		streams: map[StreamID]*Stream{},
+		windows: map[StreamID]int{},
+		done:    make(chan struct{}),
+		wake:    make(chan struct{}, 1),
+	}
+	go m.readLoop()
+	if opts.Window > 0 {
+		go m.windowLoop()
+	}
+	return m
+}
+
+// Stream returns the stream with the given id, creating it if necessary.
+// It panics if id exceeds MaxStreamID.
+func (m *Mux) Stream(id StreamID) *Stream {
+	if id > MaxStreamID {
+		panic(fmt.Sprintf("stdcopy: stream id %d out of range", id))
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.stream(id)
+}
+
+// stream returns or creates a stream, m.mtx must be held.
+func (m *Mux) stream(id StreamID) *Stream {
+	s := m.streams[id]
+	if s == nil {
+		s = &Stream{id: id, m: m, mtx: &sync.Mutex{}, sendWindow: m.opts.Window}
+		s.cond = sync.NewCond(s.mtx)
+		if m.err != nil {
+			s.rerr = m.err
+			s.werr = m.err
+		}
+		m.streams[id] = s
+	}
+	return s
+}
+
+// remove forgets s if it's still the stream of its id.
+func (m *Mux) remove(s *Stream) {
+	m.mtx.Lock()
+	if m.streams[s.id] == s {
+		delete(m.streams, s.id)
+	}
+	m.mtx.Unlock()
+}
+
+// Done returns a channel closed when the read loop stops.
+func (m *Mux) Done() <-chan struct{} {
+	return m.done
+}
+
+// Err returns the error stopped the read loop, io.EOF if the peer finished normally.
+func (m *Mux) Err() error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.err
+}
+
+// Close closes the underlying ReadWriter if it's an io.Closer, and fails all pending operations.
+func (m *Mux) Close() error {
+	m.fail(ErrMuxClosed)
+	if c, ok := m.rw.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// fail records err and wakes up all streams.
+func (m *Mux) fail(err error) {
+	m.mtx.Lock()
+	if m.err == nil {
+		m.err = err
+	}
+	err = m.err
+	streams := make([]*Stream, 0, len(m.streams))
+	for _, s := range m.streams {
+		streams = append(streams, s)
+	}
+	m.mtx.Unlock()
+	for _, s := range streams {
+		s.mtx.Lock()
+		if s.rerr == nil {
+			s.rerr = err
+		}
+		if s.werr == nil {
+			s.werr = err
+		}
+		s.cond.Broadcast()
+		s.mtx.Unlock()
+	}
+}
+
+// writeFrame writes a single frame.
+func (m *Mux) writeFrame(id StreamID, typ byte, p []byte) error {
+	buf := make([]byte, stdWriterPrefixLen+len(p))
+	buf[stdWriterFdIndex] = byte(id)
+	buf[muxTypeIndex] = typ
+	buf[2] = byte(id >> 8)
+	buf[3] = byte(id >> 16)
+	binary.BigEndian.PutUint32(buf[stdWriterSizeIndex:], uint32(len(p)))
+	copy(buf[stdWriterPrefixLen:], p)
+	m.wmtx.Lock()
+	defer m.wmtx.Unlock()
+	_, err := m.rw.Write(buf)
+	return err
+}
+
+// queueWindow queues a window update for windowLoop, so the read loop never blocks writing.
+func (m *Mux) queueWindow(id StreamID, inc int) {
+	m.mtx.Lock()
+	m.windows[id] += inc
+	m.mtx.Unlock()
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// windowLoop writes queued window updates until the read loop stops.
+func (m *Mux) windowLoop() {
+	p := make([]byte, 4)
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-m.wake:
+		}
+		m.mtx.Lock()
+		windows := m.windows
+		m.windows = map[StreamID]int{}
+		m.mtx.Unlock()
+		for id, inc := range windows {
+			binary.BigEndian.PutUint32(p, uint32(inc))
+			m.writeFrame(id, FrameWindow, p)
+		}
+	}
+}
+
+func (m *Mux) readLoop() {
+	defer close(m.done)
+	header := make([]byte, stdWriterPrefixLen)
+	for {
+		if _, err := io.ReadFull(m.rw, header); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			m.fail(err)
+			return
+		}
+		id := StreamID(header[stdWriterFdIndex]) | StreamID(header[2])<<8 | StreamID(header[3])<<16
+		typ := header[muxTypeIndex]
+		size := binary.BigEndian.Uint32(header[stdWriterSizeIndex:])
+		if size > uint32(m.opts.MaxFrameSize) {
+			m.fail(ErrFrameTooLarge)
+			return
+		}
+		p := make([]byte, size)
+		if _, err := io.ReadFull(m.rw, p); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			m.fail(err)
+			return
+		}
+		m.mtx.Lock()
+		s := m.streams[id]
+		// window updates may still arrive for a stream already forgotten
+		if s == nil && typ != FrameWindow {
+			s = m.stream(id)
+		}
+		m.mtx.Unlock()
+		if s == nil {
+			continue
+		}
+		switch typ {
+		case FrameData:
+			s.deliver(p)
+		case FrameWindow:
+			if len(p) != 4 {
+				m.fail(fmt.Errorf("stdcopy: invalid window frame of stream %d", id))
+				return
+			}
+			s.grant(int(binary.BigEndian.Uint32(p)))
+		case FrameClose:
+			s.remoteClose()
+		default:
+			m.fail(fmt.Errorf("stdcopy: unrecognized frame type %d", typ))
+			return
+		}
+	}
+}
+
+// Stream is a single channel of a Mux, it implements io.ReadWriteCloser.
+// Read and Write can be called concurrently with each other.
+type Stream struct {
+	id StreamID
+	m  *Mux
+
+	mtx  *sync.Mutex
+	cond *sync.Cond
+
+	buf      []byte // received and unread data
+	rerr     error  // returned by Read once buf is drained
+	rclosed  bool   // local side stopped reading, incoming data is discarded
+	consumed int    // bytes read since last window update
+
+	sendWindow int   // bytes allowed to send, used only with flow control
+	werr       error // returned by Write
+
+	wclosed bool // local side sent FrameClose
+	eof     bool // peer sent FrameClose
+}
+
+// ID returns the id of the stream.
+func (s *Stream) ID() StreamID {
+	return s.id
+}
+
+func (s *Stream) flowControl() bool {
+	return s.m.opts.Window > 0
+}
+
+// deliver appends incoming data, blocking while the buffer is full.
+func (s *Stream) deliver(p []byte) {
+	s.mtx.Lock()
+	if s.rclosed {
+		s.mtx.Unlock()
+		// keep the peer's window open for data that nobody reads
+		s.release(len(p))
+		return
+	}
+	limit := s.m.opts.Window
+	if limit < s.m.opts.MaxFrameSize {
+		limit = s.m.opts.MaxFrameSize
+	}
+	for len(s.buf) >= limit && s.rerr == nil && !s.rclosed {
+		s.cond.Wait()
+	}
+	s.buf = append(s.buf, p...)
+	s.cond.Broadcast()
+	s.mtx.Unlock()
+}
+
+// grant increases the send window.
+func (s *Stream) grant(n int) {
+	s.mtx.Lock()
+	s.sendWindow += n
+	s.cond.Broadcast()
+	s.mtx.Unlock()
+}
+
+// remoteClose marks the end of incoming data.
+func (s *Stream) remoteClose() {
+	s.mtx.Lock()
+	if s.rerr == nil {
+		s.rerr = io.EOF
+	}
+	s.eof = true
+	closed := s.wclosed
+	s.cond.Broadcast()
+	s.mtx.Unlock()
+	if closed {
+		s.m.remove(s)
+	}
+}
+
+// release accounts n consumed bytes and sends a window update when half of the window is consumed.
+func (s *Stream) release(n int) {
+	if !s.flowControl() || n == 0 {
+		return
+	}
+	s.mtx.Lock()
+	s.consumed += n
+	var inc int
+	if s.consumed >= s.m.opts.Window/2 {
+		inc = s.consumed
+		s.consumed = 0
+	}
+	s.mtx.Unlock()
+	if inc > 0 {
+		s.m.queueWindow(s.id, inc)
+	}
+}
+
+// Read reads data of the stream, it returns io.EOF after the peer half-closed the stream.
+func (s *Stream) Read(p []byte) (n int, err error) {
+	s.mtx.Lock()
+	for len(s.buf) == 0 && s.rerr == nil && !s.rclosed {
+		s.cond.Wait()
+	}
+	if s.rclosed {
+		s.mtx.Unlock()
+		return 0, ErrStreamClosed
+	}
+	if len(s.buf) == 0 {
+		err = s.rerr
+		s.mtx.Unlock()
+		return
+	}
+	n = copy(p, s.buf)
+	s.buf = s.buf[n:]
+	s.cond.Broadcast()
+	s.mtx.Unlock()
+	s.release(n)
+	return
+}
+
+// Write writes data to the stream, split into frames of at most MaxFrameSize bytes,
+// with flow control it blocks until the peer grants enough window.
+func (s *Stream) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		size := len(p)
+		if size > s.m.opts.MaxFrameSize {
+			size = s.m.opts.MaxFrameSize
+		}
+		s.mtx.Lock()
+		if s.flowControl() {
+			for s.sendWindow <= 0 && s.werr == nil {
+				s.cond.Wait()
+			}
+			if size > s.sendWindow {
+				size = s.sendWindow
+			}
+		}
+		if s.werr != nil {
+			err = s.werr
+			s.mtx.Unlock()
+			return
+		}
+		if s.flowControl() {
+			s.sendWindow -= size
+		}
+		s.mtx.Unlock()
+		if err = s.m.writeFrame(s.id, FrameData, p[:size]); err != nil {
+			return
+		}
+		n += size
+		p = p[size:]
+	}
+	return
+}
+
+// CloseWrite half-closes the stream, the peer reads io.EOF after all data written before.
+func (s *Stream) CloseWrite() error {
+	s.mtx.Lock()
+	if s.werr != nil {
+		s.mtx.Unlock()
+		return nil
+	}
+	s.werr = ErrStreamClosed
+	s.wclosed = true
+	eof := s.eof
+	s.cond.Broadcast()
+	s.mtx.Unlock()
+	if eof {
+		s.m.remove(s)
+	}
+	return s.m.writeFrame(s.id, FrameClose, nil)
+}
+
+// Close half-closes the stream and stops reading from it, data still arriving is discarded.
+func (s *Stream) Close() error {
+	s.mtx.Lock()
+	s.rclosed = true
+	unread := len(s.buf)
+	s.buf = nil
+	s.cond.Broadcast()
+	s.mtx.Unlock()
+	s.release(unread)
+	return s.CloseWrite()
+}
@@ -0,0 +1,174 @@
+package stdcopy
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+)
+
+func newMuxPair(opts MuxOptions) (*Mux, *Mux) {
+	c1, c2 := net.Pipe()
+	return NewMux(c1, opts), NewMux(c2, opts)
+}
+
+func TestMuxStreams(t *testing.T) {
+	m1, m2 := newMuxPair(MuxOptions{MaxFrameSize: 16, Window: 64})
+	defer m1.Close()
+	defer m2.Close()
+	data := bytes.Repeat([]byte("0123456789"), 100)
+	for _, id := range []StreamID{StreamID(Stdout), 1000, MaxStreamID} {
+		go func(id StreamID) {
+			s := m1.Stream(id)
+			s.Write(data)
+			s.CloseWrite()
+		}(id)
+	}
+	for _, id := range []StreamID{StreamID(Stdout), 1000, MaxStreamID} {
+		buf, err := ioutil.ReadAll(m2.Stream(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf, data) {
+			t.Errorf("stream %d received %d bytes, expect %d", id, len(buf), len(data))
+		}
+	}
+	if _, err := m1.Stream(StreamID(Stdout)).Write([]byte("x")); err != ErrStreamClosed {
+		t.Errorf("write after CloseWrite should fail, got %v", err)
+	}
+}
+
+func TestMuxFlowControl(t *testing.T) {
+	m1, m2 := newMuxPair(MuxOptions{MaxFrameSize: 8, Window: 32})
+	defer m1.Close()
+	defer m2.Close()
+	// nobody reads stderr, stdout must not be blocked
+	go m1.Stream(StreamID(Stderr)).Write(make([]byte, 1024))
+	go m1.Stream(StreamID(Stdout)).Write([]byte("hello world"))
+	buf := make([]byte, 11)
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadFull(m2.Stream(StreamID(Stdout)), buf)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil || string(buf) != "hello world" {
+			t.Errorf("unexpected stdout %q %v", buf, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stdout blocked by stderr")
+	}
+	// the unread stream is bounded by the window
+	time.Sleep(50 * time.Millisecond)
+	s := m2.Stream(StreamID(Stderr))
+	s.mtx.Lock()
+	n := len(s.buf)
+	s.mtx.Unlock()
+	if n > 32 {
+		t.Errorf("unread stream buffered %d bytes, exceeding window", n)
+	}
+	if buf, _ := ioutil.ReadAll(io.LimitReader(s, 1024)); len(buf) != 1024 {
+		t.Errorf("stderr received %d bytes", len(buf))
+	}
+}
+
+func TestMuxStdCompatible(t *testing.T) {
+	// StdWriter -> Mux
+	var buf bytes.Buffer
+	NewStdWriter(&buf, Stdout).Write([]byte("out"))
+	NewStdWriter(&buf, Stderr).Write([]byte("err"))
+	m := NewMux(&readWriter{Reader: &buf, Writer: ioutil.Discard}, MuxOptions{})
+	<-m.Done()
+	if m.Err() != io.EOF {
+		t.Errorf("unexpected error %v", m.Err())
+	}
+	if b, _ := ioutil.ReadAll(m.Stream(StreamID(Stdout))); string(b) != "out" {
+		t.Errorf("unexpected stdout %q", b)
+	}
+	if b, _ := ioutil.ReadAll(m.Stream(StreamID(Stderr))); string(b) != "err" {
+		t.Errorf("unexpected stderr %q", b)
+	}
+	// Mux -> StdCopy
+	var wire bytes.Buffer
+	m = NewMux(&readWriter{Reader: &bytes.Buffer{}, Writer: &wire}, MuxOptions{})
+	m.Stream(StreamID(Stdout)).Write([]byte("out"))
+	m.Stream(StreamID(Stderr)).Write([]byte("err"))
+	var out, errb bytes.Buffer
+	if _, err := StdCopy(&out, &errb, &wire); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "out" || errb.String() != "err" {
+		t.Errorf("unexpected StdCopy output %q %q", out.String(), errb.String())
+	}
+}
+
+func TestMuxFrameTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	NewStdWriter(&buf, Stdout).Write(make([]byte, 100))
+	m := NewMux(&readWriter{Reader: &buf, Writer: ioutil.Discard}, MuxOptions{MaxFrameSize: 10})
+	<-m.Done()
+	if m.Err() != ErrFrameTooLarge {
+		t.Errorf("expect ErrFrameTooLarge, got %v", m.Err())
+	}
+	if _, err := m.Stream(StreamID(Stdout)).Read(make([]byte, 1)); err != ErrFrameTooLarge {
+		t.Errorf("expect ErrFrameTooLarge from stream, got %v", err)
+	}
+}
+
+func TestMuxReleaseStreams(t *testing.T) {
+	m1, m2 := newMuxPair(MuxOptions{MaxFrameSize: 16, Window: 64})
+	defer m1.Close()
+	defer m2.Close()
+	for id := StreamID(10); id < 20; id++ {
+		s1 := m1.Stream(id)
+		s1.Write([]byte("ping"))
+		s1.CloseWrite()
+		s2 := m2.Stream(id)
+		if b, _ := ioutil.ReadAll(s2); string(b) != "ping" {
+			t.Fatalf("unexpected data %q", b)
+		}
+		s2.Write([]byte("pong"))
+		s2.CloseWrite()
+		if b, _ := ioutil.ReadAll(s1); string(b) != "pong" {
+			t.Fatalf("unexpected data %q", b)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	for _, m := range []*Mux{m1, m2} {
+		m.mtx.Lock()
+		n := len(m.streams)
+		m.mtx.Unlock()
+		if n != 0 {
+			t.Errorf("%d streams left after both sides closed", n)
+		}
+	}
+}
+
+func TestMuxDiscardDeadlock(t *testing.T) {
+	m1, m2 := newMuxPair(MuxOptions{MaxFrameSize: 8, Window: 16})
+	defer m1.Close()
+	defer m2.Close()
+	// both read loops discard data of a closed stream while the peer keeps writing,
+	// window updates of the discarded data must not block the read loops
+	m1.Stream(1).Close()
+	m2.Stream(2).Close()
+	data := make([]byte, 64*1024)
+	done := make(chan struct{}, 2)
+	go func() { m2.Stream(1).Write(data); done <- struct{}{} }()
+	go func() { m1.Stream(2).Write(data); done <- struct{}{} }()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatal("deadlock writing window updates")
+		}
+	}
+}
+
+type readWriter struct {
+	io.Reader
+	io.Writer
+}
@@ -0,0 +1,20 @@
+package log
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the Logger l, typically a child
+// logger created by With for a single request.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the Logger carried by ctx, if any.
+func FromContext(ctx context.Context) (l *Logger, ok bool) {
+	if ctx == nil {
+		return
+	}
+	l, ok = ctx.Value(contextKey{}).(*Logger)
+	return
+}
@@ -0,0 +1,17 @@
+package log
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("background context should carry no logger")
+	}
+	l := New(os.Stderr, "", 0).With("crid", "abc")
+	if got, ok := FromContext(NewContext(context.Background(), l)); !ok || got != l {
+		t.Error("logger should be carried by the context")
+	}
+}
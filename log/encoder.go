@@ -0,0 +1,186 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Field is a key-value pair attached to log entries, see Logger.With.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Entry is a single logging event handed to an Encoder.
+type Entry struct {
+	Time    time.Time // time of the event
+	Level   Level     // severity, LevelNone for the Print family
+	Prefix  string    // prefix of the Logger
+	Flag    int       // output flags of the Logger
+	File    string    // source file, set only if Llongfile or Lshortfile is set
+	Line    int       // source line, set only if Llongfile or Lshortfile is set
+	Message string    // formatted message, may end with a newline
+	Fields  []Field   // fields attached by With
+}
+
+// Encoder renders an Entry. Encode appends exactly one line, including
+// the terminating newline, to buf and returns the extended buffer.
+// Encode is always called with the Logger lock held.
+type Encoder interface {
+	Encode(buf []byte, e *Entry) []byte
+}
+
+// TextEncoder renders entries as the bracketed text line of the standard
+// logger, followed by the level and the fields, for example
+//
+//	[2009/01/23 01:23:23.123] prefix [INFO] message key=value
+type TextEncoder struct{}
+
+// Encode implements Encoder.
+func (TextEncoder) Encode(buf []byte, e *Entry) []byte {
+	formatHeader(&buf, e.Flag, e.Prefix, e.Time, e.File, e.Line)
+	if e.Level != LevelNone {
+		buf = append(buf, '[')
+		buf = append(buf, e.Level.String()...)
+		buf = append(buf, ']', ' ')
+	}
+	msg := e.Message
+	if len(e.Fields) > 0 {
+		msg = trimNewline(msg)
+	}
+	buf = append(buf, msg...)
+	for _, f := range e.Fields {
+		buf = append(buf, ' ')
+		buf = appendTextValue(buf, f.Key)
+		buf = append(buf, '=')
+		buf = appendTextValue(buf, fmt.Sprint(f.Value))
+	}
+	if len(e.Fields) > 0 || len(msg) == 0 || msg[len(msg)-1] != '\n' {
+		buf = append(buf, '\n')
+	}
+	return buf
+}
+
+// appendTextValue appends s, quoted if it would be ambiguous unquoted.
+func appendTextValue(buf []byte, s string) []byte {
+	if needsQuote(s) {
+		return strconv.AppendQuote(buf, s)
+	}
+	return append(buf, s...)
+}
+
+func needsQuote(s string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// JSONEncoder renders entries as one JSON object per line. The object
+// has the keys "time" (if any of the date or time flags is set), "level"
+// (for leveled entries), "prefix" (if not blank), "file" (if Llongfile or
+// Lshortfile is set) and "msg", followed by the fields. Values that cannot
+// be marshaled are written as strings in the manner of fmt.Sprint.
+type JSONEncoder struct{}
+
+// Encode implements Encoder.
+func (JSONEncoder) Encode(buf []byte, e *Entry) []byte {
+	buf = append(buf, '{')
+	if e.Flag&(Ldate|Ltime|Lmilliseconds|Lmicroseconds) != 0 {
+		t := e.Time
+		if e.Flag&LUTC != 0 {
+			t = t.UTC()
+		}
+		buf = appendJSONKey(buf, "time")
+		buf = append(buf, '"')
+		buf = t.AppendFormat(buf, time.RFC3339Nano)
+		buf = append(buf, '"')
+	}
+	if e.Level != LevelNone {
+		buf = appendJSONKey(buf, "level")
+		buf = appendJSONString(buf, e.Level.String())
+	}
+	if len(e.Prefix) > 0 {
+		buf = appendJSONKey(buf, "prefix")
+		buf = appendJSONString(buf, e.Prefix)
+	}
+	if e.Flag&(Lshortfile|Llongfile) != 0 {
+		file := e.File
+		if e.Flag&Lshortfile != 0 {
+			for i := len(file) - 1; i > 0; i-- {
+				if file[i] == '/' {
+					file = file[i+1:]
+					break
+				}
+			}
+		}
+		buf = appendJSONKey(buf, "file")
+		buf = appendJSONString(buf, file+":"+strconv.Itoa(e.Line))
+	}
+	buf = appendJSONKey(buf, "msg")
+	buf = appendJSONString(buf, trimNewline(e.Message))
+	for _, f := range e.Fields {
+		buf = appendJSONKey(buf, f.Key)
+		buf = appendJSONValue(buf, f.Value)
+	}
+	return append(buf, '}', '\n')
+}
+
+func appendJSONKey(buf []byte, key string) []byte {
+	if buf[len(buf)-1] != '{' {
+		buf = append(buf, ',')
+	}
+	buf = appendJSONString(buf, key)
+	return append(buf, ':')
+}
+
+func appendJSONString(buf []byte, s string) []byte {
+	b, _ := json.Marshal(s)
+	return append(buf, b...)
+}
+
+func appendJSONValue(buf []byte, v interface{}) []byte {
+	if err, ok := v.(error); ok {
+		return appendJSONString(buf, err.Error())
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return appendJSONString(buf, fmt.Sprint(v))
+	}
+	return append(buf, b...)
+}
+
+// appendFields converts alternating keys and values to fields, a key
+// already in fields is overridden in place.
+func appendFields(fields []Field, kv []interface{}) []Field {
+next:
+	for i := 0; i < len(kv); i += 2 {
+		f := Field{Key: fmt.Sprint(kv[i])}
+		if i+1 < len(kv) {
+			f.Value = kv[i+1]
+		}
+		for j := range fields {
+			if fields[j].Key == f.Key {
+				fields[j].Value = f.Value
+				continue next
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func trimNewline(s string) string {
+	if len(s) > 0 && s[len(s)-1] == '\n' {
+		return s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,99 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestLeveled(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "Test:", 0)
+	l.SetLevel(LevelInfo)
+	l.Debug("dropped")
+	l.Info("hello ", 23)
+	l.Warnf("hello %d", 24)
+	l.Println("always")
+	l.SetLevel(LevelError)
+	l.Warn("dropped")
+	l.Error("failed")
+	want := "Test:[INFO] hello 23\nTest:[WARN] hello 24\nTest:always\nTest:[ERROR] failed\n"
+	if b.String() != want {
+		t.Errorf("got %q; want %q", b.String(), want)
+	}
+	if l.Enabled(LevelWarn) || !l.Enabled(LevelError) || !l.Enabled(LevelNone) {
+		t.Error("unexpected Enabled result")
+	}
+}
+
+func TestWith(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", 0)
+	c := l.With("crid", "abc", "path", "/a b")
+	c.With("n", 1, "dangling").Info("hello")
+	c.Println("world")
+	l.Print("plain")
+	want := "[INFO] hello crid=abc path=\"/a b\" n=1 dangling=<nil>\nworld crid=abc path=\"/a b\"\nplain\n"
+	if b.String() != want {
+		t.Errorf("got %q; want %q", b.String(), want)
+	}
+	if c.mu != l.mu {
+		t.Error("child logger should share the lock of its parent")
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "web", Ldate|Lshortfile)
+	l.SetEncoder(JSONEncoder{})
+	l.With("err", errors.New("boom"), "n", 2, "ch", make(chan int)).Errorf("failed %s\n", "here")
+	var m map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &m); err != nil {
+		t.Fatalf("invalid JSON %q: %s", b.String(), err)
+	}
+	if m["level"] != "ERROR" || m["prefix"] != "web" || m["msg"] != "failed here" {
+		t.Errorf("unexpected entry %v", m)
+	}
+	if m["err"] != "boom" || m["n"] != 2.0 || m["time"] == nil {
+		t.Errorf("unexpected fields %v", m)
+	}
+	if f, _ := m["file"].(string); len(f) == 0 || f[0] == '/' {
+		t.Errorf("unexpected file %q", f)
+	}
+}
+
+func TestWithOverride(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", 0)
+	l.SetEncoder(JSONEncoder{})
+	c := l.With("a", 1, "b", 2)
+	c.With("a", 3).Info("x")
+	c.Info("y")
+	want := `{"level":"INFO","msg":"x","a":3,"b":2}` + "\n" + `{"level":"INFO","msg":"y","a":1,"b":2}` + "\n"
+	if b.String() != want {
+		t.Errorf("got %q; want %q", b.String(), want)
+	}
+}
+
+func TestZeroLogger(t *testing.T) {
+	var l Logger
+	l.Info("dropped")
+	var b bytes.Buffer
+	l.SetOutput(&b)
+	l.With("k", "v").Warn("hi")
+	if want := "[WARN] hi k=v\n"; b.String() != want {
+		t.Errorf("got %q; want %q", b.String(), want)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]Level{"debug": LevelDebug, "INFO": LevelInfo, "Warning": LevelWarn, "error": LevelError} {
+		if lvl, err := ParseLevel(s); err != nil || lvl != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", s, lvl, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
@@ -0,0 +1,53 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Level is the severity of a log entry.
+type Level int
+
+// Levels of log entries, from the least to the most severe. Entries written
+// by the Print, Fatal and Panic families carry LevelNone, which is never
+// filtered and is not rendered by the encoders.
+const (
+	LevelNone Level = iota
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{
+	LevelNone:  "",
+	LevelDebug: "DEBUG",
+	LevelInfo:  "INFO",
+	LevelWarn:  "WARN",
+	LevelError: "ERROR",
+}
+
+// String returns the upper case name of the level, or an empty string for
+// LevelNone.
+func (lvl Level) String() string {
+	if lvl < 0 || int(lvl) >= len(levelNames) {
+		return fmt.Sprintf("LEVEL(%d)", int(lvl))
+	}
+	return levelNames[lvl]
+}
+
+// ParseLevel converts a case-insensitive level name to a Level, "warning"
+// is accepted as an alias of "warn".
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelNone, fmt.Errorf("log: unknown level %q", s)
+}
@@ -12,6 +12,11 @@
 // printed does not end in a newline, the logger will add one.
 // The Fatal functions call os.Exit(1) after writing the log message.
 // The Panic functions call panic after writing the log message.
+//
+// In addition to the Print family, a Logger offers leveled methods
+// Debug[f], Info[f], Warn[f] and Error[f], filtered by a minimum Level,
+// key-value fields attached through With, and a pluggable Encoder that
+// renders each entry either as the bracketed text line or as JSON.
 package log
 
 import (
@@ -48,12 +53,18 @@ const (
 // output to an io.Writer. Each logging operation makes a single call to
 // the Writer's Write method. A Logger can be used simultaneously from
 // multiple goroutines; it guarantees to serialize access to the Writer.
+// The zero value is a Logger with TextEncoder and no flags, writing nothing
+// until SetOutput is called.
 type Logger struct {
-	mu     sync.Mutex // ensures atomic writes; protects the following fields
-	prefix string     // prefix to write at beginning of each line
-	flag   int        // properties
-	out    io.Writer  // destination for output
-	buf    []byte     // for accumulating text to write
+	once    sync.Once   // allocates mu of a zero-value Logger
+	mu      *sync.Mutex // ensures atomic writes; shared with child loggers; protects the following fields
+	prefix  string      // prefix to write at beginning of each line
+	flag    int         // properties
+	out     io.Writer   // destination for output
+	buf     []byte      // for accumulating text to write
+	level   Level       // minimum level of leveled entries to write
+	encoder Encoder     // renders entries into buf
+	fields  []Field     // fields attached by With
 }
 
 // New creates a new Logger. The out variable sets the
@@ -61,12 +72,45 @@ type Logger struct {
 // The prefix appears at the beginning of each generated log line.
 // The flag argument defines the logging properties.
 func New(out io.Writer, prefix string, flag int) *Logger {
-	return &Logger{out: out, prefix: prefix, flag: flag}
+	return &Logger{mu: new(sync.Mutex), out: out, prefix: prefix, flag: flag, level: LevelDebug, encoder: TextEncoder{}}
+}
+
+// lock locks the mutex of l, which is allocated on first use for a zero-value Logger.
+func (l *Logger) lock() {
+	l.once.Do(func() {
+		if l.mu == nil {
+			l.mu = new(sync.Mutex)
+		}
+	})
+	l.mu.Lock()
+}
+
+// With returns a child Logger that writes every entry with the given
+// key-value pairs appended to the fields of l. Keys are formatted with
+// fmt.Sprint; a trailing key without value is written with a nil value.
+// A key already attached keeps its position and takes the later value.
+// The child copies the current settings of l and shares its lock, so
+// entries of parent and child written to the same output never interleave.
+func (l *Logger) With(kv ...interface{}) *Logger {
+	l.lock()
+	defer l.mu.Unlock()
+	fields := make([]Field, 0, len(l.fields)+(len(kv)+1)/2)
+	fields = append(fields, l.fields...)
+	fields = appendFields(fields, kv)
+	return &Logger{
+		mu:      l.mu,
+		prefix:  l.prefix,
+		flag:    l.flag,
+		out:     l.out,
+		level:   l.level,
+		encoder: l.encoder,
+		fields:  fields,
+	}
 }
 
 // SetOutput sets the output destination for the logger.
 func (l *Logger) SetOutput(w io.Writer) {
-	l.mu.Lock()
+	l.lock()
 	defer l.mu.Unlock()
 	l.out = w
 }
@@ -91,46 +135,46 @@ func itoa(buf *[]byte, i int, wid int) {
 }
 
 // formatHeader writes log header to buf in following order:
-//   * l.prefix (if it's not blank),
 //   * date and/or time (if corresponding flags are provided),
+//   * prefix (if it's not blank),
 //   * file and line number (if corresponding flags are provided).
-func (l *Logger) formatHeader(buf *[]byte, t time.Time, file string, line int) {
-	if l.flag&(Ldate|Ltime|Lmilliseconds|Lmicroseconds) != 0 {
+func formatHeader(buf *[]byte, flag int, prefix string, t time.Time, file string, line int) {
+	if flag&(Ldate|Ltime|Lmilliseconds|Lmicroseconds) != 0 {
 		*buf = append(*buf, '[')
-		if l.flag&LUTC != 0 {
+		if flag&LUTC != 0 {
 			t = t.UTC()
 		}
-		if l.flag&Ldate != 0 {
+		if flag&Ldate != 0 {
 			year, month, day := t.Date()
 			itoa(buf, year, 4)
 			*buf = append(*buf, '/')
 			itoa(buf, int(month), 2)
 			*buf = append(*buf, '/')
 			itoa(buf, day, 2)
-			if l.flag&(Ltime|Lmilliseconds|Lmicroseconds) != 0 {
+			if flag&(Ltime|Lmilliseconds|Lmicroseconds) != 0 {
 				*buf = append(*buf, ' ')
 			}
 		}
-		if l.flag&(Ltime|Lmilliseconds|Lmicroseconds) != 0 {
+		if flag&(Ltime|Lmilliseconds|Lmicroseconds) != 0 {
 			hour, min, sec := t.Clock()
 			itoa(buf, hour, 2)
 			*buf = append(*buf, ':')
 			itoa(buf, min, 2)
 			*buf = append(*buf, ':')
 			itoa(buf, sec, 2)
-			if l.flag&Lmilliseconds != 0 {
+			if flag&Lmilliseconds != 0 {
 				*buf = append(*buf, '.')
 				itoa(buf, t.Nanosecond()/1e6, 3)
-			} else if l.flag&Lmicroseconds != 0 {
+			} else if flag&Lmicroseconds != 0 {
 				*buf = append(*buf, '.')
 				itoa(buf, t.Nanosecond()/1e3, 6)
 			}
 		}
 		*buf = append(*buf, ']', ' ')
 	}
-	*buf = append(*buf, l.prefix...)
-	if l.flag&(Lshortfile|Llongfile) != 0 {
-		if l.flag&Lshortfile != 0 {
+	*buf = append(*buf, prefix...)
+	if flag&(Lshortfile|Llongfile) != 0 {
+		if flag&Lshortfile != 0 {
 			short := file
 			for i := len(file) - 1; i > 0; i-- {
 				if file[i] == '/' {
@@ -154,11 +198,20 @@ func (l *Logger) formatHeader(buf *[]byte, t time.Time, file string, line int) {
 // provided for generality, although at the moment on all pre-defined
 // paths it will be 2.
 func (l *Logger) Output(calldepth int, s string) error {
+	return l.output(calldepth+1, LevelNone, s)
+}
+
+// output writes an entry of the given level, dropping leveled entries
+// below the minimum level of l. Entries of LevelNone are never dropped.
+func (l *Logger) output(calldepth int, lvl Level, s string) error {
 	now := time.Now() // get this early.
 	var file string
 	var line int
-	l.mu.Lock()
+	l.lock()
 	defer l.mu.Unlock()
+	if lvl != LevelNone && lvl < l.level {
+		return nil
+	}
 	if l.flag&(Lshortfile|Llongfile) != 0 {
 		// Release lock while getting caller info - it's expensive.
 		l.mu.Unlock()
@@ -170,12 +223,24 @@ func (l *Logger) Output(calldepth int, s string) error {
 		}
 		l.mu.Lock()
 	}
-	l.buf = l.buf[:0]
-	l.formatHeader(&l.buf, now, file, line)
-	l.buf = append(l.buf, s...)
-	if len(s) == 0 || s[len(s)-1] != '\n' {
-		l.buf = append(l.buf, '\n')
+	e := Entry{
+		Time:    now,
+		Level:   lvl,
+		Prefix:  l.prefix,
+		Flag:    l.flag,
+		File:    file,
+		Line:    line,
+		Message: s,
+		Fields:  l.fields,
 	}
+	if l.out == nil {
+		return nil
+	}
+	enc := l.encoder
+	if enc == nil {
+		enc = TextEncoder{}
+	}
+	l.buf = enc.Encode(l.buf[:0], &e)
 	_, err := l.out.Write(l.buf)
 	return err
 }
@@ -233,37 +298,134 @@ func (l *Logger) Panicln(v ...interface{}) {
 	panic(s)
 }
 
+// Debug calls l.Output to print to the logger at LevelDebug.
+// Arguments are handled in the manner of fmt.Print.
+func (l *Logger) Debug(v ...interface{}) { l.output(2, LevelDebug, fmt.Sprint(v...)) }
+
+// Debugf calls l.Output to print to the logger at LevelDebug.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) Debugf(format string, v ...interface{}) {
+	l.output(2, LevelDebug, fmt.Sprintf(format, v...))
+}
+
+// Info calls l.Output to print to the logger at LevelInfo.
+// Arguments are handled in the manner of fmt.Print.
+func (l *Logger) Info(v ...interface{}) { l.output(2, LevelInfo, fmt.Sprint(v...)) }
+
+// Infof calls l.Output to print to the logger at LevelInfo.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) Infof(format string, v ...interface{}) {
+	l.output(2, LevelInfo, fmt.Sprintf(format, v...))
+}
+
+// Warn calls l.Output to print to the logger at LevelWarn.
+// Arguments are handled in the manner of fmt.Print.
+func (l *Logger) Warn(v ...interface{}) { l.output(2, LevelWarn, fmt.Sprint(v...)) }
+
+// Warnf calls l.Output to print to the logger at LevelWarn.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) Warnf(format string, v ...interface{}) {
+	l.output(2, LevelWarn, fmt.Sprintf(format, v...))
+}
+
+// Error calls l.Output to print to the logger at LevelError.
+// Arguments are handled in the manner of fmt.Print.
+func (l *Logger) Error(v ...interface{}) { l.output(2, LevelError, fmt.Sprint(v...)) }
+
+// Errorf calls l.Output to print to the logger at LevelError.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) Errorf(format string, v ...interface{}) {
+	l.output(2, LevelError, fmt.Sprintf(format, v...))
+}
+
+// Level returns the minimum level for the logger.
+func (l *Logger) Level() Level {
+	l.lock()
+	defer l.mu.Unlock()
+	return l.level
+}
+
+// SetLevel sets the minimum level for the logger. Leveled entries below
+// it are discarded; the Print, Fatal and Panic families are not affected.
+func (l *Logger) SetLevel(lvl Level) {
+	l.lock()
+	defer l.mu.Unlock()
+	l.level = lvl
+}
+
+// Enabled reports whether entries of the given level would be written.
+func (l *Logger) Enabled(lvl Level) bool {
+	l.lock()
+	defer l.mu.Unlock()
+	return lvl == LevelNone || lvl >= l.level
+}
+
+// Encoder returns the entry encoder for the logger.
+func (l *Logger) Encoder() Encoder {
+	l.lock()
+	defer l.mu.Unlock()
+	return l.encoder
+}
+
+// SetEncoder sets the entry encoder for the logger.
+func (l *Logger) SetEncoder(enc Encoder) {
+	l.lock()
+	defer l.mu.Unlock()
+	l.encoder = enc
+}
+
 // Flags returns the output flags for the logger.
 func (l *Logger) Flags() int {
-	l.mu.Lock()
+	l.lock()
 	defer l.mu.Unlock()
 	return l.flag
 }
 
 // SetFlags sets the output flags for the logger.
 func (l *Logger) SetFlags(flag int) {
-	l.mu.Lock()
+	l.lock()
 	defer l.mu.Unlock()
 	l.flag = flag
 }
 
 // Prefix returns the output prefix for the logger.
 func (l *Logger) Prefix() string {
-	l.mu.Lock()
+	l.lock()
 	defer l.mu.Unlock()
 	return l.prefix
 }
 
 // SetPrefix sets the output prefix for the logger.
 func (l *Logger) SetPrefix(prefix string) {
-	l.mu.Lock()
+	l.lock()
 	defer l.mu.Unlock()
 	l.prefix = prefix
 }
 
+// Default returns the standard logger used by the package-level functions.
+func Default() *Logger {
+	return std
+}
+
+// With returns a child of the standard logger with the given key-value
+// pairs attached, see Logger.With.
+func With(kv ...interface{}) *Logger {
+	return std.With(kv...)
+}
+
+// SetLevel sets the minimum level for the standard logger.
+func SetLevel(lvl Level) {
+	std.SetLevel(lvl)
+}
+
+// SetEncoder sets the entry encoder for the standard logger.
+func SetEncoder(enc Encoder) {
+	std.SetEncoder(enc)
+}
+
 // SetOutput sets the output destination for the standard logger.
 func SetOutput(w io.Writer) {
-	std.mu.Lock()
+	std.lock()
 	defer std.mu.Unlock()
 	std.out = w
 }
@@ -308,6 +470,54 @@ func Println(v ...interface{}) {
 	std.Output(2, fmt.Sprintln(v...))
 }
 
+// Debug calls Output to print to the standard logger at LevelDebug.
+// Arguments are handled in the manner of fmt.Print.
+func Debug(v ...interface{}) {
+	std.output(2, LevelDebug, fmt.Sprint(v...))
+}
+
+// Debugf calls Output to print to the standard logger at LevelDebug.
+// Arguments are handled in the manner of fmt.Printf.
+func Debugf(format string, v ...interface{}) {
+	std.output(2, LevelDebug, fmt.Sprintf(format, v...))
+}
+
+// Info calls Output to print to the standard logger at LevelInfo.
+// Arguments are handled in the manner of fmt.Print.
+func Info(v ...interface{}) {
+	std.output(2, LevelInfo, fmt.Sprint(v...))
+}
+
+// Infof calls Output to print to the standard logger at LevelInfo.
+// Arguments are handled in the manner of fmt.Printf.
+func Infof(format string, v ...interface{}) {
+	std.output(2, LevelInfo, fmt.Sprintf(format, v...))
+}
+
+// Warn calls Output to print to the standard logger at LevelWarn.
+// Arguments are handled in the manner of fmt.Print.
+func Warn(v ...interface{}) {
+	std.output(2, LevelWarn, fmt.Sprint(v...))
+}
+
+// Warnf calls Output to print to the standard logger at LevelWarn.
+// Arguments are handled in the manner of fmt.Printf.
+func Warnf(format string, v ...interface{}) {
+	std.output(2, LevelWarn, fmt.Sprintf(format, v...))
+}
+
+// Error calls Output to print to the standard logger at LevelError.
+// Arguments are handled in the manner of fmt.Print.
+func Error(v ...interface{}) {
+	std.output(2, LevelError, fmt.Sprint(v...))
+}
+
+// Errorf calls Output to print to the standard logger at LevelError.
+// Arguments are handled in the manner of fmt.Printf.
+func Errorf(format string, v ...interface{}) {
+	std.output(2, LevelError, fmt.Sprintf(format, v...))
+}
+
 // Fatal is equivalent to Print() followed by a call to os.Exit(1).
 func Fatal(v ...interface{}) {
 	std.Output(2, fmt.Sprint(v...))
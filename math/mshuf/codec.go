@@ -0,0 +1,155 @@
+package mshuf
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	// Base32Alphabet lower case Crockford's base32 alphabet, without i, l, o and u
+	Base32Alphabet = "0123456789abcdefghjkmnpqrstvwxyz"
+	// Base62Alphabet digits, upper and lower case letters
+	Base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+var (
+	// ErrInvalidToken token has wrong length, invalid character or overflows uint64
+	ErrInvalidToken = errors.New("mshuf: invalid token")
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (m Matrix) MarshalBinary() ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), m...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (m *Matrix) UnmarshalBinary(data []byte) error {
+	r := Matrix(append([]byte(nil), data...))
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	*m = r
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, one hex digit per entry
+func (m Matrix) MarshalText() ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (m *Matrix) UnmarshalText(text []byte) (err error) {
+	var r Matrix
+	if r, err = ParseMatrix(string(text)); err != nil {
+		return
+	}
+	*m = r
+	return
+}
+
+// String returns the matrix as 256 hex digits, one per entry
+func (m Matrix) String() string {
+	const digits = "0123456789abcdef"
+	b := make([]byte, len(m), len(m))
+	for i, d := range m {
+		b[i] = digits[d&0x0f]
+	}
+	return string(b)
+}
+
+// ParseMatrix parse a matrix from the output of Matrix.String
+func ParseMatrix(s string) (m Matrix, err error) {
+	s = strings.TrimSpace(s)
+	if len(s) != MatrixLength {
+		err = ErrInvalidMatrix
+		return
+	}
+	m = NewMatrix()
+	for i := 0; i < MatrixLength; i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			m[i] = c - '0'
+		case c >= 'a' && c <= 'f':
+			m[i] = c - 'a' + 10
+		case c >= 'A' && c <= 'F':
+			m[i] = c - 'A' + 10
+		default:
+			err = ErrInvalidMatrix
+			return
+		}
+	}
+	err = m.Validate()
+	return
+}
+
+// Codec turns ids into short url-safe tokens by shuffling them with a matrix,
+// and encoding the result with an alphabet, tokens have fixed length for an alphabet
+type Codec struct {
+	m        Matrix
+	inv      Matrix
+	alphabet string
+	width    int
+}
+
+// NewCodec create a new codec with a valid matrix and an alphabet of at least 2 unique ASCII characters,
+// e.g. NewCodec(NewMatrixFromKey(secret), Base62Alphabet)
+func NewCodec(m Matrix, alphabet string) (c *Codec, err error) {
+	if err = m.Validate(); err != nil {
+		return
+	}
+	if len(alphabet) < 2 {
+		err = errors.New("mshuf: alphabet too short")
+		return
+	}
+	for i := 0; i < len(alphabet); i++ {
+		if alphabet[i] >= 0x80 || strings.IndexByte(alphabet[i+1:], alphabet[i]) >= 0 {
+			err = errors.New("mshuf: invalid alphabet")
+			return
+		}
+	}
+	c = &Codec{m: m, inv: m.Inverse(), alphabet: alphabet}
+	// width is the number of digits of max uint64
+	for n := ^uint64(0); n > 0; n /= uint64(len(alphabet)) {
+		c.width++
+	}
+	return
+}
+
+// Encode shuffle and encode an id
+func (c *Codec) Encode(id uint64) string {
+	n := c.m.Shuffle(id)
+	base := uint64(len(c.alphabet))
+	b := make([]byte, c.width, c.width)
+	for i := c.width - 1; i >= 0; i-- {
+		b[i] = c.alphabet[n%base]
+		n /= base
+	}
+	return string(b)
+}
+
+// Decode decode and unshuffle a token created by Encode
+func (c *Codec) Decode(token string) (id uint64, err error) {
+	if len(token) != c.width {
+		err = ErrInvalidToken
+		return
+	}
+	base := uint64(len(c.alphabet))
+	var n uint64
+	for i := 0; i < len(token); i++ {
+		d := strings.IndexByte(c.alphabet, token[i])
+		if d < 0 || n > (^uint64(0)-uint64(d))/base {
+			err = ErrInvalidToken
+			return
+		}
+		n = n*base + uint64(d)
+	}
+	id = c.inv.Shuffle(n)
+	return
+}
@@ -0,0 +1,66 @@
+package mshuf
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/quick"
+)
+
+func TestMatrix_Marshal(t *testing.T) {
+	m := NewMatrixFromKey([]byte("secret"))
+	buf, err := json.Marshal(map[string]Matrix{"m": m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r map[string]Matrix
+	if err = json.Unmarshal(buf, &r); err != nil {
+		t.Fatal(err)
+	}
+	if r["m"].String() != m.String() {
+		t.Error("text round trip failed")
+	}
+	b, _ := m.MarshalBinary()
+	var r2 Matrix
+	if err = r2.UnmarshalBinary(b); err != nil || r2.String() != m.String() {
+		t.Error("binary round trip failed", err)
+	}
+	if _, err = ParseMatrix("0123"); err != ErrInvalidMatrix {
+		t.Error("short text should be invalid")
+	}
+}
+
+func TestCodec(t *testing.T) {
+	m := NewMatrixFromKey([]byte("secret"))
+	for _, alphabet := range []string{Base32Alphabet, Base62Alphabet} {
+		c, err := NewCodec(m, alphabet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := func(n uint64) bool {
+			s := c.Encode(n)
+			r, err := c.Decode(s)
+			return err == nil && r == n && len(s) == c.width
+		}
+		if err = quick.Check(f, nil); err != nil {
+			t.Error(err)
+		}
+		for _, n := range []uint64{0, 1, ^uint64(0)} {
+			if r, err := c.Decode(c.Encode(n)); err != nil || r != n {
+				t.Errorf("round trip failed for %v", n)
+			}
+		}
+		if _, err = c.Decode("!"); err != ErrInvalidToken {
+			t.Error("invalid token should fail")
+		}
+	}
+	c, _ := NewCodec(m, Base62Alphabet)
+	if _, err := c.Decode("zzzzzzzzzzz"); err != ErrInvalidToken {
+		t.Error("overflow token should fail")
+	}
+	if _, err := NewCodec(m, "aa"); err == nil {
+		t.Error("duplicated alphabet should fail")
+	}
+	if _, err := NewCodec(NewMatrix(), Base62Alphabet); err == nil {
+		t.Error("invalid matrix should fail")
+	}
+}
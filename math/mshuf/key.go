@@ -0,0 +1,42 @@
+package mshuf
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// keyReader an endless deterministic stream of HMAC-SHA256(key, counter) blocks
+type keyReader struct {
+	key []byte
+	ctr uint64
+	buf []byte
+}
+
+func (kr *keyReader) Read(p []byte) (n int, err error) {
+	for n < len(p) {
+		if len(kr.buf) == 0 {
+			c := make([]byte, 8, 8)
+			binary.BigEndian.PutUint64(c, kr.ctr)
+			kr.ctr++
+			h := hmac.New(sha256.New, kr.key)
+			h.Write(c)
+			kr.buf = h.Sum(nil)
+		}
+		c := copy(p[n:], kr.buf)
+		kr.buf = kr.buf[c:]
+		n += c
+	}
+	return
+}
+
+// NewMatrixFromKey derive a matrix deterministically from a secret key,
+// same key always produces the same matrix
+func NewMatrixFromKey(key []byte) Matrix {
+	m := NewMatrix()
+	r := &keyReader{key: key}
+	for i := 0; i < MatrixSize; i++ {
+		m.RandomRowAt(r, i)
+	}
+	return m
+}
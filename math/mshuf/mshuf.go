@@ -2,10 +2,16 @@ package mshuf
 
 import (
 	"encoding/binary"
+	"errors"
 	"io"
 	"math/rand"
 )
 
+var (
+	// ErrInvalidMatrix matrix has wrong length, or a row is not a permutation of 0 to f
+	ErrInvalidMatrix = errors.New("mshuf: invalid matrix")
+)
+
 // Matrix a matrix for mshuf
 type Matrix []byte
 
@@ -50,6 +56,54 @@ func (m Matrix) Shuffle(n uint64) uint64 {
 	return binary.BigEndian.Uint64(b)
 }
 
+// Unshuffle reverse Shuffle, m.Unshuffle(m.Shuffle(n)) == n for a valid matrix
+func (m Matrix) Unshuffle(n uint64) uint64 {
+	b := make([]byte, 8, 8)
+	binary.BigEndian.PutUint64(b, n)
+	for i := 0; i < 8; i++ {
+		d := b[i]
+		b[i] = indexOf(m[i*2*MatrixSize:(i*2+1)*MatrixSize], d>>4)<<4 + indexOf(m[(i*2+1)*MatrixSize:(i*2+2)*MatrixSize], d&0x0f)
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+// Inverse create the inverse matrix, m.Inverse().Shuffle() is equivalent to m.Unshuffle()
+func (m Matrix) Inverse() Matrix {
+	r := NewMatrix()
+	for n := 0; n < MatrixSize; n++ {
+		for i := 0; i < MatrixSize; i++ {
+			r[n*MatrixSize+int(m[n*MatrixSize+i])] = byte(i)
+		}
+	}
+	return r
+}
+
+// Validate check the matrix has correct length and every row is a permutation of 0 to f
+func (m Matrix) Validate() error {
+	if len(m) != MatrixLength {
+		return ErrInvalidMatrix
+	}
+	for n := 0; n < MatrixSize; n++ {
+		var seen uint16
+		for _, d := range m[n*MatrixSize : (n+1)*MatrixSize] {
+			if d >= MatrixSize || seen&(1<<d) != 0 {
+				return ErrInvalidMatrix
+			}
+			seen |= 1 << d
+		}
+	}
+	return nil
+}
+
+func indexOf(row []byte, d byte) byte {
+	for i, v := range row {
+		if v == d {
+			return byte(i)
+		}
+	}
+	return 0
+}
+
 // RandSequence create a rand sequence from 0 to f
 func randSequence(seed int64, seq []byte) {
 	// fill sequence
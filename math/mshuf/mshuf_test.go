@@ -1,9 +1,11 @@
 package mshuf
 
 import (
+	crand "crypto/rand"
 	"encoding/binary"
 	"math/rand"
 	"testing"
+	"testing/quick"
 )
 
 func TestMatrix_ShuffleIdentity(t *testing.T) {
@@ -21,3 +23,66 @@ func TestMatrix_ShuffleIdentity(t *testing.T) {
 		}
 	}
 }
+
+func randomMatrix() Matrix {
+	m := NewMatrix()
+	for i := 0; i < MatrixSize; i++ {
+		m.RandomRowAt(crand.Reader, i)
+	}
+	return m
+}
+
+func TestMatrix_UnshuffleProperty(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		m := randomMatrix()
+		if err := m.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		inv := m.Inverse()
+		f := func(n uint64) bool {
+			r := m.Shuffle(n)
+			return m.Unshuffle(r) == n && inv.Shuffle(r) == n && m.Shuffle(m.Unshuffle(n)) == n
+		}
+		if err := quick.Check(f, nil); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestMatrix_ShuffleInjective(t *testing.T) {
+	m := NewMatrixFromKey([]byte("secret"))
+	// every nibble is mapped independently, exhausting the lowest 16 bits covers every row used by them
+	seen := map[uint64]bool{}
+	for n := uint64(0); n < 1<<16; n++ {
+		r := m.Shuffle(n)
+		if seen[r] {
+			t.Fatalf("Shuffle is not injective, %v collides", n)
+		}
+		seen[r] = true
+	}
+}
+
+func TestNewMatrixFromKey(t *testing.T) {
+	m1 := NewMatrixFromKey([]byte("secret"))
+	m2 := NewMatrixFromKey([]byte("secret"))
+	m3 := NewMatrixFromKey([]byte("another"))
+	if err := m1.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if m1.String() != m2.String() {
+		t.Error("same key should derive same matrix")
+	}
+	if m1.String() == m3.String() {
+		t.Error("different keys should derive different matrices")
+	}
+}
+
+func TestMatrix_Validate(t *testing.T) {
+	m := NewMatrix()
+	if m.Validate() != ErrInvalidMatrix {
+		t.Error("empty matrix should be invalid")
+	}
+	if Matrix(make([]byte, 10)).Validate() != ErrInvalidMatrix {
+		t.Error("short matrix should be invalid")
+	}
+}
@@ -0,0 +1,332 @@
+// Package graceful provides zero-downtime restart for servers.
+//
+// On restart, the binary re-executes itself, passing its listening sockets to the
+// new process as extra files, described by environment variable EnvFDs, while the old
+// process drains existing connections. TCP listeners are created by net/reuse on unix, so that
+// with Options.ReusePort set, the new process binds the same address by itself instead, and
+// the old process keeps serving until the new one calls Ready.
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"landzero.net/x/log"
+)
+
+const (
+	// EnvFDs environment variable describing inherited listeners, as comma separated
+	// "network:address", the n-th listener is file descriptor 3+n
+	EnvFDs = "GRACEFUL_FDS"
+	// EnvReady environment variable of the file descriptor, which the new process writes to
+	// by Ready once it's serving
+	EnvReady = "GRACEFUL_READY"
+
+	// DefaultTimeout default timeout of draining servers
+	DefaultTimeout = time.Second * 30
+
+	firstInheritedFD = 3
+)
+
+var (
+	// ErrUnsupportedListener listener can't provide a file descriptor
+	ErrUnsupportedListener = errors.New("graceful: listener doesn't support File()")
+	// ErrNotReady new process exited or timed out before calling Ready
+	ErrNotReady = errors.New("graceful: new process is not ready")
+)
+
+// Server a server can be drained, *http.Server implements it
+type Server interface {
+	Shutdown(ctx context.Context) error
+}
+
+// Options options for Graceful
+type Options struct {
+	// ReusePort don't pass tcp listeners to the new process, the new process binds the same
+	// address with SO_REUSEPORT, and must call Ready once it's serving, unix listeners are always passed
+	ReusePort bool
+	// Timeout timeout of draining servers, and of waiting the new process to be ready,
+	// defaults to DefaultTimeout
+	Timeout time.Duration
+	// Args command line of the new process, defaults to os.Args, the executable is always os.Executable()
+	Args []string
+}
+
+type listener struct {
+	network string
+	address string
+	l       net.Listener
+}
+
+// Graceful manages listeners and servers of a process for graceful restart
+type Graceful struct {
+	opts Options
+
+	mtx       *sync.Mutex
+	inherited map[string]*os.File
+	ready     *os.File
+	listeners []listener
+	servers   []Server
+}
+
+// New create a Graceful, listeners passed by parent process are collected from EnvFDs,
+// which is then unset with EnvReady, so child processes don't take the file descriptors
+func New(opts Options) *Graceful {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	g := &Graceful{
+		opts:      opts,
+		mtx:       &sync.Mutex{},
+		inherited: map[string]*os.File{},
+	}
+	for i, key := range splitFDs(os.Getenv(EnvFDs)) {
+		g.inherited[key] = os.NewFile(uintptr(firstInheritedFD+i), key)
+	}
+	if fd, err := strconv.Atoi(os.Getenv(EnvReady)); err == nil && fd >= firstInheritedFD {
+		g.ready = os.NewFile(uintptr(fd), EnvReady)
+	}
+	os.Unsetenv(EnvFDs)
+	os.Unsetenv(EnvReady)
+	return g
+}
+
+// Ready tells the parent process that the current process is serving, the parent waits
+// for it before shutdown if Options.ReusePort is set, it does nothing if not started by Restart
+func (g *Graceful) Ready() (err error) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	if g.ready == nil {
+		return
+	}
+	_, err = g.ready.Write([]byte{1})
+	g.ready.Close()
+	g.ready = nil
+	return
+}
+
+func splitFDs(s string) (keys []string) {
+	if len(s) == 0 {
+		return
+	}
+	return strings.Split(s, ",")
+}
+
+func fdKey(network, address string) string {
+	return network + ":" + address
+}
+
+// Inherited returns true if any listener is passed by parent process
+func (g *Graceful) Inherited() bool {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	return len(g.inherited) > 0
+}
+
+// Listen returns the listener passed by parent process with same network and address,
+// or creates a new one, tcp listeners are created with SO_REUSEPORT, stale unix socket files are removed
+func (g *Graceful) Listen(network, address string) (l net.Listener, err error) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	key := fdKey(network, address)
+	if f := g.inherited[key]; f != nil {
+		delete(g.inherited, key)
+		l, err = net.FileListener(f)
+		f.Close()
+	} else {
+		switch network {
+		case "tcp", "tcp4", "tcp6":
+			l, err = listenTCP(network, address)
+		case "unix":
+			os.Remove(address)
+			l, err = net.Listen(network, address)
+		default:
+			l, err = net.Listen(network, address)
+		}
+	}
+	if err != nil {
+		return
+	}
+	g.listeners = append(g.listeners, listener{network: network, address: address, l: l})
+	return
+}
+
+// Add add a server to be drained by Shutdown
+func (g *Graceful) Add(s Server) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	g.servers = append(g.servers, s)
+}
+
+// Restart start a new process of the same executable, passing listeners to it,
+// the current process should Shutdown afterward. If any tcp listener is not passed for
+// Options.ReusePort, it returns after the new process calls Ready, or kills the new process
+// and returns ErrNotReady if it exits or times out before.
+func (g *Graceful) Restart() (p *os.Process, err error) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	var exe string
+	if exe, err = os.Executable(); err != nil {
+		return
+	}
+	args := g.opts.Args
+	if len(args) == 0 {
+		args = os.Args
+	}
+	var keys []string
+	var files []*os.File
+	var unixListeners []*net.UnixListener
+	var reused bool
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, ln := range g.listeners {
+		if g.opts.ReusePort && strings.HasPrefix(ln.network, "tcp") {
+			reused = true
+			continue
+		}
+		fl, ok := ln.l.(interface {
+			File() (*os.File, error)
+		})
+		if !ok {
+			err = ErrUnsupportedListener
+			return
+		}
+		var f *os.File
+		if f, err = fl.File(); err != nil {
+			return
+		}
+		files = append(files, f)
+		keys = append(keys, fdKey(ln.network, ln.address))
+		if ul, ok := ln.l.(*net.UnixListener); ok {
+			unixListeners = append(unixListeners, ul)
+		}
+	}
+	env := make([]string, 0, len(os.Environ())+2)
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, EnvFDs+"=") && !strings.HasPrefix(kv, EnvReady+"=") {
+			env = append(env, kv)
+		}
+	}
+	env = append(env, EnvFDs+"="+strings.Join(keys, ","))
+	// the new process binds tcp addresses by itself, wait for it to be ready
+	var ready *os.File
+	if reused {
+		var w *os.File
+		if ready, w, err = os.Pipe(); err != nil {
+			return
+		}
+		defer ready.Close()
+		env = append(env, EnvReady+"="+strconv.Itoa(firstInheritedFD+len(files)))
+		files = append(files, w)
+	}
+	cmd := &exec.Cmd{
+		Path:       exe,
+		Args:       args,
+		Env:        env,
+		Stdin:      os.Stdin,
+		Stdout:     os.Stdout,
+		Stderr:     os.Stderr,
+		ExtraFiles: files,
+	}
+	err = cmd.Start()
+	// passing files to the new process puts them, and the listeners sharing them, in blocking mode
+	for _, f := range files {
+		setNonblock(f)
+	}
+	if err != nil {
+		err = fmt.Errorf("graceful: failed to start new process: %v", err)
+		return
+	}
+	if ready != nil {
+		// close the write end, so read fails once the new process exits
+		files[len(files)-1].Close()
+		files = files[:len(files)-1]
+		if err = waitReady(ready, g.opts.Timeout); err != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+			return
+		}
+	}
+	// the socket files are now owned by the new process
+	for _, ul := range unixListeners {
+		ul.SetUnlinkOnClose(false)
+	}
+	p = cmd.Process
+	return
+}
+
+func waitReady(r *os.File, timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := r.Read(make([]byte, 1))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return ErrNotReady
+		}
+		return nil
+	case <-time.After(timeout):
+		return ErrNotReady
+	}
+}
+
+// Shutdown drain all servers within timeout, and close all listeners
+func (g *Graceful) Shutdown() (err error) {
+	g.mtx.Lock()
+	servers := append([]Server(nil), g.servers...)
+	listeners := append([]listener(nil), g.listeners...)
+	g.mtx.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), g.opts.Timeout)
+	defer cancel()
+	wg := &sync.WaitGroup{}
+	errs := make([]error, len(servers))
+	for i, s := range servers {
+		wg.Add(1)
+		go func(i int, s Server) {
+			defer wg.Done()
+			errs[i] = s.Shutdown(ctx)
+		}(i, s)
+	}
+	wg.Wait()
+	for _, ln := range listeners {
+		ln.l.Close() // servers may already closed it
+	}
+	for _, e := range errs {
+		if e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+// Wait blocks until a terminating signal, on restart signal (SIGUSR2, not available on Windows)
+// a new process is started before shutdown, if restart fails the current process keeps running
+func (g *Graceful) Wait() error {
+	ch := make(chan os.Signal, 1)
+	notify(ch)
+	defer signal.Stop(ch)
+	for sig := range ch {
+		if isRestart(sig) {
+			if _, err := g.Restart(); err != nil {
+				log.Println("graceful: restart failed:", err)
+				continue
+			}
+		}
+		break
+	}
+	return g.Shutdown()
+}
@@ -0,0 +1,194 @@
+// +build !windows
+
+package graceful
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+const (
+	envHelper  = "GRACEFUL_TEST_HELPER"
+	envAddrs   = "GRACEFUL_TEST_ADDRS"
+	envNoReady = "GRACEFUL_TEST_NO_READY"
+)
+
+func serve(t testing.TB, g *Graceful, network, address, name string) net.Listener {
+	l, err := g.Listen(network, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &http.Server{Handler: http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, name)
+	})}
+	g.Add(s)
+	go s.Serve(l)
+	return l
+}
+
+// TestHelperProcess the new process started by Restart
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv(envHelper) != "1" {
+		return
+	}
+	g := New(Options{})
+	if !g.Inherited() {
+		t.Fatal("no listener inherited")
+	}
+	if len(os.Getenv(EnvFDs)) > 0 || len(os.Getenv(EnvReady)) > 0 {
+		t.Fatal(EnvFDs, "or", EnvReady, "not unset")
+	}
+	for _, addr := range strings.Split(os.Getenv(envAddrs), ",") {
+		kv := strings.SplitN(addr, ":", 2)
+		serve(t, g, kv[0], kv[1], "child")
+	}
+	if os.Getenv(envNoReady) != "1" {
+		g.Ready()
+	}
+	g.Wait()
+}
+
+func get(network, address string) (string, error) {
+	c := &http.Client{Transport: &http.Transport{
+		DisableKeepAlives: true,
+		Dial: func(string, string) (net.Conn, error) {
+			return net.Dial(network, address)
+		},
+	}}
+	res, err := c.Get("http://graceful/")
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	buf, err := ioutil.ReadAll(res.Body)
+	return string(buf), err
+}
+
+func TestRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "graceful")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "test.sock")
+
+	g := New(Options{Args: []string{os.Args[0], "-test.run=TestHelperProcess"}})
+	tl := serve(t, g, "tcp", "127.0.0.1:0", "parent")
+	serve(t, g, "unix", sock, "parent")
+	addrs := map[string]string{"tcp": tl.Addr().String(), "unix": sock}
+
+	for network, address := range addrs {
+		if s, err := get(network, address); err != nil || s != "parent" {
+			t.Fatal(network, s, err)
+		}
+	}
+
+	os.Setenv(envHelper, "1")
+	os.Setenv(envAddrs, "tcp:127.0.0.1:0,unix:"+sock)
+	defer os.Unsetenv(envHelper)
+	defer os.Unsetenv(envAddrs)
+
+	p, err := g.Restart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		p.Signal(syscall.SIGTERM)
+		p.Wait()
+	}()
+	if err = g.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(sock); err != nil {
+		t.Fatal("socket file removed by old process:", err)
+	}
+
+	for network, address := range addrs {
+		var s string
+		for i := 0; i < 50; i++ {
+			if s, err = get(network, address); err == nil {
+				break
+			}
+			time.Sleep(time.Millisecond * 100)
+		}
+		if err != nil || s != "child" {
+			t.Fatal(network, s, err)
+		}
+	}
+}
+
+func TestRestartReusePort(t *testing.T) {
+	dir, err := ioutil.TempDir("", "graceful")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "test.sock")
+
+	g := New(Options{ReusePort: true, Args: []string{os.Args[0], "-test.run=TestHelperProcess"}})
+	tl := serve(t, g, "tcp", "127.0.0.1:0", "parent")
+	serve(t, g, "unix", sock, "parent")
+
+	os.Setenv(envHelper, "1")
+	os.Setenv(envAddrs, "tcp:"+tl.Addr().String()+",unix:"+sock)
+	defer os.Unsetenv(envHelper)
+	defer os.Unsetenv(envAddrs)
+
+	p, err := g.Restart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		p.Signal(syscall.SIGTERM)
+		p.Wait()
+	}()
+	if err = g.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	// the new process is serving once Restart returns
+	if s, err := get("tcp", tl.Addr().String()); err != nil || s != "child" {
+		t.Fatal(s, err)
+	}
+}
+
+func TestRestartNotReady(t *testing.T) {
+	dir, err := ioutil.TempDir("", "graceful")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "test.sock")
+
+	g := New(Options{ReusePort: true, Timeout: time.Second, Args: []string{os.Args[0], "-test.run=TestHelperProcess"}})
+	tl := serve(t, g, "tcp", "127.0.0.1:0", "parent")
+	serve(t, g, "unix", sock, "parent")
+
+	os.Setenv(envHelper, "1")
+	os.Setenv(envNoReady, "1")
+	os.Setenv(envAddrs, "tcp:"+tl.Addr().String()+",unix:"+sock)
+	defer os.Unsetenv(envHelper)
+	defer os.Unsetenv(envNoReady)
+	defer os.Unsetenv(envAddrs)
+
+	if _, err = g.Restart(); err != ErrNotReady {
+		t.Fatal("expect", ErrNotReady, "got", err)
+	}
+	if s, err := get("tcp", tl.Addr().String()); err != nil || s != "parent" {
+		t.Fatal(s, err)
+	}
+	// still owns the socket file
+	if err = g.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(sock); !os.IsNotExist(err) {
+		t.Fatal("socket file should be removed:", err)
+	}
+}
@@ -0,0 +1,32 @@
+// +build !windows
+
+package graceful
+
+import (
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"landzero.net/x/net/reuse"
+)
+
+func listenTCP(network, address string) (net.Listener, error) {
+	return reuse.Listen(network, address)
+}
+
+func setNonblock(f *os.File) {
+	if rc, err := f.SyscallConn(); err == nil {
+		rc.Control(func(fd uintptr) {
+			syscall.SetNonblock(int(fd), true)
+		})
+	}
+}
+
+func notify(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR2)
+}
+
+func isRestart(sig os.Signal) bool {
+	return sig == syscall.SIGUSR2
+}
@@ -0,0 +1,23 @@
+package graceful
+
+import (
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// SO_REUSEPORT is not available, listeners can only be passed
+func listenTCP(network, address string) (net.Listener, error) {
+	return net.Listen(network, address)
+}
+
+func setNonblock(f *os.File) {}
+
+func notify(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
+}
+
+func isRestart(sig os.Signal) bool {
+	return false
+}
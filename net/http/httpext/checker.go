@@ -0,0 +1,81 @@
+package httpext
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"landzero.net/x/database/orm"
+	"landzero.net/x/database/redis"
+)
+
+// Checker a health check, returns nil if healthy
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc function as Checker
+type CheckerFunc func(ctx context.Context) error
+
+// Check implements Checker
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// HTTPChecker checks url responds with 2xx status code
+func HTTPChecker(url string) Checker {
+	return CheckerFunc(func(ctx context.Context) (err error) {
+		var req *http.Request
+		if req, err = http.NewRequest(http.MethodGet, url, nil); err != nil {
+			return
+		}
+		var resp *http.Response
+		if resp, err = http.DefaultClient.Do(req.WithContext(ctx)); err != nil {
+			return
+		}
+		defer resp.Body.Close()
+		io.Copy(ioutil.Discard, resp.Body)
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			err = fmt.Errorf("bad status code: %d", resp.StatusCode)
+		}
+		return
+	})
+}
+
+// TCPChecker checks address accepts tcp connection
+func TCPChecker(address string) Checker {
+	return CheckerFunc(func(ctx context.Context) (err error) {
+		var c net.Conn
+		if c, err = (&net.Dialer{}).DialContext(ctx, "tcp", address); err != nil {
+			return
+		}
+		return c.Close()
+	})
+}
+
+// SQLChecker checks database connection of orm.DB with ping
+func SQLChecker(db *orm.DB) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		return db.DB().PingContext(ctx)
+	})
+}
+
+// RedisChecker checks redis connection with PING
+func RedisChecker(c redis.Cmdable) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		// PING doesn't accept a context, wait for it in a goroutine
+		ch := make(chan error, 1)
+		go func() {
+			ch <- c.Ping().Err()
+		}()
+		select {
+		case err := <-ch:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
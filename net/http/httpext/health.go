@@ -0,0 +1,316 @@
+package httpext
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"landzero.net/x/log"
+)
+
+const (
+	// DefaultCheckInterval default interval of active checks
+	DefaultCheckInterval = time.Second * 10
+	// DefaultCheckTimeout default timeout of a single check
+	DefaultCheckTimeout = time.Second * 5
+	// DefaultFailureThreshold default count of consecutive failures to become unhealthy
+	DefaultFailureThreshold = 3
+	// DefaultSuccessThreshold default count of consecutive successes to become healthy
+	DefaultSuccessThreshold = 1
+)
+
+var (
+	// ErrCheckNotFound check with the name is not added
+	ErrCheckNotFound = errors.New("healthcheck: check not found")
+)
+
+// Check a named health check
+type Check struct {
+	// Name unique name of the check
+	Name string
+	// Checker the checker, nil for a passive check which is only updated by HealthChecker.Report
+	Checker Checker
+	// Interval interval of active checking, defaults to DefaultCheckInterval
+	Interval time.Duration
+	// Timeout timeout of a single check, defaults to DefaultCheckTimeout
+	Timeout time.Duration
+	// FailureThreshold consecutive failures to become unhealthy, defaults to DefaultFailureThreshold
+	FailureThreshold int
+	// SuccessThreshold consecutive successes to become healthy, defaults to DefaultSuccessThreshold
+	SuccessThreshold int
+	// Liveness the check also affects liveness, all checks affect readiness
+	Liveness bool
+}
+
+// CheckStatus status of a check
+type CheckStatus struct {
+	Name                 string    `json:"name"`
+	Healthy              bool      `json:"healthy"`
+	Liveness             bool      `json:"liveness,omitempty"`
+	Error                string    `json:"error,omitempty"`
+	LastCheck            time.Time `json:"last_check"`
+	ConsecutiveFailures  int       `json:"consecutive_failures"`
+	ConsecutiveSuccesses int       `json:"consecutive_successes"`
+
+	failureThreshold int
+}
+
+// HealthChecker runs named checks, and aggregates their results to readiness and liveness.
+// Checks start unhealthy and become healthy after SuccessThreshold successes.
+type HealthChecker struct {
+	mtx     *sync.Mutex
+	checks  []Check
+	status  map[string]*CheckStatus
+	stops   map[string]chan struct{}
+	started bool
+	done    chan struct{}
+}
+
+// NewHealthChecker create a new HealthChecker
+func NewHealthChecker() *HealthChecker {
+	return &HealthChecker{
+		mtx:    &sync.Mutex{},
+		status: map[string]*CheckStatus{},
+		stops:  map[string]chan struct{}{},
+		done:   make(chan struct{}),
+	}
+}
+
+// Add add a check, an existed check with same name is replaced, checks added after Start are started immediately
+func (h *HealthChecker) Add(c Check) {
+	if c.Interval <= 0 {
+		c.Interval = DefaultCheckInterval
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultCheckTimeout
+	}
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = DefaultFailureThreshold
+	}
+	if c.SuccessThreshold <= 0 {
+		c.SuccessThreshold = DefaultSuccessThreshold
+	}
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	for i, e := range h.checks {
+		if e.Name == c.Name {
+			h.checks = append(h.checks[:i], h.checks[i+1:]...)
+			break
+		}
+	}
+	if stop := h.stops[c.Name]; stop != nil {
+		close(stop)
+		delete(h.stops, c.Name)
+	}
+	h.checks = append(h.checks, c)
+	h.status[c.Name] = &CheckStatus{Name: c.Name, Liveness: c.Liveness, failureThreshold: c.FailureThreshold}
+	if h.started {
+		h.start(c)
+	}
+}
+
+// Start start active checking of all checks in background
+func (h *HealthChecker) Start() {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if h.started {
+		return
+	}
+	h.started = true
+	for _, c := range h.checks {
+		h.start(c)
+	}
+}
+
+// start starts checking c in background until stopped or replaced, h.mtx must be held
+func (h *HealthChecker) start(c Check) {
+	if c.Checker == nil {
+		return
+	}
+	stop := make(chan struct{})
+	h.stops[c.Name] = stop
+	go func() {
+		t := time.NewTicker(c.Interval)
+		defer t.Stop()
+		for {
+			h.run(context.Background(), c, stop)
+			select {
+			case <-t.C:
+			case <-stop:
+				return
+			case <-h.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stop active checking, a stopped HealthChecker can't be started again
+func (h *HealthChecker) Stop() {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	select {
+	case <-h.done:
+	default:
+		close(h.done)
+	}
+}
+
+// run runs c once and reports the result, unless stop is closed meanwhile as c is replaced
+func (h *HealthChecker) run(ctx context.Context, c Check, stop chan struct{}) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+	err = c.Checker.Check(ctx)
+	h.report(c.Name, err, stop)
+	return
+}
+
+// Report report a result of the check with the name, used by passive checks, or to report
+// failures observed from real traffic
+func (h *HealthChecker) Report(name string, err error) error {
+	return h.report(name, err, nil)
+}
+
+func (h *HealthChecker) report(name string, err error, stop chan struct{}) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if stop != nil {
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+	}
+	s := h.status[name]
+	if s == nil {
+		return ErrCheckNotFound
+	}
+	var c Check
+	for _, e := range h.checks {
+		if e.Name == name {
+			c = e
+		}
+	}
+	s.LastCheck = time.Now()
+	if err == nil {
+		s.Error = ""
+		s.ConsecutiveFailures = 0
+		s.ConsecutiveSuccesses++
+		if !s.Healthy && s.ConsecutiveSuccesses >= c.SuccessThreshold {
+			s.Healthy = true
+			log.Println("healthcheck:", name, "is healthy")
+		}
+	} else {
+		s.Error = err.Error()
+		s.ConsecutiveSuccesses = 0
+		s.ConsecutiveFailures++
+		if s.Healthy && s.ConsecutiveFailures >= c.FailureThreshold {
+			s.Healthy = false
+			log.Println("healthcheck:", name, "is unhealthy:", err.Error())
+		}
+	}
+	return nil
+}
+
+// CheckAll run all active checks once synchronously, results are reported, returns the first error regardless of thresholds
+func (h *HealthChecker) CheckAll(ctx context.Context) (err error) {
+	h.mtx.Lock()
+	checks := append([]Check(nil), h.checks...)
+	h.mtx.Unlock()
+	for _, c := range checks {
+		if c.Checker == nil {
+			continue
+		}
+		if e := h.run(ctx, c, nil); e != nil && err == nil {
+			err = errors.New(c.Name + ": " + e.Error())
+		}
+	}
+	return
+}
+
+// Statuses returns statuses of all checks, in order of adding
+func (h *HealthChecker) Statuses() []CheckStatus {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	ss := make([]CheckStatus, 0, len(h.checks))
+	for _, c := range h.checks {
+		ss = append(ss, *h.status[c.Name])
+	}
+	return ss
+}
+
+// Ready returns true if all checks are healthy
+func (h *HealthChecker) Ready() bool {
+	for _, s := range h.Statuses() {
+		if !s.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// Live returns true if no liveness check reached FailureThreshold consecutive failures, checks
+// not run yet or still warming up towards SuccessThreshold are considered alive
+func (h *HealthChecker) Live() bool {
+	for _, s := range h.Statuses() {
+		if s.Liveness && s.ConsecutiveFailures >= s.failureThreshold {
+			return false
+		}
+	}
+	return true
+}
+
+type healthResponse struct {
+	Status string        `json:"status"`
+	Checks []CheckStatus `json:"checks"`
+}
+
+func (h *HealthChecker) handler(ok func() bool, liveness bool) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		res := healthResponse{Status: "ok", Checks: []CheckStatus{}}
+		for _, s := range h.Statuses() {
+			if !liveness || s.Liveness {
+				res.Checks = append(res.Checks, s)
+			}
+		}
+		code := http.StatusOK
+		if !ok() {
+			res.Status = "unavailable"
+			code = http.StatusServiceUnavailable
+		}
+		rw.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		rw.Header().Set("Cache-Control", "no-cache")
+		rw.WriteHeader(code)
+		json.NewEncoder(rw).Encode(res)
+	}
+}
+
+// ReadinessHandler returns a handler responds 200 if Ready, or 503, with statuses of all checks in JSON,
+// can be mounted in net/web directly
+func (h *HealthChecker) ReadinessHandler() http.HandlerFunc {
+	return h.handler(h.Ready, false)
+}
+
+// LivenessHandler returns a handler responds 200 if Live, or 503, with statuses of liveness checks in JSON,
+// can be mounted in net/web directly
+func (h *HealthChecker) LivenessHandler() http.HandlerFunc {
+	return h.handler(h.Live, true)
+}
+
+// HealthCheckExitCode check url once with timeout, returns 0 if healthy or 1 if not, suitable for
+// os.Exit in a container HEALTHCHECK command
+func HealthCheckExitCode(url string, timeout time.Duration) int {
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := HTTPChecker(url).Check(ctx); err != nil {
+		log.Println("healthcheck: failed:", err.Error())
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,163 @@
+package httpext
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckers(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	defer ok.Close()
+	bad := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer bad.Close()
+	ctx := context.Background()
+	if err := HTTPChecker(ok.URL).Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := HTTPChecker(bad.URL).Check(ctx); err == nil {
+		t.Fatal("should fail")
+	}
+	if err := TCPChecker(ok.Listener.Addr().String()).Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := l.Addr().String()
+	l.Close()
+	if err := TCPChecker(addr).Check(ctx); err == nil {
+		t.Fatal("should fail")
+	}
+	if HealthCheckExitCode(ok.URL, time.Second) != 0 || HealthCheckExitCode(bad.URL, time.Second) != 1 {
+		t.Fatal("bad exit code")
+	}
+}
+
+func TestHealthCheckerThresholds(t *testing.T) {
+	h := NewHealthChecker()
+	h.Add(Check{Name: "db", FailureThreshold: 2, SuccessThreshold: 2, Liveness: true})
+	h.Add(Check{Name: "cache"})
+	if h.Ready() || !h.Live() {
+		t.Fatal("should be not ready but alive initially")
+	}
+	if err := h.Report("missing", nil); err != ErrCheckNotFound {
+		t.Fatal(err)
+	}
+	h.Report("cache", nil)
+	h.Report("db", nil)
+	if h.Ready() {
+		t.Fatal("db needs 2 successes")
+	}
+	h.Report("db", nil)
+	if !h.Ready() || !h.Live() {
+		t.Fatal("should be ready")
+	}
+	h.Report("db", errors.New("boom"))
+	if !h.Ready() {
+		t.Fatal("db needs 2 failures")
+	}
+	h.Report("db", errors.New("boom"))
+	if h.Ready() || h.Live() {
+		t.Fatal("should be not ready and not alive")
+	}
+	if s := h.Statuses()[0]; s.Name != "db" || s.Error != "boom" || s.ConsecutiveFailures != 2 {
+		t.Fatal(s)
+	}
+}
+
+func TestHealthCheckerWarmUp(t *testing.T) {
+	h := NewHealthChecker()
+	h.Add(Check{Name: "db", FailureThreshold: 2, SuccessThreshold: 2, Liveness: true})
+	h.Report("db", nil)
+	if h.Ready() || !h.Live() {
+		t.Fatal("should be alive while warming up")
+	}
+	h.Report("db", errors.New("boom"))
+	if !h.Live() {
+		t.Fatal("db needs 2 failures to be not alive")
+	}
+	h.Report("db", errors.New("boom"))
+	if h.Live() {
+		t.Fatal("should be not alive after 2 failures")
+	}
+}
+
+func TestHealthCheckerReplace(t *testing.T) {
+	h := NewHealthChecker()
+	h.Start()
+	defer h.Stop()
+	checker := func(err error) Checker {
+		return CheckerFunc(func(ctx context.Context) error { return err })
+	}
+	h.Add(Check{Name: "svc", Interval: time.Millisecond * 5, Checker: checker(errors.New("old"))})
+	time.Sleep(time.Millisecond * 20)
+	h.Add(Check{Name: "svc", Interval: time.Millisecond * 5, Checker: checker(nil)})
+	for i := 0; i < 10; i++ {
+		time.Sleep(time.Millisecond * 10)
+		s := h.Statuses()[0]
+		if len(s.Error) > 0 {
+			t.Fatal("replaced check still reporting:", s.Error)
+		}
+		if i == 9 && !s.Healthy {
+			t.Fatal("new check not started")
+		}
+	}
+}
+
+func TestHealthCheckerActive(t *testing.T) {
+	h := NewHealthChecker()
+	fail := make(chan bool, 1)
+	fail <- false
+	h.Add(Check{
+		Name:             "flaky",
+		Interval:         time.Millisecond * 10,
+		FailureThreshold: 1,
+		Liveness:         true,
+		Checker: CheckerFunc(func(ctx context.Context) error {
+			f := <-fail
+			fail <- f
+			if f {
+				return errors.New("down")
+			}
+			return nil
+		}),
+	})
+	h.Start()
+	defer h.Stop()
+	wait := func(ready bool) {
+		for i := 0; i < 100; i++ {
+			if h.Ready() == ready {
+				return
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+		t.Fatal("timeout waiting for ready =", ready)
+	}
+	wait(true)
+	<-fail
+	fail <- true
+	wait(false)
+
+	rw := httptest.NewRecorder()
+	h.LivenessHandler()(rw, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rw.Code != http.StatusServiceUnavailable {
+		t.Fatal(rw.Code)
+	}
+	var res healthResponse
+	if err := json.Unmarshal(rw.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != "unavailable" || len(res.Checks) != 1 || res.Checks[0].Error != "down" {
+		t.Fatal(res)
+	}
+
+	if err := h.CheckAll(context.Background()); err == nil || err.Error() != "flaky: down" {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,170 @@
+package netext
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnStats accounting of a Conn
+type ConnStats struct {
+	// BytesRead total bytes read
+	BytesRead uint64
+	// BytesWritten total bytes written
+	BytesWritten uint64
+	// Started time the Conn was wrapped, usually the accept or dial time
+	Started time.Time
+	// LastActivity time of last successful read or write
+	LastActivity time.Time
+	// FirstByte latency from Started to the first byte read, zero if nothing read yet
+	FirstByte time.Duration
+	// Timeouts count of reads and writes failed by deadlines
+	Timeouts uint64
+}
+
+// ConnHooks hooks of a Conn, all fields are optional, hooks are called synchronously
+type ConnHooks struct {
+	// OnRead called after every Read
+	OnRead func(c *Conn, n int, err error)
+	// OnWrite called after every Write
+	OnWrite func(c *Conn, n int, err error)
+	// OnFirstByte called once when the first byte is read
+	OnFirstByte func(c *Conn, latency time.Duration)
+	// OnTimeout called when a Read or Write fails because of a deadline
+	OnTimeout func(c *Conn, err error)
+	// OnEnd called once, on first Read or Write error, or Close, same as ConnEndHook
+	OnEnd func(c *Conn)
+}
+
+// ConnOptions options for WrapConn
+type ConnOptions struct {
+	// Hooks hooks of the Conn
+	Hooks ConnHooks
+	// ReadLimiters limiters applied to reads, nil elements are ignored
+	ReadLimiters []*RateLimiter
+	// WriteLimiters limiters applied to writes, nil elements are ignored
+	WriteLimiters []*RateLimiter
+}
+
+// Conn a net.Conn with accounting, hooks and rate limiting
+type Conn struct {
+	net.Conn
+
+	opts    ConnOptions
+	started time.Time
+
+	bytesRead    uint64
+	bytesWritten uint64
+	timeouts     uint64
+	lastActivity int64 // unix nano
+	firstByte    int64 // nanoseconds
+
+	firstByteOnce *sync.Once
+	ended         int32
+}
+
+// WrapConn wrap a net.Conn with accounting, hooks and rate limiting
+func WrapConn(c net.Conn, opts ConnOptions) *Conn {
+	now := time.Now()
+	return &Conn{
+		Conn:          c,
+		opts:          opts,
+		started:       now,
+		lastActivity:  now.UnixNano(),
+		firstByteOnce: &sync.Once{},
+	}
+}
+
+// Stats returns a snapshot of accounting
+func (c *Conn) Stats() ConnStats {
+	return ConnStats{
+		BytesRead:    atomic.LoadUint64(&c.bytesRead),
+		BytesWritten: atomic.LoadUint64(&c.bytesWritten),
+		Started:      c.started,
+		LastActivity: time.Unix(0, atomic.LoadInt64(&c.lastActivity)),
+		FirstByte:    time.Duration(atomic.LoadInt64(&c.firstByte)),
+		Timeouts:     atomic.LoadUint64(&c.timeouts),
+	}
+}
+
+// IdleTime returns duration since last successful read or write
+func (c *Conn) IdleTime() time.Duration {
+	return time.Since(time.Unix(0, atomic.LoadInt64(&c.lastActivity)))
+}
+
+func (c *Conn) end() {
+	if atomic.CompareAndSwapInt32(&c.ended, 0, 1) && c.opts.Hooks.OnEnd != nil {
+		c.opts.Hooks.OnEnd(c)
+	}
+}
+
+func (c *Conn) after(n int, err error) {
+	if n > 0 {
+		atomic.StoreInt64(&c.lastActivity, time.Now().UnixNano())
+	}
+	if err != nil {
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			atomic.AddUint64(&c.timeouts, 1)
+			if c.opts.Hooks.OnTimeout != nil {
+				c.opts.Hooks.OnTimeout(c, err)
+			}
+			// a timeout doesn't end the connection, it can be retried with a new deadline
+			return
+		}
+		c.end()
+	}
+}
+
+// Read implements net.Conn
+func (c *Conn) Read(b []byte) (n int, err error) {
+	ls := limiters(c.opts.ReadLimiters)
+	if len(ls) > 0 {
+		b = b[:ls.chunk(len(b))]
+	}
+	n, err = c.Conn.Read(b)
+	if n > 0 {
+		atomic.AddUint64(&c.bytesRead, uint64(n))
+		c.firstByteOnce.Do(func() {
+			latency := time.Since(c.started)
+			atomic.StoreInt64(&c.firstByte, int64(latency))
+			if c.opts.Hooks.OnFirstByte != nil {
+				c.opts.Hooks.OnFirstByte(c, latency)
+			}
+		})
+		ls.waitN(n)
+	}
+	if c.opts.Hooks.OnRead != nil {
+		c.opts.Hooks.OnRead(c, n, err)
+	}
+	c.after(n, err)
+	return
+}
+
+// Write implements net.Conn
+func (c *Conn) Write(b []byte) (n int, err error) {
+	ls := limiters(c.opts.WriteLimiters)
+	for len(b) > 0 {
+		chunk := ls.chunk(len(b))
+		ls.waitN(chunk)
+		var nw int
+		nw, err = c.Conn.Write(b[:chunk])
+		n += nw
+		b = b[nw:]
+		if err != nil {
+			break
+		}
+	}
+	atomic.AddUint64(&c.bytesWritten, uint64(n))
+	if c.opts.Hooks.OnWrite != nil {
+		c.opts.Hooks.OnWrite(c, n, err)
+	}
+	c.after(n, err)
+	return
+}
+
+// Close implements net.Conn
+func (c *Conn) Close() error {
+	c.end()
+	return c.Conn.Close()
+}
@@ -0,0 +1,150 @@
+package netext
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrListenerClosed error listener is closed
+	ErrListenerClosed = errors.New("listener closed")
+)
+
+// ListenerStats accounting of a Listener
+type ListenerStats struct {
+	// Active count of connections not closed yet
+	Active int
+	// Accepted total count of accepted connections
+	Accepted uint64
+	// BytesRead total bytes read by all connections
+	BytesRead uint64
+	// BytesWritten total bytes written by all connections
+	BytesWritten uint64
+}
+
+// ListenerOptions options for WrapListener
+type ListenerOptions struct {
+	// MaxConns max count of active connections, Accept blocks until an active connection ends,
+	// pending connections are queued in the backlog of underlying listener, zero means unlimited
+	MaxConns int
+	// ConnRate rate limit of each connection, applied to reads and writes separately
+	ConnRate Rate
+	// ListenerRate rate limit shared by all connections, applied to reads and writes separately
+	ListenerRate Rate
+	// Hooks hooks of every accepted connection
+	Hooks ConnHooks
+	// OnAccept called with every accepted connection before it's returned
+	OnAccept func(c *Conn)
+}
+
+// Listener a net.Listener with connection limit, accounting, hooks and rate limiting,
+// all accepted connections are *Conn
+type Listener struct {
+	net.Listener
+
+	opts      ListenerOptions
+	readRate  *RateLimiter
+	writeRate *RateLimiter
+
+	cond   *sync.Cond
+	active int
+	closed bool
+
+	accepted     uint64
+	bytesRead    uint64
+	bytesWritten uint64
+}
+
+// WrapListener wrap a net.Listener
+func WrapListener(l net.Listener, opts ListenerOptions) *Listener {
+	return &Listener{
+		Listener:  l,
+		opts:      opts,
+		readRate:  NewRateLimiter(opts.ListenerRate),
+		writeRate: NewRateLimiter(opts.ListenerRate),
+		cond:      sync.NewCond(&sync.Mutex{}),
+	}
+}
+
+// Stats returns a snapshot of accounting
+func (l *Listener) Stats() ListenerStats {
+	l.cond.L.Lock()
+	active := l.active
+	l.cond.L.Unlock()
+	return ListenerStats{
+		Active:       active,
+		Accepted:     atomic.LoadUint64(&l.accepted),
+		BytesRead:    atomic.LoadUint64(&l.bytesRead),
+		BytesWritten: atomic.LoadUint64(&l.bytesWritten),
+	}
+}
+
+// Accept implements net.Listener, blocks while MaxConns connections are active
+func (l *Listener) Accept() (net.Conn, error) {
+	l.cond.L.Lock()
+	for l.opts.MaxConns > 0 && l.active >= l.opts.MaxConns && !l.closed {
+		l.cond.Wait()
+	}
+	if l.closed {
+		l.cond.L.Unlock()
+		return nil, ErrListenerClosed
+	}
+	// reserve the slot before accepting
+	l.active++
+	l.cond.L.Unlock()
+
+	nc, err := l.Listener.Accept()
+	if err != nil {
+		l.release()
+		return nil, err
+	}
+	atomic.AddUint64(&l.accepted, 1)
+
+	hooks := l.opts.Hooks
+	onRead, onWrite, onEnd := hooks.OnRead, hooks.OnWrite, hooks.OnEnd
+	hooks.OnRead = func(c *Conn, n int, err error) {
+		atomic.AddUint64(&l.bytesRead, uint64(n))
+		if onRead != nil {
+			onRead(c, n, err)
+		}
+	}
+	hooks.OnWrite = func(c *Conn, n int, err error) {
+		atomic.AddUint64(&l.bytesWritten, uint64(n))
+		if onWrite != nil {
+			onWrite(c, n, err)
+		}
+	}
+	hooks.OnEnd = func(c *Conn) {
+		l.release()
+		if onEnd != nil {
+			onEnd(c)
+		}
+	}
+	c := WrapConn(nc, ConnOptions{
+		Hooks:         hooks,
+		ReadLimiters:  []*RateLimiter{NewRateLimiter(l.opts.ConnRate), l.readRate},
+		WriteLimiters: []*RateLimiter{NewRateLimiter(l.opts.ConnRate), l.writeRate},
+	})
+	if l.opts.OnAccept != nil {
+		l.opts.OnAccept(c)
+	}
+	return c, nil
+}
+
+func (l *Listener) release() {
+	l.cond.L.Lock()
+	l.active--
+	l.cond.L.Unlock()
+	l.cond.Signal()
+}
+
+// Close implements net.Listener, wakes up blocked Accept
+func (l *Listener) Close() error {
+	l.cond.L.Lock()
+	l.closed = true
+	l.cond.L.Unlock()
+	l.cond.Broadcast()
+	return l.Listener.Close()
+}
@@ -0,0 +1,100 @@
+package netext
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWrapConn(t *testing.T) {
+	c1, c2 := net.Pipe()
+	var ended, firstByte, timeouts int32
+	c := WrapConn(c1, ConnOptions{Hooks: ConnHooks{
+		OnFirstByte: func(c *Conn, latency time.Duration) { atomic.AddInt32(&firstByte, 1) },
+		OnTimeout:   func(c *Conn, err error) { atomic.AddInt32(&timeouts, 1) },
+		OnEnd:       func(c *Conn) { atomic.AddInt32(&ended, 1) },
+	}})
+	go func() {
+		c2.Write([]byte("hello"))
+		ioutil.ReadAll(c2)
+	}()
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(c, buf); err != nil {
+		t.Fatal(err)
+	}
+	c.Write([]byte("world!"))
+	c.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	if _, err := c.Read(buf); err == nil {
+		t.Fatal("read should time out")
+	}
+	c.Close()
+	c.Close()
+	s := c.Stats()
+	if s.BytesRead != 5 || s.BytesWritten != 6 || s.Timeouts != 1 || s.FirstByte <= 0 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	if firstByte != 1 || timeouts != 1 || ended != 1 {
+		t.Errorf("unexpected hook calls %d %d %d", firstByte, timeouts, ended)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	var rl *RateLimiter
+	rl.WaitN(100) // nil limiter should not block
+	rl = NewRateLimiter(Rate{BytesPerSecond: 1000, Burst: 100})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		rl.WaitN(100)
+	}
+	// burst is free, the other 200 bytes take 200ms
+	if d := time.Since(start); d < 150*time.Millisecond || d > time.Second {
+		t.Errorf("unexpected duration %v", d)
+	}
+}
+
+func TestWrapListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := WrapListener(ln, ListenerOptions{MaxConns: 1})
+	defer l.Close()
+	go func() {
+		for i := 0; i < 2; i++ {
+			c, err := net.Dial("tcp", ln.Addr().String())
+			if err != nil {
+				return
+			}
+			c.Write([]byte("hello"))
+			defer c.Close()
+		}
+	}()
+	c, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, _ := l.Accept()
+		accepted <- c
+	}()
+	select {
+	case <-accepted:
+		t.Fatal("second connection should wait for the first one")
+	case <-time.After(50 * time.Millisecond):
+	}
+	io.ReadFull(c, make([]byte, 5))
+	c.Close()
+	select {
+	case c2 := <-accepted:
+		c2.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("second connection should be accepted")
+	}
+	if s := l.Stats(); s.Accepted != 2 || s.BytesRead != 5 || s.Active != 0 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+}
@@ -0,0 +1,95 @@
+package netext
+
+import (
+	"sync"
+	"time"
+)
+
+// Rate rate limit in bytes per second, zero value means unlimited
+type Rate struct {
+	// BytesPerSecond sustained rate
+	BytesPerSecond int
+	// Burst max bytes can be transferred at once, defaults to BytesPerSecond
+	Burst int
+}
+
+// RateLimiter a token bucket limiter, safe for concurrent use
+type RateLimiter struct {
+	rate   float64
+	burst  int
+	mtx    *sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter create a new token bucket limiter, returns nil if r is unlimited,
+// a nil *RateLimiter never blocks
+func NewRateLimiter(r Rate) *RateLimiter {
+	if r.BytesPerSecond <= 0 {
+		return nil
+	}
+	if r.Burst <= 0 {
+		r.Burst = r.BytesPerSecond
+	}
+	return &RateLimiter{
+		rate:   float64(r.BytesPerSecond),
+		burst:  r.Burst,
+		mtx:    &sync.Mutex{},
+		tokens: float64(r.Burst),
+		last:   time.Now(),
+	}
+}
+
+// Burst returns max bytes can be transferred at once
+func (rl *RateLimiter) Burst() int {
+	if rl == nil {
+		return 0
+	}
+	return rl.burst
+}
+
+// reserve takes n tokens and returns the duration to wait before they are available
+func (rl *RateLimiter) reserve(n int) time.Duration {
+	rl.mtx.Lock()
+	defer rl.mtx.Unlock()
+	now := time.Now()
+	rl.tokens += now.Sub(rl.last).Seconds() * rl.rate
+	if rl.tokens > float64(rl.burst) {
+		rl.tokens = float64(rl.burst)
+	}
+	rl.last = now
+	rl.tokens -= float64(n)
+	if rl.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-rl.tokens / rl.rate * float64(time.Second))
+}
+
+// WaitN blocks until n bytes are allowed, n larger than burst is allowed but waits longer
+func (rl *RateLimiter) WaitN(n int) {
+	if rl == nil || n <= 0 {
+		return
+	}
+	if d := rl.reserve(n); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// limiters a group of limiters applied together
+type limiters []*RateLimiter
+
+// chunk returns the max bytes can be transferred at once by all limiters, or n if unlimited
+func (ls limiters) chunk(n int) int {
+	for _, l := range ls {
+		if b := l.Burst(); b > 0 && b < n {
+			n = b
+		}
+	}
+	return n
+}
+
+func (ls limiters) waitN(n int) {
+	for _, l := range ls {
+		l.WaitN(n)
+	}
+}
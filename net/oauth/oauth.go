@@ -16,7 +16,7 @@
 // Caveats:
 //      - Currently only supports HMAC and RSA signatures.
 //      - Currently only supports SHA1 and SHA256 hashes.
-//      - Currently only supports OAuth 1.0
+//      - OAuth 1.0 only, see OAuth2Consumer in oauth2.go for OAuth 2.0
 //
 // Overview of how to use this library:
 //      (1) First create a new Consumer instance with the NewConsumer function
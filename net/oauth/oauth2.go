@@ -0,0 +1,534 @@
+package oauth
+
+//
+// OAuth 2.0 client, see RFC 6749, RFC 7636 (PKCE) and RFC 8628 (device authorization grant)
+//
+// Overview of how to use the authorization code grant:
+//      (1) Create a new OAuth2Consumer with NewOAuth2Consumer
+//      (2) Create a PKCE verifier with NewPKCEVerifier, save it along with a random state
+//      (3) Redirect the user to AuthCodeUrl(state, verifier)
+//      (4) On the callback, check the state, and call Exchange() with the "code" and the verifier
+//      (5) Call MakeHttpClient with the returned OAuth2Token, the token is refreshed automatically
+//
+
+import (
+	"context"
+	cryptoRand "crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	GRANT_AUTHORIZATION_CODE = "authorization_code"
+	GRANT_CLIENT_CREDENTIALS = "client_credentials"
+	GRANT_REFRESH_TOKEN      = "refresh_token"
+	GRANT_DEVICE_CODE        = "urn:ietf:params:oauth:grant-type:device_code"
+
+	PKCE_METHOD_S256 = "S256"
+
+	// OAuth2ExpiryDelta a token is considered expired this long before its actual expiry
+	OAuth2ExpiryDelta = 10 * time.Second
+	// DefaultDeviceInterval default polling interval of the device code grant
+	DefaultDeviceInterval = 5 * time.Second
+)
+
+var (
+	// ErrNoRefreshToken the token is expired and can not be refreshed
+	ErrNoRefreshToken = errors.New("oauth2: token expired and refresh token is not set")
+	// ErrMissingAccessToken the token response contains no access_token
+	ErrMissingAccessToken = errors.New("oauth2: server response missing access_token")
+)
+
+// OAuth2AuthStyle how client credentials are sent to the token endpoint
+type OAuth2AuthStyle int
+
+const (
+	// OAUTH2_AUTH_HEADER HTTP Basic authorization header, recommended by RFC 6749
+	OAUTH2_AUTH_HEADER OAuth2AuthStyle = iota
+	// OAUTH2_AUTH_PARAMS client_id and client_secret in the request body
+	OAUTH2_AUTH_PARAMS
+)
+
+// OAuth2ServiceProvider endpoints of an OAuth 2.0 authorization server
+type OAuth2ServiceProvider struct {
+	AuthorizeUrl           string
+	TokenUrl               string
+	DeviceAuthorizationUrl string
+	AuthStyle              OAuth2AuthStyle
+}
+
+// OAuth2Token a token returned by the token endpoint
+type OAuth2Token struct {
+	AccessToken  string
+	TokenType    string
+	RefreshToken string
+	// Expiry zero means the token never expires
+	Expiry time.Time
+	Scope  string
+	// AdditionalData other fields of the token response, like "id_token"
+	AdditionalData map[string]string
+}
+
+// Valid returns true if the token is set and not expired
+func (t *OAuth2Token) Valid() bool {
+	return t != nil && len(t.AccessToken) > 0 && !t.expired(time.Now())
+}
+
+func (t *OAuth2Token) expired(now time.Time) bool {
+	return !t.Expiry.IsZero() && now.Add(OAuth2ExpiryDelta).After(t.Expiry)
+}
+
+// Type returns the token type used in the Authorization header, defaults to "Bearer"
+func (t *OAuth2Token) Type() string {
+	switch strings.ToLower(t.TokenType) {
+	case "", "bearer":
+		return "Bearer"
+	case "mac":
+		return "MAC"
+	case "basic":
+		return "Basic"
+	}
+	return t.TokenType
+}
+
+// OAuth2Error an error response of the authorization server, see RFC 6749 section 5.2
+type OAuth2Error struct {
+	Code        string
+	Description string
+	Uri         string
+	StatusCode  int
+}
+
+func (e *OAuth2Error) Error() string {
+	s := "oauth2: " + e.Code
+	if len(e.Description) > 0 {
+		s += ": " + e.Description
+	}
+	return s
+}
+
+// OAuth2Consumer an OAuth 2.0 client, safe for concurrent use
+type OAuth2Consumer struct {
+	// Scopes default scopes requested
+	Scopes []string
+	// RedirectUrl redirect_uri of the authorization code grant
+	RedirectUrl string
+
+	// Defaults to http.Client{}, can be overridden (e.g. for testing) as necessary
+	HttpClient HttpClient
+
+	clientId        string
+	clientSecret    string
+	serviceProvider OAuth2ServiceProvider
+	clock           clock
+}
+
+// NewOAuth2Consumer creates a new OAuth2Consumer
+//      - clientId and clientSecret:
+//        values you should obtain from the service provider when you register your
+//        application, clientSecret is empty for public clients
+//
+//      - serviceProvider:
+//        endpoints of the authorization server
+func NewOAuth2Consumer(clientId string, clientSecret string, serviceProvider OAuth2ServiceProvider) *OAuth2Consumer {
+	return &OAuth2Consumer{
+		HttpClient:      &http.Client{},
+		clientId:        clientId,
+		clientSecret:    clientSecret,
+		serviceProvider: serviceProvider,
+		clock:           &defaultClock{},
+	}
+}
+
+func (c *OAuth2Consumer) now() time.Time {
+	return time.Unix(0, c.clock.Nanos())
+}
+
+// NewPKCEVerifier creates a random PKCE code verifier
+func NewPKCEVerifier() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := cryptoRand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// PKCEChallenge returns the S256 code challenge of a verifier
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeUrl returns the url to redirect the user to, PKCE is used if verifier is not empty
+func (c *OAuth2Consumer) AuthCodeUrl(state string, verifier string, additionalParams map[string]string) string {
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", c.clientId)
+	if len(c.RedirectUrl) > 0 {
+		v.Set("redirect_uri", c.RedirectUrl)
+	}
+	if len(c.Scopes) > 0 {
+		v.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	if len(state) > 0 {
+		v.Set("state", state)
+	}
+	if len(verifier) > 0 {
+		v.Set("code_challenge", PKCEChallenge(verifier))
+		v.Set("code_challenge_method", PKCE_METHOD_S256)
+	}
+	for k, val := range additionalParams {
+		v.Set(k, val)
+	}
+	u := c.serviceProvider.AuthorizeUrl
+	if strings.Contains(u, "?") {
+		return u + "&" + v.Encode()
+	}
+	return u + "?" + v.Encode()
+}
+
+// Exchange exchanges an authorization code for a token, verifier is the PKCE verifier passed to AuthCodeUrl
+func (c *OAuth2Consumer) Exchange(ctx context.Context, code string, verifier string) (*OAuth2Token, error) {
+	v := url.Values{}
+	v.Set("grant_type", GRANT_AUTHORIZATION_CODE)
+	v.Set("code", code)
+	if len(c.RedirectUrl) > 0 {
+		v.Set("redirect_uri", c.RedirectUrl)
+	}
+	if len(verifier) > 0 {
+		v.Set("code_verifier", verifier)
+	}
+	return c.retrieveToken(ctx, v)
+}
+
+// ClientCredentials requests a token with the client credentials grant, Scopes is used if no scope is given
+func (c *OAuth2Consumer) ClientCredentials(ctx context.Context, scopes ...string) (*OAuth2Token, error) {
+	if len(scopes) == 0 {
+		scopes = c.Scopes
+	}
+	v := url.Values{}
+	v.Set("grant_type", GRANT_CLIENT_CREDENTIALS)
+	if len(scopes) > 0 {
+		v.Set("scope", strings.Join(scopes, " "))
+	}
+	return c.retrieveToken(ctx, v)
+}
+
+// RefreshToken requests a new token with the refresh token grant,
+// the refresh token is kept if the server does not issue a new one
+func (c *OAuth2Consumer) RefreshToken(ctx context.Context, refreshToken string) (*OAuth2Token, error) {
+	if len(refreshToken) == 0 {
+		return nil, ErrNoRefreshToken
+	}
+	v := url.Values{}
+	v.Set("grant_type", GRANT_REFRESH_TOKEN)
+	v.Set("refresh_token", refreshToken)
+	t, err := c.retrieveToken(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.RefreshToken) == 0 {
+		t.RefreshToken = refreshToken
+	}
+	return t, nil
+}
+
+// DeviceAuthorization a device authorization response, see RFC 8628 section 3.2
+type DeviceAuthorization struct {
+	DeviceCode              string
+	UserCode                string
+	VerificationUri         string
+	VerificationUriComplete string
+	Expiry                  time.Time
+	// Interval polling interval, defaults to DefaultDeviceInterval
+	Interval time.Duration
+}
+
+// DeviceAuth starts the device authorization grant, show UserCode and VerificationUri to the user,
+// then call PollDeviceToken
+func (c *OAuth2Consumer) DeviceAuth(ctx context.Context, scopes ...string) (*DeviceAuthorization, error) {
+	if len(scopes) == 0 {
+		scopes = c.Scopes
+	}
+	v := url.Values{}
+	v.Set("client_id", c.clientId)
+	if len(scopes) > 0 {
+		v.Set("scope", strings.Join(scopes, " "))
+	}
+	data, err := c.post(ctx, c.serviceProvider.DeviceAuthorizationUrl, v, false)
+	if err != nil {
+		return nil, err
+	}
+	da := &DeviceAuthorization{
+		DeviceCode:              data["device_code"],
+		UserCode:                data["user_code"],
+		VerificationUri:         data["verification_uri"],
+		VerificationUriComplete: data["verification_uri_complete"],
+		Interval:                DefaultDeviceInterval,
+	}
+	if len(da.VerificationUri) == 0 {
+		// pre-standard servers, like Google
+		da.VerificationUri = data["verification_url"]
+	}
+	if len(da.DeviceCode) == 0 {
+		return nil, errors.New("oauth2: server response missing device_code")
+	}
+	if n, _ := strconv.ParseInt(data["expires_in"], 10, 64); n > 0 {
+		da.Expiry = c.now().Add(time.Duration(n) * time.Second)
+	}
+	if n, _ := strconv.ParseInt(data["interval"], 10, 64); n > 0 {
+		da.Interval = time.Duration(n) * time.Second
+	}
+	return da, nil
+}
+
+// PollDeviceToken polls the token endpoint until the user authorizes the device, the authorization
+// expires, or ctx is done, "slow_down" responses increase the interval by 5 seconds
+func (c *OAuth2Consumer) PollDeviceToken(ctx context.Context, da *DeviceAuthorization) (*OAuth2Token, error) {
+	interval := da.Interval
+	if interval <= 0 {
+		interval = DefaultDeviceInterval
+	}
+	v := url.Values{}
+	v.Set("grant_type", GRANT_DEVICE_CODE)
+	v.Set("device_code", da.DeviceCode)
+	for {
+		if !da.Expiry.IsZero() && c.now().After(da.Expiry) {
+			return nil, &OAuth2Error{Code: "expired_token", Description: "device code expired"}
+		}
+		t := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+		tok, err := c.retrieveToken(ctx, v)
+		if err == nil {
+			return tok, nil
+		}
+		oe, ok := err.(*OAuth2Error)
+		if !ok {
+			return nil, err
+		}
+		switch oe.Code {
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		default:
+			return nil, err
+		}
+	}
+}
+
+func (c *OAuth2Consumer) retrieveToken(ctx context.Context, v url.Values) (*OAuth2Token, error) {
+	data, err := c.post(ctx, c.serviceProvider.TokenUrl, v, true)
+	if err != nil {
+		return nil, err
+	}
+	t := &OAuth2Token{AdditionalData: map[string]string{}}
+	for k, val := range data {
+		switch k {
+		case "access_token":
+			t.AccessToken = val
+		case "token_type":
+			t.TokenType = val
+		case "refresh_token":
+			t.RefreshToken = val
+		case "scope":
+			t.Scope = val
+		case "expires_in":
+			if n, _ := strconv.ParseInt(val, 10, 64); n > 0 {
+				t.Expiry = c.now().Add(time.Duration(n) * time.Second)
+			}
+		default:
+			t.AdditionalData[k] = val
+		}
+	}
+	if len(t.AccessToken) == 0 {
+		return nil, ErrMissingAccessToken
+	}
+	return t, nil
+}
+
+// post posts a form and parses the JSON or form encoded response into a flat map
+func (c *OAuth2Consumer) post(ctx context.Context, urlStr string, v url.Values, authenticate bool) (map[string]string, error) {
+	if authenticate {
+		if c.serviceProvider.AuthStyle == OAUTH2_AUTH_PARAMS || len(c.clientSecret) == 0 {
+			v.Set("client_id", c.clientId)
+			if len(c.clientSecret) > 0 {
+				v.Set("client_secret", c.clientSecret)
+			}
+		}
+	}
+	req, err := http.NewRequest("POST", urlStr, strings.NewReader(v.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if authenticate && c.serviceProvider.AuthStyle == OAUTH2_AUTH_HEADER && len(c.clientSecret) > 0 {
+		req.SetBasicAuth(url.QueryEscape(c.clientId), url.QueryEscape(c.clientSecret))
+	}
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	data := parseOAuth2Response(resp.Header.Get("Content-Type"), body)
+	// some servers, like GitHub, report errors with status 200
+	if code := data["error"]; len(code) > 0 {
+		return nil, &OAuth2Error{
+			Code:        code,
+			Description: data["error_description"],
+			Uri:         data["error_uri"],
+			StatusCode:  resp.StatusCode,
+		}
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, HTTPExecuteError{
+			ResponseBodyBytes: body,
+			Status:            resp.Status,
+			StatusCode:        resp.StatusCode,
+		}
+	}
+	return data, nil
+}
+
+func parseOAuth2Response(contentType string, body []byte) map[string]string {
+	data := map[string]string{}
+	mt, _, _ := mime.ParseMediaType(contentType)
+	if mt == "application/x-www-form-urlencoded" || mt == "text/plain" {
+		vals, err := url.ParseQuery(string(body))
+		if err == nil {
+			for k := range vals {
+				data[k] = vals.Get(k)
+			}
+			return data
+		}
+	}
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return data
+	}
+	for k, val := range raw {
+		switch val := val.(type) {
+		case string:
+			data[k] = val
+		case float64:
+			data[k] = strconv.FormatFloat(val, 'f', -1, 64)
+		case bool:
+			data[k] = strconv.FormatBool(val)
+		case nil:
+		default:
+			buf, _ := json.Marshal(val)
+			data[k] = string(buf)
+		}
+	}
+	return data
+}
+
+// TokenSource returns a valid token, refreshing it if needed
+type TokenSource interface {
+	Token(ctx context.Context) (*OAuth2Token, error)
+}
+
+// RefreshingTokenSource a TokenSource refreshes the token with the refresh token grant,
+// or the client credentials grant if the token was obtained with it
+type RefreshingTokenSource struct {
+	// OnRefresh called with the new token after refreshing, to persist it
+	OnRefresh func(t *OAuth2Token)
+
+	consumer          *OAuth2Consumer
+	clientCredentials bool
+	mtx               *sync.Mutex
+	token             *OAuth2Token
+}
+
+// MakeTokenSource creates a TokenSource starting with token, token can be nil for client credentials
+func (c *OAuth2Consumer) MakeTokenSource(token *OAuth2Token) *RefreshingTokenSource {
+	return &RefreshingTokenSource{consumer: c, mtx: &sync.Mutex{}, token: token}
+}
+
+// MakeClientCredentialsTokenSource creates a TokenSource requesting tokens with the client credentials grant
+func (c *OAuth2Consumer) MakeClientCredentialsTokenSource() *RefreshingTokenSource {
+	return &RefreshingTokenSource{consumer: c, mtx: &sync.Mutex{}, clientCredentials: true}
+}
+
+// Token implements TokenSource
+func (s *RefreshingTokenSource) Token(ctx context.Context) (t *OAuth2Token, err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.token != nil && len(s.token.AccessToken) > 0 && !s.token.expired(s.consumer.now()) {
+		return s.token, nil
+	}
+	if s.clientCredentials {
+		t, err = s.consumer.ClientCredentials(ctx)
+	} else if s.token != nil {
+		t, err = s.consumer.RefreshToken(ctx, s.token.RefreshToken)
+	} else {
+		err = ErrNoRefreshToken
+	}
+	if err != nil {
+		return
+	}
+	s.token = t
+	if s.OnRefresh != nil {
+		s.OnRefresh(t)
+	}
+	return
+}
+
+// OAuth2RoundTripper a http.RoundTripper adds the Authorization header from a TokenSource
+type OAuth2RoundTripper struct {
+	Source TokenSource
+	// Base defaults to http.DefaultTransport
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (rt *OAuth2RoundTripper) RoundTrip(userRequest *http.Request) (*http.Response, error) {
+	t, err := rt.Source.Token(userRequest.Context())
+	if err != nil {
+		if userRequest.Body != nil {
+			userRequest.Body.Close()
+		}
+		return nil, err
+	}
+	serverRequest := cloneReq(userRequest)
+	serverRequest.Header.Set(HTTP_AUTH_HEADER, t.Type()+" "+t.AccessToken)
+	base := rt.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(serverRequest)
+}
+
+// MakeRoundTripper creates a http.RoundTripper authorized with the token, which is refreshed when expired
+func (c *OAuth2Consumer) MakeRoundTripper(token *OAuth2Token) (*OAuth2RoundTripper, error) {
+	return &OAuth2RoundTripper{Source: c.MakeTokenSource(token)}, nil
+}
+
+// MakeHttpClient creates a http.Client authorized with the token, which is refreshed when expired
+func (c *OAuth2Consumer) MakeHttpClient(token *OAuth2Token) (*http.Client, error) {
+	rt, err := c.MakeRoundTripper(token)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: rt}, nil
+}
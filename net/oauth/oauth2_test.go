@@ -0,0 +1,252 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// oauth2Server a minimal in-process authorization server with a protected resource
+type oauth2Server struct {
+	*httptest.Server
+	t          *testing.T
+	mtx        sync.Mutex
+	challenges map[string]string
+	issued     int
+	polls      int
+	expiresIn  int
+}
+
+func newOAuth2Server(t *testing.T) *oauth2Server {
+	s := &oauth2Server{t: t, challenges: map[string]string{}, expiresIn: 3600}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/device", s.device)
+	mux.HandleFunc("/resource", s.resource)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+func (s *oauth2Server) provider() OAuth2ServiceProvider {
+	return OAuth2ServiceProvider{
+		AuthorizeUrl:           s.URL + "/authorize",
+		TokenUrl:               s.URL + "/token",
+		DeviceAuthorizationUrl: s.URL + "/device",
+	}
+}
+
+func (s *oauth2Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("client_id") != "client" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	s.mtx.Lock()
+	s.challenges["code1"] = q.Get("code_challenge")
+	s.mtx.Unlock()
+	http.Redirect(w, r, q.Get("redirect_uri")+"?code=code1&state="+q.Get("state"), http.StatusFound)
+}
+
+func (s *oauth2Server) writeError(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+func (s *oauth2Server) token(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	if id, secret, ok := r.BasicAuth(); !ok || id != "client" || secret != "secret" {
+		s.writeError(w, "invalid_client")
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	switch r.PostForm.Get("grant_type") {
+	case GRANT_AUTHORIZATION_CODE:
+		c, ok := s.challenges[r.PostForm.Get("code")]
+		if !ok || c != PKCEChallenge(r.PostForm.Get("code_verifier")) {
+			s.writeError(w, "invalid_grant")
+			return
+		}
+		delete(s.challenges, r.PostForm.Get("code"))
+	case GRANT_REFRESH_TOKEN:
+		if r.PostForm.Get("refresh_token") != "refresh" {
+			s.writeError(w, "invalid_grant")
+			return
+		}
+	case GRANT_CLIENT_CREDENTIALS:
+		// form encoded response, like GitHub
+		s.issued++
+		w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+		w.Write([]byte(url.Values{
+			"access_token": {"cc" + string(rune('0'+s.issued))},
+			"token_type":   {"bearer"},
+			"scope":        {r.PostForm.Get("scope")},
+		}.Encode()))
+		return
+	case GRANT_DEVICE_CODE:
+		if s.polls++; s.polls < 3 {
+			s.writeError(w, "authorization_pending")
+			return
+		}
+	default:
+		s.writeError(w, "unsupported_grant_type")
+		return
+	}
+	s.issued++
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token":  "at" + string(rune('0'+s.issued)),
+		"token_type":    "Bearer",
+		"refresh_token": "refresh",
+		"expires_in":    s.expiresIn,
+		"id_token":      "idt",
+	})
+}
+
+func (s *oauth2Server) device(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"device_code":      "dc",
+		"user_code":        "ABCD-EFGH",
+		"verification_uri": s.URL + "/activate",
+		"expires_in":       600,
+		"interval":         5,
+	})
+}
+
+func (s *oauth2Server) resource(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(r.Header.Get("Authorization")))
+}
+
+func get(t *testing.T, c *http.Client, u string) string {
+	resp, err := c.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	buf, _ := ioutil.ReadAll(resp.Body)
+	return string(buf)
+}
+
+func TestOAuth2AuthorizationCode(t *testing.T) {
+	s := newOAuth2Server(t)
+	defer s.Close()
+	c := NewOAuth2Consumer("client", "secret", s.provider())
+	c.RedirectUrl = s.URL + "/callback"
+	c.Scopes = []string{"read", "write"}
+	verifier, err := NewPKCEVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := c.AuthCodeUrl("xyz", verifier, nil)
+	// follow the authorization server redirect without calling back
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	loc, _ := url.Parse(resp.Header.Get("Location"))
+	if loc.Query().Get("state") != "xyz" {
+		t.Fatal("unexpected redirect", loc)
+	}
+	if _, err = c.Exchange(context.Background(), loc.Query().Get("code"), "wrong"); err == nil {
+		t.Fatal("exchange should fail with wrong verifier")
+	} else if oe, ok := err.(*OAuth2Error); !ok || oe.Code != "invalid_grant" || oe.StatusCode != http.StatusBadRequest {
+		t.Fatal("unexpected error", err)
+	}
+	s.challenges["code1"] = PKCEChallenge(verifier)
+	tok, err := c.Exchange(context.Background(), "code1", verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tok.Valid() || tok.AccessToken != "at1" || tok.RefreshToken != "refresh" || tok.AdditionalData["id_token"] != "idt" {
+		t.Fatalf("unexpected token %+v", tok)
+	}
+	hc, _ := c.MakeHttpClient(tok)
+	if v := get(t, hc, s.URL+"/resource"); v != "Bearer at1" {
+		t.Error("unexpected authorization", v)
+	}
+}
+
+func TestOAuth2Refresh(t *testing.T) {
+	s := newOAuth2Server(t)
+	defer s.Close()
+	c := NewOAuth2Consumer("client", "secret", s.provider())
+	// expires within OAuth2ExpiryDelta
+	tok := &OAuth2Token{AccessToken: "old", RefreshToken: "refresh", Expiry: time.Now().Add(time.Second)}
+	src := c.MakeTokenSource(tok)
+	var refreshed *OAuth2Token
+	src.OnRefresh = func(t *OAuth2Token) { refreshed = t }
+	hc := &http.Client{Transport: &OAuth2RoundTripper{Source: src}}
+	if v := get(t, hc, s.URL+"/resource"); v != "Bearer at1" {
+		t.Error("unexpected authorization", v)
+	}
+	if refreshed == nil || refreshed.AccessToken != "at1" {
+		t.Error("OnRefresh should be called")
+	}
+	// still valid, no more refreshing
+	if v := get(t, hc, s.URL+"/resource"); v != "Bearer at1" {
+		t.Error("unexpected authorization", v)
+	}
+	src = c.MakeTokenSource(&OAuth2Token{AccessToken: "old", Expiry: time.Now().Add(-time.Hour)})
+	if _, err := src.Token(context.Background()); err != ErrNoRefreshToken {
+		t.Error("expected ErrNoRefreshToken, got", err)
+	}
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	s := newOAuth2Server(t)
+	defer s.Close()
+	c := NewOAuth2Consumer("client", "secret", s.provider())
+	tok, err := c.ClientCredentials(context.Background(), "a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "cc1" || tok.Scope != "a b" || tok.Type() != "Bearer" || !tok.Expiry.IsZero() {
+		t.Fatalf("unexpected token %+v", tok)
+	}
+	hc := &http.Client{Transport: &OAuth2RoundTripper{Source: c.MakeClientCredentialsTokenSource()}}
+	if v := get(t, hc, s.URL+"/resource"); v != "Bearer cc2" {
+		t.Error("unexpected authorization", v)
+	}
+	bad := NewOAuth2Consumer("client", "wrong", s.provider())
+	if _, err = bad.ClientCredentials(context.Background()); err == nil || err.(*OAuth2Error).Code != "invalid_client" {
+		t.Error("expected invalid_client, got", err)
+	}
+}
+
+func TestOAuth2DeviceCode(t *testing.T) {
+	s := newOAuth2Server(t)
+	defer s.Close()
+	c := NewOAuth2Consumer("client", "secret", s.provider())
+	da, err := c.DeviceAuth(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if da.UserCode != "ABCD-EFGH" || da.Interval != 5*time.Second || da.Expiry.IsZero() {
+		t.Fatalf("unexpected device authorization %+v", da)
+	}
+	da.Interval = time.Millisecond
+	tok, err := c.PollDeviceToken(context.Background(), da)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "at1" || s.polls != 3 {
+		t.Errorf("unexpected token %+v after %d polls", tok, s.polls)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = c.PollDeviceToken(ctx, da); err != context.Canceled {
+		t.Error("expected canceled, got", err)
+	}
+}
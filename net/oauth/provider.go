@@ -25,10 +25,16 @@ func (m oauthBufferReader) Close() error { return nil }
 
 type ConsumerGetter func(key string, header map[string]string) (*Consumer, error)
 
+// NonceChecker returns an error if the nonce has been used, called after the signature is verified
+type NonceChecker func(consumerKey string, nonce string, timestamp string) error
+
 // Provider provides methods for a 2-legged Oauth1 provider
 type Provider struct {
 	ConsumerGetter ConsumerGetter
 
+	// NonceChecker rejects replayed requests, optional
+	NonceChecker NonceChecker
+
 	// For mocking
 	clock clock
 }
@@ -37,8 +43,8 @@ type Provider struct {
 // Returns a Provider
 func NewProvider(secretGetter ConsumerGetter) *Provider {
 	provider := &Provider{
-		secretGetter,
-		&defaultClock{},
+		ConsumerGetter: secretGetter,
+		clock:          &defaultClock{},
 	}
 	return provider
 }
@@ -159,5 +165,11 @@ func (provider *Provider) IsAuthorized(request *http.Request) (*string, error) {
 		return nil, err
 	}
 
+	if provider.NonceChecker != nil {
+		if err = provider.NonceChecker(consumerKey, userParams[NONCE_PARAM], timestamp); err != nil {
+			return nil, err
+		}
+	}
+
 	return &consumerKey, nil
 }
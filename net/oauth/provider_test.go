@@ -1,6 +1,7 @@
 package oauth
 
 import (
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -134,3 +135,36 @@ func TestConsumerKeyWithEqualsInIt(t *testing.T) {
 	assertEq(t, nil, err)
 	assertEq(t, "consumerkeywithequals=", *authorized)
 }
+
+func TestProviderNonceChecker(t *testing.T) {
+	p := NewProvider(func(s string, h map[string]string) (*Consumer, error) {
+		c := NewConsumer(s, "consumersecret", ServiceProvider{})
+		c.signer = &MockSigner{}
+		return c, nil
+	})
+	p.clock = &MockClock{Time: 1446226936}
+	seen := map[string]bool{}
+	p.NonceChecker = func(key, nonce, timestamp string) error {
+		if seen[key+nonce+timestamp] {
+			return fmt.Errorf("nonce already used")
+		}
+		seen[key+nonce+timestamp] = true
+		return nil
+	}
+
+	for i := 0; i < 2; i++ {
+		fakeRequest, err := http.NewRequest("GET", "https://example.com/some/path", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fakeRequest.Header.Set(HTTP_AUTH_HEADER, "OAuth oauth_nonce=\"799507437267152061446226936\", oauth_timestamp=\"1446226936\", oauth_version=\"1.0\", oauth_signature_method=\"HMAC-SHA1\", oauth_consumer_key=\"consumerkey\", oauth_signature=\"MOCK_SIGNATURE\"")
+
+		authorized, err := p.IsAuthorized(fakeRequest)
+		if i == 0 {
+			assertEq(t, nil, err)
+			assertEq(t, "consumerkey", *authorized)
+		} else if err == nil {
+			t.Fatal("replayed request should be rejected")
+		}
+	}
+}
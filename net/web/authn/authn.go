@@ -0,0 +1,218 @@
+// Package authn is a middleware that authenticates requests signed with OAuth 1.0a or carrying OAuth 2.0 bearer tokens
+package authn
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"landzero.net/x/net/oauth"
+	"landzero.net/x/net/web"
+)
+
+const (
+	// SchemeOAuth1 principal authenticated by OAuth 1.0a signature
+	SchemeOAuth1 = "oauth1"
+	// SchemeBearer principal authenticated by OAuth 2.0 bearer token
+	SchemeBearer = "bearer"
+
+	// DefaultNonceTTL default time a OAuth 1.0a nonce is remembered, covers the 5 minutes clock skew allowed by oauth.Provider
+	DefaultNonceTTL = 10 * time.Minute
+)
+
+var (
+	// ErrNoCredentials request carries no credentials
+	ErrNoCredentials = errors.New("authn: no credentials")
+	// ErrInvalidToken bearer token is unknown or expired
+	ErrInvalidToken = errors.New("authn: invalid token")
+	// ErrNonceReused OAuth 1.0a nonce is already used
+	ErrNonceReused = errors.New("authn: nonce already used")
+)
+
+// Principal the authenticated principal of a request, mapped into *web.Context
+type Principal struct {
+	// Scheme SchemeOAuth1 or SchemeBearer
+	Scheme string `json:"scheme"`
+	// ClientID consumer key of OAuth 1.0a, or client id the token is issued to
+	ClientID string `json:"client_id"`
+	// Subject user the token is issued for, empty for 2-legged OAuth 1.0a and client credentials
+	Subject string `json:"subject,omitempty"`
+	// Scopes granted scopes
+	Scopes []string `json:"scopes,omitempty"`
+	// Expiry expiry of the token, zero means never
+	Expiry time.Time `json:"expiry,omitempty"`
+	// Extra extra attributes
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+// HasScope returns true if all scopes are granted
+func (p *Principal) HasScope(scopes ...string) bool {
+	for _, s := range scopes {
+		found := false
+		for _, g := range p.Scopes {
+			if g == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Options authenticator options
+type Options struct {
+	// Provider verifies OAuth 1.0a signed requests, nil disables OAuth 1.0a
+	Provider *oauth.Provider
+	// Tokens looks up OAuth 2.0 bearer tokens, nil disables bearer tokens
+	Tokens TokenStore
+	// Nonces rejects replayed OAuth 1.0a requests, nil disables replay checking
+	Nonces NonceStore
+	// NonceTTL time a nonce is remembered, default DefaultNonceTTL
+	NonceTTL time.Duration
+	// Optional pass requests without credentials through, no Principal is mapped,
+	// requests with invalid credentials are still rejected
+	Optional bool
+	// Realm realm of WWW-Authenticate header
+	Realm string
+	// ErrorFunc replies to unauthenticated requests, default replies 401 with WWW-Authenticate header
+	ErrorFunc func(ctx *web.Context, err error)
+}
+
+func prepareOptions(options []Options) Options {
+	var opt Options
+	if len(options) > 0 {
+		opt = options[0]
+	}
+	if opt.NonceTTL <= 0 {
+		opt.NonceTTL = DefaultNonceTTL
+	}
+	if opt.ErrorFunc == nil {
+		opt.ErrorFunc = func(ctx *web.Context, err error) {
+			unauthorized(ctx, opt, err)
+		}
+	}
+	if opt.Provider != nil && opt.Nonces != nil {
+		// copy, do not modify the caller's provider
+		p := *opt.Provider
+		p.NonceChecker = func(key, nonce, timestamp string) error {
+			seen, err := opt.Nonces.Seen(key+":"+timestamp+":"+nonce, opt.NonceTTL)
+			if err != nil {
+				return err
+			}
+			if seen {
+				return ErrNonceReused
+			}
+			return nil
+		}
+		opt.Provider = &p
+	}
+	return opt
+}
+
+func unauthorized(ctx *web.Context, opt Options, err error) {
+	var challenges []string
+	if opt.Tokens != nil {
+		c := `Bearer realm="` + opt.Realm + `"`
+		if err == ErrInvalidToken {
+			c += `, error="invalid_token"`
+		}
+		challenges = append(challenges, c)
+	}
+	if opt.Provider != nil {
+		challenges = append(challenges, `OAuth realm="`+opt.Realm+`"`)
+	}
+	for _, c := range challenges {
+		ctx.Resp.Header().Add("WWW-Authenticate", c)
+	}
+	http.Error(ctx.Resp, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// bearerToken extracts bearer token from Authorization header or "access_token" form value, see RFC 6750
+func bearerToken(req *http.Request) string {
+	h := req.Header.Get("Authorization")
+	if len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	if len(h) == 0 {
+		return req.FormValue("access_token")
+	}
+	return ""
+}
+
+func isOAuth1(req *http.Request) bool {
+	h := req.Header.Get("Authorization")
+	if len(h) > 6 && strings.EqualFold(h[:6], oauth.OAUTH_HEADER) {
+		return true
+	}
+	return len(h) == 0 && len(req.FormValue("oauth_signature")) > 0
+}
+
+func authenticate(ctx *web.Context, opt Options) (p *Principal, err error) {
+	req := ctx.Req.Request
+	if opt.Provider != nil && isOAuth1(req) {
+		var key *string
+		if key, err = opt.Provider.IsAuthorized(req); err != nil {
+			return
+		}
+		p = &Principal{Scheme: SchemeOAuth1, ClientID: *key}
+		return
+	}
+	if opt.Tokens != nil {
+		if token := bearerToken(req); len(token) > 0 {
+			if p, err = opt.Tokens.Lookup(token); err != nil {
+				return
+			}
+			if p == nil || (!p.Expiry.IsZero() && time.Now().After(p.Expiry)) {
+				p, err = nil, ErrInvalidToken
+				return
+			}
+			// the store may return shared principals
+			cp := *p
+			cp.Scheme = SchemeBearer
+			p = &cp
+			return
+		}
+	}
+	err = ErrNoCredentials
+	return
+}
+
+// Authenticator create a middleware authenticates requests, and maps *Principal into *web.Context,
+// also sets ctx.Data["Principal"]
+func Authenticator(options ...Options) web.Handler {
+	opt := prepareOptions(options)
+	return func(ctx *web.Context) {
+		p, err := authenticate(ctx, opt)
+		if err != nil {
+			if err == ErrNoCredentials && opt.Optional {
+				return
+			}
+			ctx.Logger().Println("authn:", err)
+			opt.ErrorFunc(ctx, err)
+			return
+		}
+		ctx.Map(p)
+		ctx.Data["Principal"] = p
+	}
+}
+
+// RequireScope create a handler rejects requests without the scopes with 403, must be used after Authenticator
+func RequireScope(scopes ...string) web.Handler {
+	return func(ctx *web.Context) {
+		p, _ := ctx.Data["Principal"].(*Principal)
+		if p == nil {
+			http.Error(ctx.Resp, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		if !p.HasScope(scopes...) {
+			if p.Scheme == SchemeBearer {
+				ctx.Resp.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+strings.Join(scopes, " ")+`"`)
+			}
+			http.Error(ctx.Resp, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package authn
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"landzero.net/x/net/oauth"
+	"landzero.net/x/net/web"
+	"landzero.net/x/net/web/cache"
+)
+
+func newTestServer(opt Options) *httptest.Server {
+	m := web.New()
+	m.Use(Authenticator(opt))
+	m.Get("/whoami", func(p *Principal) string {
+		return p.Scheme + ":" + p.ClientID + ":" + p.Subject
+	})
+	m.Get("/admin", RequireScope("admin"), func() string { return "ok" })
+	return httptest.NewServer(m)
+}
+
+func do(t *testing.T, c *http.Client, req *http.Request) (int, string) {
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	buf, _ := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, string(buf)
+}
+
+func TestBearer(t *testing.T) {
+	tokens := NewCacheTokenStore(cache.NewMemoryCacher(), "token:")
+	tokens.Put("t1", &Principal{ClientID: "app", Subject: "alice", Scopes: []string{"read"}})
+	tokens.Put("t2", &Principal{ClientID: "app", Subject: "bob", Scopes: []string{"admin"}, Expiry: time.Now().Add(time.Hour)})
+	tokens.Put("t3", &Principal{ClientID: "app", Subject: "eve", Expiry: time.Now().Add(-time.Hour)})
+	s := newTestServer(Options{Tokens: tokens, Realm: "test"})
+	defer s.Close()
+
+	cases := []struct {
+		path   string
+		token  string
+		status int
+		body   string
+	}{
+		{"/whoami", "t1", 200, "bearer:app:alice"},
+		{"/whoami", "t2", 200, "bearer:app:bob"},
+		{"/whoami", "t3", 401, ""},
+		{"/whoami", "nope", 401, ""},
+		{"/whoami", "", 401, ""},
+		{"/admin", "t1", 403, ""},
+		{"/admin", "t2", 200, "ok"},
+	}
+	for i, c := range cases {
+		req, _ := http.NewRequest("GET", s.URL+c.path, nil)
+		if len(c.token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		status, body := do(t, http.DefaultClient, req)
+		if status != c.status || (len(c.body) > 0 && body != c.body) {
+			t.Errorf("case %d: unexpected %d %q", i, status, body)
+		}
+	}
+	tokens.Revoke("t1")
+	req, _ := http.NewRequest("GET", s.URL+"/whoami?access_token=t1", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 401 || resp.Header.Get("WWW-Authenticate") != `Bearer realm="test", error="invalid_token"` {
+		t.Error("revoked token should be rejected", resp.StatusCode, resp.Header)
+	}
+}
+
+type mapTokens map[string]*Principal
+
+func (m mapTokens) Lookup(token string) (*Principal, error) {
+	return m[token], nil
+}
+
+func TestBearerSharedPrincipal(t *testing.T) {
+	p := &Principal{ClientID: "app", Subject: "alice"}
+	s := newTestServer(Options{Tokens: mapTokens{"t1": p}})
+	defer s.Close()
+	req, _ := http.NewRequest("GET", s.URL+"/whoami", nil)
+	req.Header.Set("Authorization", "Bearer t1")
+	if status, body := do(t, http.DefaultClient, req); status != 200 || body != "bearer:app:alice" {
+		t.Fatal("unexpected", status, body)
+	}
+	if len(p.Scheme) > 0 {
+		t.Error("principal of store should not be modified, got scheme", p.Scheme)
+	}
+}
+
+type onceTransport struct {
+	req *http.Request
+}
+
+// RoundTrip records the signed request for replaying
+func (o *onceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	o.req = req
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestOAuth1(t *testing.T) {
+	sp := oauth.ServiceProvider{}
+	provider := oauth.NewProvider(func(key string, h map[string]string) (*oauth.Consumer, error) {
+		return oauth.NewConsumer(key, "secret", sp), nil
+	})
+	s := newTestServer(Options{
+		Provider: provider,
+		Nonces:   NewCacheNonceStore(cache.NewMemoryCacher(), "nonce:"),
+	})
+	defer s.Close()
+
+	consumer := oauth.NewConsumer("key1", "secret", sp)
+	rec := &onceTransport{}
+	consumer.HttpClient = &http.Client{Transport: rec}
+	client, _ := consumer.MakeHttpClient(&oauth.AccessToken{})
+	req, _ := http.NewRequest("GET", s.URL+"/whoami", nil)
+	if status, body := do(t, client, req); status != 200 || body != "oauth1:key1:" {
+		t.Fatal("unexpected", status, body)
+	}
+	// replay the signed request
+	replay, _ := http.NewRequest("GET", s.URL+"/whoami", nil)
+	replay.Header = rec.req.Header
+	if status, _ := do(t, http.DefaultClient, replay); status != 401 {
+		t.Error("replayed request should be rejected, got", status)
+	}
+	// wrong secret
+	bad := oauth.NewConsumer("key1", "wrong", sp)
+	client, _ = bad.MakeHttpClient(&oauth.AccessToken{})
+	req, _ = http.NewRequest("GET", s.URL+"/whoami", nil)
+	if status, _ := do(t, client, req); status != 401 {
+		t.Error("bad signature should be rejected, got", status)
+	}
+}
+
+func TestOptional(t *testing.T) {
+	m := web.New()
+	m.Use(Authenticator(Options{Tokens: NewCacheTokenStore(cache.NewMemoryCacher(), ""), Optional: true}))
+	m.Get("/", func(ctx *web.Context) string {
+		if ctx.Data["Principal"] == nil {
+			return "anonymous"
+		}
+		return "authenticated"
+	})
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	m.ServeHTTP(rec, req)
+	if rec.Body.String() != "anonymous" {
+		t.Error("unexpected", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	req.Header.Set("Authorization", "Bearer nope")
+	m.ServeHTTP(rec, req)
+	if rec.Code != 401 {
+		t.Error("invalid token should be rejected even if optional, got", rec.Code)
+	}
+}
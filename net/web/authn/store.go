@@ -0,0 +1,102 @@
+package authn
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"landzero.net/x/net/web/cache"
+)
+
+// TokenStore looks up OAuth 2.0 bearer tokens
+type TokenStore interface {
+	// Lookup returns the principal of the token, nil if not found
+	Lookup(token string) (*Principal, error)
+}
+
+// NonceStore remembers OAuth 1.0a nonces to reject replays
+type NonceStore interface {
+	// Seen records the key for ttl, returns true if it's already recorded
+	Seen(key string, ttl time.Duration) (bool, error)
+}
+
+// CacheTokenStore a TokenStore backed by net/web/cache, principals are stored as JSON
+// so any cache adapter works
+type CacheTokenStore struct {
+	c      cache.Cache
+	prefix string
+}
+
+// NewCacheTokenStore create a new CacheTokenStore, prefix is prepended to cache keys
+func NewCacheTokenStore(c cache.Cache, prefix string) *CacheTokenStore {
+	return &CacheTokenStore{c: c, prefix: prefix}
+}
+
+// Put stores the principal of a token, until p.Expiry if set
+func (s *CacheTokenStore) Put(token string, p *Principal) error {
+	buf, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	var timeout int64
+	if !p.Expiry.IsZero() {
+		// round up, cache timeout is in seconds
+		if timeout = int64(p.Expiry.Sub(time.Now())/time.Second) + 1; timeout <= 0 {
+			return nil
+		}
+	}
+	return s.c.Put(s.prefix+token, string(buf), timeout)
+}
+
+// Lookup implements TokenStore
+func (s *CacheTokenStore) Lookup(token string) (p *Principal, err error) {
+	var buf []byte
+	switch v := s.c.Get(s.prefix + token).(type) {
+	case string:
+		buf = []byte(v)
+	case []byte:
+		buf = v
+	default:
+		return
+	}
+	p = &Principal{}
+	if err = json.Unmarshal(buf, p); err != nil {
+		p = nil
+	}
+	return
+}
+
+// Revoke removes a token
+func (s *CacheTokenStore) Revoke(token string) error {
+	return s.c.Delete(s.prefix + token)
+}
+
+// CacheNonceStore a NonceStore backed by net/web/cache
+//
+// net/web/cache has no atomic set-if-absent, concurrent checks of the same nonce are serialized
+// in process, a shared cache across processes leaves a small window for replays.
+type CacheNonceStore struct {
+	mtx    *sync.Mutex
+	c      cache.Cache
+	prefix string
+}
+
+// NewCacheNonceStore create a new CacheNonceStore, prefix is prepended to cache keys
+func NewCacheNonceStore(c cache.Cache, prefix string) *CacheNonceStore {
+	return &CacheNonceStore{mtx: &sync.Mutex{}, c: c, prefix: prefix}
+}
+
+// Seen implements NonceStore
+func (s *CacheNonceStore) Seen(key string, ttl time.Duration) (bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	key = s.prefix + key
+	if s.c.IsExist(key) {
+		return true, nil
+	}
+	timeout := int64(ttl / time.Second)
+	if timeout <= 0 {
+		timeout = 1
+	}
+	return false, s.c.Put(key, "1", timeout)
+}
@@ -58,6 +58,8 @@ type Options struct {
 	Interval int
 	// Occupy entire database. Default is false.
 	OccupyMode bool
+	// Encrypter to encrypt values with, ie: *com.Keyring, see Encrypted. Default is nil, not encrypted.
+	Encrypter Encrypter
 }
 
 func prepareOptions(options []Options) Options {
@@ -81,7 +83,13 @@ func NewCacher(name string, opt Options) (Cache, error) {
 	if !ok {
 		return nil, fmt.Errorf("cache: unknown adapter '%s'(forgot to import?)", name)
 	}
-	return adapter, adapter.StartAndGC(opt)
+	if err := adapter.StartAndGC(opt); err != nil {
+		return adapter, err
+	}
+	if opt.Encrypter != nil {
+		return Encrypted(adapter, opt.Encrypter), nil
+	}
+	return adapter, nil
 }
 
 // Cacher is a middleware that maps a cache.Cache service into the Macaron handler chain.
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"encoding/base64"
+	"errors"
+)
+
+var errEncryptedCounter = errors.New("cache: Incr and Decr are not supported by encrypted cache")
+
+// Encrypter encrypts and decrypts values, it is implemented by *com.Keyring for key rotation.
+// ad is the cache key, it must be authenticated so a value can not be moved to another key.
+type Encrypter interface {
+	Encrypt(plaintext, ad []byte) ([]byte, error)
+	Decrypt(ciphertext, ad []byte) ([]byte, error)
+}
+
+type encryptedCache struct {
+	Cache
+	e Encrypter
+}
+
+// Encrypted wraps c to encrypt values with e. Values are encoded with gob and stored
+// as base64 strings, so any adapter can hold them, types other than builtin ones must be registered by gob.Register.
+// Values which can not be decrypted, ie: written with a removed key or under another key, are treated as missing.
+// Incr and Decr are not supported.
+func Encrypted(c Cache, e Encrypter) Cache {
+	return &encryptedCache{Cache: c, e: e}
+}
+
+// Put encrypts value and puts it into cache with key and expire time.
+func (c *encryptedCache) Put(key string, val interface{}, timeout int64) error {
+	data, err := EncodeGob(&Item{Val: val})
+	if err != nil {
+		return err
+	}
+	if data, err = c.e.Encrypt(data, []byte(key)); err != nil {
+		return err
+	}
+	return c.Cache.Put(key, base64.RawStdEncoding.EncodeToString(data), timeout)
+}
+
+// Get gets cached value by given key and decrypts it.
+func (c *encryptedCache) Get(key string) interface{} {
+	var data []byte
+	var err error
+	switch v := c.Cache.Get(key).(type) {
+	case string:
+		data, err = base64.RawStdEncoding.DecodeString(v)
+	case []byte:
+		data, err = base64.RawStdEncoding.DecodeString(string(v))
+	default:
+		return nil
+	}
+	if err != nil {
+		return nil
+	}
+	if data, err = c.e.Decrypt(data, []byte(key)); err != nil {
+		return nil
+	}
+	item := &Item{}
+	if err = DecodeGob(data, item); err != nil {
+		return nil
+	}
+	return item.Val
+}
+
+// Incr is not supported.
+func (c *encryptedCache) Incr(key string) error {
+	return errEncryptedCounter
+}
+
+// Decr is not supported.
+func (c *encryptedCache) Decr(key string) error {
+	return errEncryptedCounter
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"landzero.net/x/com"
+)
+
+func Test_Encrypted(t *testing.T) {
+	Convey("Test encrypted cache", t, func() {
+		kr := com.NewKeyring()
+		So(kr.AddKey("1", bytes.Repeat([]byte{1}, 32)), ShouldBeNil)
+		So(kr.SetPrimary("1"), ShouldBeNil)
+
+		raw := NewMemoryCacher()
+		So(raw.StartAndGC(Options{Interval: 2}), ShouldBeNil)
+		c := Encrypted(raw, kr)
+
+		So(c.Put("uname", "unknwon", 0), ShouldBeNil)
+		So(c.Get("uname"), ShouldEqual, "unknwon")
+		So(raw.Get("uname"), ShouldNotEqual, "unknwon")
+		So(c.IsExist("uname"), ShouldBeTrue)
+		So(c.Get("404"), ShouldBeNil)
+
+		So(c.Put("int", 1, 0), ShouldBeNil)
+		So(c.Get("int"), ShouldEqual, 1)
+		So(c.Incr("int"), ShouldNotBeNil)
+
+		Convey("Rotate keys", func() {
+			So(kr.AddKey("2", bytes.Repeat([]byte{2}, 16)), ShouldBeNil)
+			So(kr.SetPrimary("2"), ShouldBeNil)
+			So(c.Get("uname"), ShouldEqual, "unknwon")
+
+			So(kr.RemoveKey("1"), ShouldBeNil)
+			So(c.Get("uname"), ShouldBeNil)
+		})
+
+		Convey("Values moved to another key are treated as missing", func() {
+			So(raw.Put("moved", raw.Get("uname"), 0), ShouldBeNil)
+			So(c.Get("moved"), ShouldBeNil)
+		})
+
+		Convey("Plain values are treated as missing", func() {
+			So(raw.Put("plain", "unknwon", 0), ShouldBeNil)
+			So(c.Get("plain"), ShouldBeNil)
+		})
+	})
+}
@@ -95,9 +95,10 @@ type Context struct {
 	params Params
 	Render
 	Locale
-	Data   map[string]interface{}
-	crid   string
-	logger *log.Logger
+	Data      map[string]interface{}
+	crid      string
+	logger    *log.Logger
+	reqLogger *log.Logger
 }
 
 func (c *Context) handler() Handler {
@@ -140,6 +141,12 @@ func (c *Context) CridMark() string {
 	return "CRID[" + c.Crid() + "]"
 }
 
+// Logger request-scoped logger, a child of web logger with field CridFieldName attached,
+// it is also carried by Req.Context(), retrieve it with log.FromContext in packages without *Context
+func (c *Context) Logger() *log.Logger {
+	return c.reqLogger
+}
+
 func (c *Context) Next() {
 	c.index++
 	c.run()
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/text/language"
 	"landzero.net/x/log"
 	"landzero.net/x/net/web"
+	"landzero.net/x/time/ago"
 )
 
 // I18n the i18n interface
@@ -24,6 +26,18 @@ type I18n interface {
 	LocaleNames() []string
 }
 
+// AgoI18n formats relative times and durations in the active language, it's mapped into
+// *web.Context along with I18n, "I18n" of template data implements both
+type AgoI18n interface {
+	// AgoLocale get the time/ago locale of active language, from "ago." keys of locale file,
+	// or a built-in pack of time/ago, or English
+	AgoLocale() *ago.Locale
+	// TimeAgo format a time relative to now, like "2 hours ago"
+	TimeAgo(t time.Time) string
+	// Duration format a duration precisely, like "2 hours 5 minutes 3 seconds"
+	Duration(d time.Duration) string
+}
+
 // Options i18n options
 type Options struct {
 	// Directory directory contains i18n files, default "locales"
@@ -47,6 +61,7 @@ type i18n struct {
 	src *Source
 	l   string
 	ln  string
+	al  *ago.Locale
 }
 
 func (in *i18n) setup() {
@@ -58,6 +73,7 @@ func (in *i18n) setup() {
 	)
 	in.l = lang.String()
 	in.ctx.MapTo(in, (*I18n)(nil))
+	in.ctx.MapTo(in, (*AgoI18n)(nil))
 	in.ctx.Data["I18n"] = in
 	in.ctx.Data["Lang"] = in.l
 	for i, n := range in.opt.Locales {
@@ -100,6 +116,31 @@ func (in *i18n) LocaleNames() []string {
 	return in.opt.LocaleNames
 }
 
+func (in *i18n) AgoLocale() *ago.Locale {
+	if in.al != nil {
+		return in.al
+	}
+	var ok bool
+	if in.al, ok = ago.NewLocaleFromLookup(in.l, func(key string) string {
+		return in.src.Get(in.l + "." + key)
+	}); ok {
+		return in.al
+	}
+	if in.al, ok = ago.LookupLocale(in.l); ok {
+		return in.al
+	}
+	in.al = ago.LocaleEnglish
+	return in.al
+}
+
+func (in *i18n) TimeAgo(t time.Time) string {
+	return in.AgoLocale().Format(t)
+}
+
+func (in *i18n) Duration(d time.Duration) string {
+	return in.AgoLocale().FormatDuration(d, ago.Precise)
+}
+
 func extractOptions(opts ...Options) (opt Options) {
 	if len(opts) > 0 {
 		opt = opts[0]
@@ -0,0 +1,47 @@
+package i18n
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"landzero.net/x/runtime/binfs"
+)
+
+func init() {
+	binfs.Load(&binfs.Chunk{
+		Path: []string{"locales-ago", "uk-UA.yml"},
+		Data: []byte(`
+ago:
+  past: "{{0}} тому"
+  units:
+    hour:
+      one: "{{0}} годину"
+      few: "{{0}} години"
+      many: "{{0}} годин"
+`),
+	})
+}
+
+var _ AgoI18n = &i18n{}
+
+func TestI18nAgoLocale(t *testing.T) {
+	src := &Source{
+		dir:   "locales-ago",
+		binfs: true,
+		data:  map[string]string{},
+		l:     &sync.RWMutex{},
+	}
+	in := &i18n{src: src, l: "uk-UA"}
+	if s := in.TimeAgo(time.Now().Add(-3 * time.Hour)); s != "3 години тому" {
+		t.Error(s)
+	}
+	in = &i18n{src: src, l: "ru-RU"}
+	if s := in.TimeAgo(time.Now().Add(-5 * time.Hour)); s != "5 часов назад" {
+		t.Error(s)
+	}
+	in = &i18n{src: src, l: "xx-XX"}
+	if s := in.Duration(2*time.Hour + 5*time.Minute); s != "2 hours 5 minutes" {
+		t.Error(s)
+	}
+}
@@ -44,3 +44,23 @@ func Test_Logger(t *testing.T) {
 		So(len(buf.String()), ShouldBeGreaterThan, 0)
 	})
 }
+
+func Test_RequestLogger(t *testing.T) {
+	Convey("Request-scoped logger", t, func() {
+		buf := bytes.NewBufferString("")
+		m := NewWithLogger(buf)
+		m.Get("/", func(ctx *Context) {
+			ctx.Logger().Println("hello")
+			l, ok := log.FromContext(ctx.Req.Context())
+			So(ok, ShouldBeTrue)
+			So(l, ShouldEqual, ctx.Logger())
+		})
+
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "http://localhost:4000/", nil)
+		So(err, ShouldBeNil)
+		req.Header.Set(CridHeaderName, "abcdefgh")
+		m.ServeHTTP(resp, req)
+		So(buf.String(), ShouldContainSubstring, "hello crid=abcdefgh")
+	})
+}
@@ -0,0 +1,366 @@
+// Package login is a middleware provides "Sign in with X" by OAuth 2.0 and OpenID Connect providers
+//
+// For every provider, two routes are served:
+//
+//	GET {Prefix}/{Name}/login?next=/path     redirects to the provider
+//	GET {Prefix}/{Name}/callback             exchanges the code, fetches the profile, calls OnLogin
+//
+// State, PKCE verifier and OIDC nonce are stored in the session, session.Sessioner must be used before.
+package login
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"landzero.net/x/com"
+	"landzero.net/x/net/oauth"
+	"landzero.net/x/net/web"
+	"landzero.net/x/net/web/session"
+)
+
+var (
+	// ErrStateMismatch callback state does not match the session, it may be forged or the session is expired
+	ErrStateMismatch = errors.New("login: state mismatch")
+	// ErrMissingIDToken provider has Issuer but the token response has no id_token
+	ErrMissingIDToken = errors.New("login: missing id_token")
+	// ErrSubjectMismatch userinfo sub differs from id token sub
+	ErrSubjectMismatch = errors.New("login: userinfo subject mismatch")
+)
+
+// Provider a OAuth 2.0 or OpenID Connect provider
+type Provider struct {
+	// Name name in routes, like "github"
+	Name string
+	// ClientID and ClientSecret obtained from the provider
+	ClientID     string
+	ClientSecret string
+	// Endpoint authorize and token endpoints
+	Endpoint oauth.OAuth2ServiceProvider
+	// Scopes requested scopes, like "openid", "email", "profile"
+	Scopes []string
+	// AuthParams additional parameters of the authorization url
+	AuthParams map[string]string
+	// UserInfoURL fetched with the access token, optional if Issuer is set
+	UserInfoURL string
+	// Issuer OpenID Connect issuer, id_token is required and verified if set
+	Issuer string
+	// JWKSURL key set of verifying id_token, required if Issuer is set
+	JWKSURL string
+	// MapProfile fills profile from claims of id_token and userinfo, after the default mapping
+	MapProfile func(claims map[string]interface{}, p *Profile)
+
+	jwks *JWKS
+}
+
+// Profile a normalized user profile
+type Profile struct {
+	// Provider name of the provider
+	Provider string
+	// ID unique id of the user at the provider, "sub" of OIDC or "id" of most APIs
+	ID            string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Username      string
+	AvatarURL     string
+	// Token the token response
+	Token *oauth.OAuth2Token
+	// Claims merged claims of id_token and userinfo, numbers of userinfo are json.Number
+	Claims map[string]interface{}
+}
+
+// Options login options
+type Options struct {
+	// Prefix route prefix, default "/auth"
+	Prefix string
+	// Providers providers
+	Providers []Provider
+	// BaseURL external base url of redirect_uri, like "https://example.com", default derived from request
+	BaseURL string
+	// SessionKey prefix of session keys, default "login."
+	SessionKey string
+	// OnLogin called with the profile after the session id is regenerated, redirects to "next" if nothing is written
+	OnLogin func(ctx *web.Context, sess session.Store, p *Profile)
+	// OnError called on failures, default replies 401
+	OnError func(ctx *web.Context, err error)
+}
+
+func prepareOptions(options []Options) Options {
+	var opt Options
+	if len(options) > 0 {
+		opt = options[0]
+	}
+	if len(opt.Prefix) == 0 {
+		opt.Prefix = "/auth"
+	}
+	opt.Prefix = strings.TrimRight(opt.Prefix, "/")
+	opt.BaseURL = strings.TrimRight(opt.BaseURL, "/")
+	if len(opt.SessionKey) == 0 {
+		opt.SessionKey = "login."
+	}
+	if opt.OnError == nil {
+		opt.OnError = func(ctx *web.Context, err error) {
+			http.Error(ctx.Resp, "login failed", http.StatusUnauthorized)
+		}
+	}
+	providers := make([]Provider, len(opt.Providers))
+	for i, p := range opt.Providers {
+		if len(p.Issuer) > 0 {
+			if len(p.JWKSURL) == 0 {
+				panic("login: provider " + p.Name + " has Issuer but no JWKSURL")
+			}
+			p.jwks = NewJWKS(p.JWKSURL)
+		}
+		providers[i] = p
+	}
+	opt.Providers = providers
+	return opt
+}
+
+// Loginer create a login middleware
+func Loginer(options ...Options) web.Handler {
+	opt := prepareOptions(options)
+	return func(ctx *web.Context, sess session.Store) {
+		if ctx.Req.Method != "GET" || !strings.HasPrefix(ctx.Req.URL.Path, opt.Prefix+"/") {
+			return
+		}
+		// {Prefix}/{Name}/{action}
+		parts := strings.Split(strings.TrimPrefix(ctx.Req.URL.Path, opt.Prefix+"/"), "/")
+		if len(parts) != 2 {
+			return
+		}
+		for i := range opt.Providers {
+			p := &opt.Providers[i]
+			if p.Name != parts[0] {
+				continue
+			}
+			switch parts[1] {
+			case "login":
+				handleLogin(ctx, sess, opt, p)
+			case "callback":
+				if err := handleCallback(ctx, sess, opt, p); err != nil {
+					ctx.Logger().Println("login:", p.Name, err)
+					opt.OnError(ctx, err)
+				}
+			}
+			return
+		}
+	}
+}
+
+func (opt Options) consumer(ctx *web.Context, p *Provider) *oauth.OAuth2Consumer {
+	c := oauth.NewOAuth2Consumer(p.ClientID, p.ClientSecret, p.Endpoint)
+	c.Scopes = p.Scopes
+	base := opt.BaseURL
+	if len(base) == 0 {
+		scheme := "http"
+		if ctx.Req.TLS != nil || ctx.Req.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		base = scheme + "://" + ctx.Req.Host
+	}
+	c.RedirectUrl = base + opt.Prefix + "/" + p.Name + "/callback"
+	return c
+}
+
+func (opt Options) key(p *Provider, k string) string {
+	return opt.SessionKey + p.Name + "." + k
+}
+
+// safeNext only allows local paths, to avoid open redirects
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+func handleLogin(ctx *web.Context, sess session.Store, opt Options, p *Provider) {
+	verifier, err := oauth.NewPKCEVerifier()
+	if err != nil {
+		opt.OnError(ctx, err)
+		return
+	}
+	state := string(com.RandomCreateBytes(32))
+	params := map[string]string{}
+	for k, v := range p.AuthParams {
+		params[k] = v
+	}
+	sess.Set(opt.key(p, "state"), state)
+	sess.Set(opt.key(p, "verifier"), verifier)
+	sess.Set(opt.key(p, "next"), safeNext(ctx.Query("next")))
+	if len(p.Issuer) > 0 {
+		nonce := string(com.RandomCreateBytes(32))
+		params["nonce"] = nonce
+		sess.Set(opt.key(p, "nonce"), nonce)
+	}
+	ctx.Redirect(opt.consumer(ctx, p).AuthCodeUrl(state, verifier, params))
+}
+
+func handleCallback(ctx *web.Context, sess session.Store, opt Options, p *Provider) (err error) {
+	state, _ := sess.Get(opt.key(p, "state")).(string)
+	verifier, _ := sess.Get(opt.key(p, "verifier")).(string)
+	nonce, _ := sess.Get(opt.key(p, "nonce")).(string)
+	next, _ := sess.Get(opt.key(p, "next")).(string)
+	// state is single use
+	for _, k := range []string{"state", "verifier", "nonce", "next"} {
+		sess.Delete(opt.key(p, k))
+	}
+	if e := ctx.Query("error"); len(e) > 0 {
+		return &oauth.OAuth2Error{Code: e, Description: ctx.Query("error_description")}
+	}
+	if len(state) == 0 || ctx.Query("state") != state {
+		return ErrStateMismatch
+	}
+	c := opt.consumer(ctx, p)
+	cctx := ctx.Req.Context()
+	var tok *oauth.OAuth2Token
+	if tok, err = c.Exchange(cctx, ctx.Query("code"), verifier); err != nil {
+		return
+	}
+	claims := map[string]interface{}{}
+	subject := ""
+	if len(p.Issuer) > 0 {
+		raw := tok.AdditionalData["id_token"]
+		if len(raw) == 0 {
+			return ErrMissingIDToken
+		}
+		var idt *IDToken
+		if idt, err = VerifyIDToken(raw, p.jwks, p.Issuer, p.ClientID, nonce); err != nil {
+			return
+		}
+		subject = idt.Subject
+		for k, v := range idt.Claims {
+			claims[k] = v
+		}
+	}
+	if len(p.UserInfoURL) > 0 {
+		var info map[string]interface{}
+		if info, err = fetchUserInfo(cctx, c, tok, p.UserInfoURL); err != nil {
+			return
+		}
+		// see OpenID Connect Core 5.3.2, sub of userinfo must match the id token
+		if sub, ok := info["sub"]; ok && len(subject) > 0 && fmt.Sprint(sub) != subject {
+			return ErrSubjectMismatch
+		}
+		for k, v := range info {
+			claims[k] = v
+		}
+	}
+	profile := newProfile(p, tok, claims)
+	if len(profile.ID) == 0 {
+		return errors.New("login: missing user id in claims")
+	}
+	// avoid session fixation
+	var raw session.RawStore
+	if raw, err = sess.RegenerateId(ctx); err != nil {
+		return
+	}
+	sess = regenerated{Store: sess, raw: raw}
+	if opt.OnLogin != nil {
+		opt.OnLogin(ctx, sess, profile)
+	}
+	// Sessioner only releases the store of old session id
+	if err = raw.Release(); err != nil {
+		return
+	}
+	if !ctx.Written() {
+		ctx.Redirect(safeNext(next))
+	}
+	return
+}
+
+func fetchUserInfo(cctx context.Context, c *oauth.OAuth2Consumer, tok *oauth.OAuth2Token, u string) (info map[string]interface{}, err error) {
+	var client *http.Client
+	if client, err = c.MakeHttpClient(tok); err != nil {
+		return
+	}
+	var req *http.Request
+	if req, err = http.NewRequest("GET", u, nil); err != nil {
+		return
+	}
+	req.Header.Set("Accept", "application/json")
+	var resp *http.Response
+	if resp, err = client.Do(req.WithContext(cctx)); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	var buf []byte
+	if buf, err = ioutil.ReadAll(resp.Body); err != nil {
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("login: userinfo returned %s", resp.Status)
+		return
+	}
+	info, err = decodeClaims(buf)
+	return
+}
+
+// decodeClaims decodes numbers as json.Number, so large numeric ids keep their precision
+func decodeClaims(buf []byte) (claims map[string]interface{}, err error) {
+	d := json.NewDecoder(bytes.NewReader(buf))
+	d.UseNumber()
+	claims = map[string]interface{}{}
+	err = d.Decode(&claims)
+	return
+}
+
+func claimString(claims map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		switch v := claims[k].(type) {
+		case string:
+			if len(v) > 0 {
+				return v
+			}
+		case json.Number:
+			return v.String()
+		case float64:
+			return com.ToStr(int64(v))
+		}
+	}
+	return ""
+}
+
+// newProfile maps common claims of OpenID Connect, GitHub, Google and alike
+func newProfile(p *Provider, tok *oauth.OAuth2Token, claims map[string]interface{}) *Profile {
+	pf := &Profile{
+		Provider:  p.Name,
+		ID:        claimString(claims, "sub", "id", "user_id"),
+		Email:     claimString(claims, "email"),
+		Name:      claimString(claims, "name", "display_name"),
+		Username:  claimString(claims, "preferred_username", "login", "username", "nickname"),
+		AvatarURL: claimString(claims, "picture", "avatar_url"),
+		Token:     tok,
+		Claims:    claims,
+	}
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		pf.EmailVerified = v
+	case string:
+		pf.EmailVerified = v == "true"
+	}
+	if p.MapProfile != nil {
+		p.MapProfile(claims, pf)
+	}
+	return pf
+}
+
+// regenerated a session.Store whose data operations go to the regenerated raw store
+type regenerated struct {
+	session.Store
+	raw session.RawStore
+}
+
+func (s regenerated) Set(k interface{}, v interface{}) error { return s.raw.Set(k, v) }
+func (s regenerated) Get(k interface{}) interface{}          { return s.raw.Get(k) }
+func (s regenerated) Delete(k interface{}) error             { return s.raw.Delete(k) }
+func (s regenerated) ID() string                             { return s.raw.ID() }
+func (s regenerated) Release() error                         { return s.raw.Release() }
+func (s regenerated) Flush() error                           { return s.raw.Flush() }
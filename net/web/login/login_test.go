@@ -0,0 +1,296 @@
+package login
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"landzero.net/x/net/oauth"
+	"landzero.net/x/net/web"
+	"landzero.net/x/net/web/session"
+)
+
+// idp a minimal in-process OpenID Connect provider
+type idp struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	mtx    sync.Mutex
+	codes  map[string]url.Values
+	issuer string
+	// badNonce issue id tokens with a wrong nonce
+	badNonce bool
+}
+
+func newIDP(t *testing.T) *idp {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &idp{key: key, codes: map[string]url.Values{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		p.mtx.Lock()
+		p.codes["c1"] = q
+		p.mtx.Unlock()
+		http.Redirect(w, r, q.Get("redirect_uri")+"?code=c1&state="+url.QueryEscape(q.Get("state")), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		p.mtx.Lock()
+		q, ok := p.codes[r.PostForm.Get("code")]
+		delete(p.codes, r.PostForm.Get("code"))
+		p.mtx.Unlock()
+		if !ok || q.Get("code_challenge") != oauth.PKCEChallenge(r.PostForm.Get("code_verifier")) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		nonce := q.Get("nonce")
+		if p.badNonce {
+			nonce = "forged"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "at",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token": p.sign(map[string]interface{}{
+				"iss":   p.issuer,
+				"sub":   "u1",
+				"aud":   "client",
+				"exp":   time.Now().Add(time.Hour).Unix(),
+				"iat":   time.Now().Unix(),
+				"nonce": nonce,
+				"email": "alice@example.com",
+			}),
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "k1",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer at" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"sub":"u1","name":"Alice","preferred_username":"alice","email_verified":true}`))
+	})
+	p.Server = httptest.NewServer(mux)
+	p.issuer = p.URL
+	return p
+}
+
+func (p *idp) sign(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	s := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(s))
+	sig, _ := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, sum[:])
+	return s + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func (p *idp) provider() Provider {
+	return Provider{
+		Name:     "test",
+		ClientID: "client",
+		Endpoint: oauth.OAuth2ServiceProvider{
+			AuthorizeUrl: p.URL + "/authorize",
+			TokenUrl:     p.URL + "/token",
+		},
+		Scopes:      []string{"openid", "email", "profile"},
+		UserInfoURL: p.URL + "/userinfo",
+		Issuer:      p.issuer,
+		JWKSURL:     p.URL + "/jwks",
+	}
+}
+
+func newApp(p Provider, profiles chan *Profile, sopts ...session.Options) *httptest.Server {
+	m := web.New()
+	m.Use(session.Sessioner(sopts...))
+	m.Use(Loginer(Options{
+		Providers: []Provider{p},
+		OnLogin: func(ctx *web.Context, sess session.Store, p *Profile) {
+			sess.Set("uid", p.ID)
+			profiles <- p
+		},
+	}))
+	m.Get("/private", func(sess session.Store) string {
+		uid, _ := sess.Get("uid").(string)
+		return "uid=" + uid
+	})
+	return httptest.NewServer(m)
+}
+
+func get(t *testing.T, c *http.Client, u string) (int, string) {
+	resp, err := c.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	buf, _ := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, string(buf)
+}
+
+func TestLogin(t *testing.T) {
+	p := newIDP(t)
+	defer p.Close()
+	profiles := make(chan *Profile, 1)
+	app := newApp(p.provider(), profiles)
+	defer app.Close()
+
+	jar, _ := cookiejar.New(nil)
+	c := &http.Client{Jar: jar}
+	status, body := get(t, c, app.URL+"/auth/test/login?next=/private")
+	if status != 200 || body != "uid=u1" {
+		t.Fatal("unexpected", status, body)
+	}
+	pf := <-profiles
+	if pf.ID != "u1" || pf.Email != "alice@example.com" || !pf.EmailVerified || pf.Name != "Alice" || pf.Username != "alice" || pf.Provider != "test" {
+		t.Errorf("unexpected profile %+v", pf)
+	}
+	// replaying the callback fails, state is single use
+	status, _ = get(t, c, app.URL+"/auth/test/callback?code=c1&state=x")
+	if status != http.StatusUnauthorized {
+		t.Error("callback replay should fail, got", status)
+	}
+}
+
+func TestLoginFileSession(t *testing.T) {
+	p := newIDP(t)
+	defer p.Close()
+	dir, _ := ioutil.TempDir("", "login-session")
+	defer os.RemoveAll(dir)
+	app := newApp(p.provider(), make(chan *Profile, 1), session.Options{Adapter: "file", AdapterConfig: dir})
+	defer app.Close()
+
+	jar, _ := cookiejar.New(nil)
+	c := &http.Client{Jar: jar}
+	status, body := get(t, c, app.URL+"/auth/test/login?next=/private")
+	if status != 200 || body != "uid=u1" {
+		t.Fatal("unexpected", status, body)
+	}
+}
+
+func TestLoginBadNonce(t *testing.T) {
+	p := newIDP(t)
+	defer p.Close()
+	p.badNonce = true
+	app := newApp(p.provider(), make(chan *Profile, 1))
+	defer app.Close()
+	jar, _ := cookiejar.New(nil)
+	c := &http.Client{Jar: jar}
+	if status, _ := get(t, c, app.URL+"/auth/test/login"); status != http.StatusUnauthorized {
+		t.Error("forged nonce should fail, got", status)
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	p := newIDP(t)
+	defer p.Close()
+	jwks := NewJWKS(p.URL + "/jwks")
+	claims := map[string]interface{}{
+		"iss": p.issuer, "sub": "u1", "aud": []string{"other", "client"},
+		"exp": time.Now().Add(time.Minute).Unix(), "nonce": "n",
+	}
+	if _, err := VerifyIDToken(p.sign(claims), jwks, p.issuer, "client", "n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyIDToken(p.sign(claims), jwks, p.issuer, "nobody", "n"); err == nil {
+		t.Error("audience should mismatch")
+	}
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	if _, err := VerifyIDToken(p.sign(claims), jwks, p.issuer, "client", "n"); err == nil {
+		t.Error("should be expired")
+	}
+	raw := p.sign(claims)
+	parts := strings.Split(raw, ".")
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	tampered := strings.Split(p.sign(claims), ".")[1]
+	if _, err := VerifyIDToken(parts[0]+"."+tampered+"."+parts[2], jwks, p.issuer, "client", "n"); err == nil {
+		t.Error("tampered token should fail")
+	}
+}
+
+func TestJWKSFetch(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var fetches int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches++; fetches == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{
+				{"kid": "ed", "kty": "OKP", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+				{"kid": "hs", "kty": "oct", "k": "c2VjcmV0"},
+				{"kid": "k1", "kty": "RSA", "n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()), "e": "AQAB"},
+			},
+		})
+	}))
+	defer s.Close()
+	jwks := NewJWKS(s.URL)
+	if _, err := jwks.Key("k1"); err == nil {
+		t.Error("first fetch should fail")
+	}
+	// failed fetch is retried at once, unsupported keys are skipped
+	if k, err := jwks.Key("k1"); err != nil || k.(*rsa.PublicKey).N.Cmp(key.N) != 0 {
+		t.Error("unexpected", k, err)
+	}
+	if _, err := jwks.Key("ed"); err != ErrUnknownKey {
+		t.Error("unsupported key should be unknown, got", err)
+	}
+	if fetches != 2 {
+		t.Error("unexpected fetches", fetches)
+	}
+}
+
+func TestClaimString(t *testing.T) {
+	claims, err := decodeClaims([]byte(`{"id":9007199254740993,"login":"alice"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := claimString(claims, "sub", "id"); id != "9007199254740993" {
+		t.Error("unexpected id", id)
+	}
+	if s := claimString(claims, "login"); s != "alice" {
+		t.Error("unexpected login", s)
+	}
+}
+
+func TestSafeNext(t *testing.T) {
+	for in, out := range map[string]string{
+		"/a?b=c":            "/a?b=c",
+		"":                  "/",
+		"//evil.com":        "/",
+		"/\\evil.com":       "/",
+		"https://evil.com/": "/",
+	} {
+		if s := safeNext(in); s != out {
+			t.Errorf("%q: expected %q, got %q", in, out, s)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package login
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // hash functions of JWT algorithms
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// JWKSRefreshInterval min interval of refetching JWKS when an unknown key id is seen
+	JWKSRefreshInterval = time.Minute
+	// IDTokenLeeway allowed clock skew of verifying exp and iat
+	IDTokenLeeway = time.Minute
+)
+
+var (
+	// ErrInvalidIDToken id token is malformed or its signature is invalid
+	ErrInvalidIDToken = errors.New("login: invalid id token")
+	// ErrUnknownKey id token is signed with a key not in JWKS
+	ErrUnknownKey = errors.New("login: id token signed with unknown key")
+
+	errUnsupportedKey = errors.New("login: unsupported key")
+)
+
+// JWKS a JSON Web Key Set fetched from url, keys are cached and refetched on unknown key id
+type JWKS struct {
+	url    string
+	client *http.Client
+
+	mtx     *sync.Mutex
+	keys    map[string]crypto.PublicKey
+	fetched time.Time
+}
+
+// NewJWKS create a new JWKS fetched from url
+func NewJWKS(url string) *JWKS {
+	return &JWKS{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		mtx:    &sync.Mutex{},
+		keys:   map[string]crypto.PublicKey{},
+	}
+}
+
+// Key returns the public key with the key id, an empty kid matches the only key of the set
+func (j *JWKS) Key(kid string) (k crypto.PublicKey, err error) {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	if k = j.find(kid); k != nil {
+		return
+	}
+	if !j.fetched.IsZero() && time.Since(j.fetched) < JWKSRefreshInterval {
+		err = ErrUnknownKey
+		return
+	}
+	if err = j.fetch(); err != nil {
+		return
+	}
+	if k = j.find(kid); k == nil {
+		err = ErrUnknownKey
+	}
+	return
+}
+
+func (j *JWKS) find(kid string) crypto.PublicKey {
+	if len(kid) == 0 && len(j.keys) == 1 {
+		for _, k := range j.keys {
+			return k
+		}
+	}
+	return j.keys[kid]
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetch replaces the keys, keys of unsupported types or curves are skipped
+func (j *JWKS) fetch() (err error) {
+	var resp *http.Response
+	if resp, err = j.client.Get(j.url); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("login: failed to fetch jwks: %s", resp.Status)
+		return
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if len(k.Use) > 0 && k.Use != "sig" {
+			continue
+		}
+		var pk crypto.PublicKey
+		if pk, err = k.publicKey(); err == errUnsupportedKey {
+			err = nil
+			continue
+		} else if err != nil {
+			return
+		}
+		keys[k.Kid] = pk
+	}
+	j.keys = keys
+	j.fetched = time.Now()
+	return
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errUnsupportedKey
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, errUnsupportedKey
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(buf), nil
+}
+
+// IDToken a verified OpenID Connect ID token
+type IDToken struct {
+	Issuer   string
+	Subject  string
+	Audience []string
+	Expiry   time.Time
+	IssuedAt time.Time
+	Nonce    string
+	// Claims all claims
+	Claims map[string]interface{}
+}
+
+var algorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// VerifyIDToken verifies signature, issuer, audience, expiry and nonce of a raw ID token
+func VerifyIDToken(raw string, keys *JWKS, issuer string, clientID string, nonce string) (t *IDToken, err error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		err = ErrInvalidIDToken
+		return
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err = decodeSegment(parts[0], &header); err != nil {
+		return
+	}
+	hash, ok := algorithms[header.Alg]
+	if !ok {
+		err = fmt.Errorf("%v: unsupported algorithm %q", ErrInvalidIDToken, header.Alg)
+		return
+	}
+	var sig []byte
+	if sig, err = base64.RawURLEncoding.DecodeString(parts[2]); err != nil {
+		err = ErrInvalidIDToken
+		return
+	}
+	var key crypto.PublicKey
+	if key, err = keys.Key(header.Kid); err != nil {
+		return
+	}
+	h := hash.New()
+	h.Write([]byte(parts[0] + "." + parts[1]))
+	if err = verifySignature(key, header.Alg, hash, h.Sum(nil), sig); err != nil {
+		return
+	}
+	claims := map[string]interface{}{}
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return
+	}
+	t = &IDToken{Claims: claims}
+	t.Issuer, _ = claims["iss"].(string)
+	t.Subject, _ = claims["sub"].(string)
+	t.Nonce, _ = claims["nonce"].(string)
+	switch aud := claims["aud"].(type) {
+	case string:
+		t.Audience = []string{aud}
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				t.Audience = append(t.Audience, s)
+			}
+		}
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		t.Expiry = time.Unix(int64(exp), 0)
+	}
+	if iat, ok := claims["iat"].(float64); ok {
+		t.IssuedAt = time.Unix(int64(iat), 0)
+	}
+	// claims
+	now := time.Now()
+	switch {
+	case t.Issuer != issuer:
+		err = fmt.Errorf("%v: issuer %q mismatch", ErrInvalidIDToken, t.Issuer)
+	case !contains(t.Audience, clientID):
+		err = fmt.Errorf("%v: audience mismatch", ErrInvalidIDToken)
+	case t.Expiry.IsZero() || now.After(t.Expiry.Add(IDTokenLeeway)):
+		err = fmt.Errorf("%v: expired", ErrInvalidIDToken)
+	case t.IssuedAt.After(now.Add(IDTokenLeeway)):
+		err = fmt.Errorf("%v: issued in the future", ErrInvalidIDToken)
+	case len(t.Subject) == 0:
+		err = fmt.Errorf("%v: missing sub", ErrInvalidIDToken)
+	case t.Nonce != nonce:
+		err = fmt.Errorf("%v: nonce mismatch", ErrInvalidIDToken)
+	}
+	if err != nil {
+		t = nil
+	}
+	return
+}
+
+func verifySignature(key crypto.PublicKey, alg string, hash crypto.Hash, digest []byte, sig []byte) error {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if alg[0] == 'R' && rsa.VerifyPKCS1v15(k, hash, digest, sig) == nil {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if alg[0] == 'E' && len(sig) == 2*size {
+			r := new(big.Int).SetBytes(sig[:size])
+			s := new(big.Int).SetBytes(sig[size:])
+			if ecdsa.Verify(k, digest, r, s) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%v: bad signature", ErrInvalidIDToken)
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	buf, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return ErrInvalidIDToken
+	}
+	if err = json.Unmarshal(buf, v); err != nil {
+		return ErrInvalidIDToken
+	}
+	return nil
+}
+
+func contains(l []string, s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
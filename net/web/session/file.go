@@ -81,7 +81,7 @@ func (s *FileStore) Release() error {
 	s.p.lock.Lock()
 	defer s.p.lock.Unlock()
 
-	data, err := EncodeGob(s.data)
+	data, err := EncryptGob(s.p.e, s.sid, s.data)
 	if err != nil {
 		return err
 	}
@@ -103,6 +103,7 @@ type FileAdapter struct {
 	lock        sync.RWMutex
 	maxlifetime int64
 	rootPath    string
+	e           Encrypter
 }
 
 // Init initializes file session provider with given root path.
@@ -114,6 +115,13 @@ func (p *FileAdapter) Init(maxlifetime int64, rootPath string) error {
 	return nil
 }
 
+// SetEncrypter sets encrypter to encrypt session files with.
+func (p *FileAdapter) SetEncrypter(e Encrypter) {
+	p.lock.Lock()
+	p.e = e
+	p.lock.Unlock()
+}
+
 func (p *FileAdapter) filepath(sid string) string {
 	return path.Join(p.rootPath, string(sid[0]), string(sid[1]), sid)
 }
@@ -150,7 +158,7 @@ func (p *FileAdapter) Read(sid string) (_ RawStore, err error) {
 	if len(data) == 0 {
 		kv = make(map[interface{}]interface{})
 	} else {
-		kv, err = DecodeGob(data)
+		kv, err = DecryptGob(p.e, sid, data)
 		if err != nil {
 			return nil, err
 		}
@@ -183,7 +191,7 @@ func (p *FileAdapter) regenerate(oldsid, sid string) (err error) {
 
 	oldname := p.filepath(oldsid)
 	if !com.IsFile(oldname) {
-		data, err := EncodeGob(make(map[interface{}]interface{}))
+		data, err := EncryptGob(p.e, oldsid, make(map[interface{}]interface{}))
 		if err != nil {
 			return err
 		}
@@ -201,6 +209,21 @@ func (p *FileAdapter) regenerate(oldsid, sid string) (err error) {
 	if err = os.Rename(oldname, filename); err != nil {
 		return err
 	}
+	if p.e != nil {
+		// encrypted data is bound to the session id
+		var data []byte
+		if data, err = ioutil.ReadFile(filename); err != nil {
+			return err
+		}
+		var kv map[interface{}]interface{}
+		if kv, err = DecryptGob(p.e, oldsid, data); err != nil {
+			return err
+		}
+		if data, err = EncryptGob(p.e, sid, kv); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filename, data, 0600)
+	}
 	return nil
 }
 
@@ -0,0 +1,57 @@
+package session
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"landzero.net/x/com"
+)
+
+func Test_EncryptedFileAdapter(t *testing.T) {
+	Convey("Test encrypted file adapter", t, func() {
+		dir, err := ioutil.TempDir("", "session-file")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		kr := com.NewKeyring()
+		So(kr.AddKey("1", make([]byte, 32)), ShouldBeNil)
+		So(kr.SetPrimary("1"), ShouldBeNil)
+
+		p := &FileAdapter{}
+		So(p.Init(3600, dir), ShouldBeNil)
+		p.SetEncrypter(kr)
+
+		s, err := p.Read("aa1")
+		So(err, ShouldBeNil)
+		So(s.Set("uname", "unknwon"), ShouldBeNil)
+		So(s.Release(), ShouldBeNil)
+
+		s, err = p.Read("aa1")
+		So(err, ShouldBeNil)
+		So(s.Get("uname"), ShouldEqual, "unknwon")
+
+		Convey("Regenerate keeps data", func() {
+			s, err := p.Regenerate("aa1", "bb2")
+			So(err, ShouldBeNil)
+			So(s.Get("uname"), ShouldEqual, "unknwon")
+			So(p.Exist("aa1"), ShouldBeFalse)
+
+			s, err = p.Read("bb2")
+			So(err, ShouldBeNil)
+			So(s.Get("uname"), ShouldEqual, "unknwon")
+		})
+
+		Convey("Data moved to another session is discarded", func() {
+			s, err := p.Read("cc3")
+			So(err, ShouldBeNil)
+			So(s.Release(), ShouldBeNil)
+			So(os.Rename(p.filepath("aa1"), p.filepath("cc3")), ShouldBeNil)
+
+			s, err = p.Read("cc3")
+			So(err, ShouldBeNil)
+			So(s.Get("uname"), ShouldBeNil)
+		})
+	})
+}
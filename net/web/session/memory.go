@@ -212,6 +212,9 @@ func (p *MemAdapter) GC() {
 	p.lock.RUnlock()
 }
 
+// SetEncrypter does nothing, session data never leaves the process.
+func (_ *MemAdapter) SetEncrypter(_ Encrypter) {}
+
 func init() {
 	Register("memory", &MemAdapter{list: list.New(), data: make(map[string]*list.Element)})
 }
@@ -31,6 +31,7 @@ type RedisStore struct {
 	duration    time.Duration
 	lock        sync.RWMutex
 	data        map[interface{}]interface{}
+	e           session.Encrypter
 }
 
 // NewRedisStore creates and returns a redis session store.
@@ -77,7 +78,7 @@ func (s *RedisStore) ID() string {
 
 // Release releases resource and save data to provider.
 func (s *RedisStore) Release() error {
-	data, err := session.EncodeGob(s.data)
+	data, err := session.EncryptGob(s.e, s.sid, s.data)
 	if err != nil {
 		return err
 	}
@@ -99,6 +100,7 @@ type RedisAdapter struct {
 	c        *redis.Client
 	duration time.Duration
 	prefix   string
+	e        session.Encrypter
 }
 
 // Init initializes redis session provider.
@@ -118,6 +120,17 @@ func (p *RedisAdapter) Init(maxlifetime int64, configs string) (err error) {
 	return p.c.Ping().Err()
 }
 
+// SetEncrypter sets encrypter to encrypt session data with.
+func (p *RedisAdapter) SetEncrypter(e session.Encrypter) {
+	p.e = e
+}
+
+func (p *RedisAdapter) newStore(sid string, kv map[interface{}]interface{}) *RedisStore {
+	s := NewRedisStore(p.c, p.prefix, sid, p.duration, kv)
+	s.e = p.e
+	return s
+}
+
 // Read returns raw session store by session ID.
 func (p *RedisAdapter) Read(sid string) (session.RawStore, error) {
 	psid := p.prefix + sid
@@ -135,13 +148,13 @@ func (p *RedisAdapter) Read(sid string) (session.RawStore, error) {
 	if len(kvs) == 0 {
 		kv = make(map[interface{}]interface{})
 	} else {
-		kv, err = session.DecodeGob([]byte(kvs))
+		kv, err = session.DecryptGob(p.e, sid, []byte(kvs))
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	return NewRedisStore(p.c, p.prefix, sid, p.duration, kv), nil
+	return p.newStore(sid, kv), nil
 }
 
 // Exist returns true if session with given ID exists.
@@ -182,13 +195,20 @@ func (p *RedisAdapter) Regenerate(oldsid, sid string) (_ session.RawStore, err e
 	if len(kvs) == 0 {
 		kv = make(map[interface{}]interface{})
 	} else {
-		kv, err = session.DecodeGob([]byte(kvs))
+		kv, err = session.DecryptGob(p.e, oldsid, []byte(kvs))
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	return NewRedisStore(p.c, p.prefix, sid, p.duration, kv), nil
+	s := p.newStore(sid, kv)
+	if p.e != nil {
+		// encrypted data is bound to the session id
+		if err = s.Release(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
 }
 
 // Count counts and returns number of sessions.
@@ -92,6 +92,9 @@ type Options struct {
 	Domain string
 	// Session ID length. Default is 16.
 	IDLength int
+	// Encrypter to encrypt session data with, ie: *com.Keyring, for adapters which implement EncryptAdapter.
+	// Default is nil, not encrypted.
+	Encrypter Encrypter
 }
 
 func prepareOptions(options []Options) Options {
@@ -189,6 +192,19 @@ type Adapter interface {
 	GC()
 }
 
+// Encrypter encrypts and decrypts session data, it is implemented by *com.Keyring for key rotation.
+// ad is the session ID, it must be authenticated so data can not be moved to another session.
+type Encrypter interface {
+	Encrypt(plaintext, ad []byte) ([]byte, error)
+	Decrypt(ciphertext, ad []byte) ([]byte, error)
+}
+
+// EncryptAdapter is implemented by adapters which can encrypt session data.
+type EncryptAdapter interface {
+	// SetEncrypter sets encrypter to encrypt session data with.
+	SetEncrypter(e Encrypter)
+}
+
 var providers = make(map[string]Adapter)
 
 // Register registers a provider.
@@ -222,6 +238,13 @@ func NewManager(name string, opt Options) (*Manager, error) {
 	if !ok {
 		return nil, fmt.Errorf("session: unknown provider '%s'(forgotten import?)", name)
 	}
+	if opt.Encrypter != nil {
+		ea, ok := p.(EncryptAdapter)
+		if !ok {
+			return nil, fmt.Errorf("session: provider '%s' does not support encryption", name)
+		}
+		ea.SetEncrypter(opt.Encrypter)
+	}
 	return &Manager{p, opt}, p.Init(opt.Maxlifetime, opt.AdapterConfig)
 }
 
@@ -50,6 +50,29 @@ func DecodeGob(encoded []byte) (out map[interface{}]interface{}, err error) {
 	return out, err
 }
 
+// EncryptGob encodes obj by EncodeGob, then encrypts it for session sid with e if e is not nil.
+func EncryptGob(e Encrypter, sid string, obj map[interface{}]interface{}) ([]byte, error) {
+	data, err := EncodeGob(obj)
+	if err != nil || e == nil {
+		return data, err
+	}
+	return e.Encrypt(data, []byte(sid))
+}
+
+// DecryptGob decrypts encoded of session sid with e if e is not nil, then decodes it by DecodeGob.
+// Data which can not be decrypted, ie: written before encryption was enabled, with a removed key
+// or for another session, is discarded as if the session expired.
+func DecryptGob(e Encrypter, sid string, encoded []byte) (map[interface{}]interface{}, error) {
+	if e != nil {
+		data, err := e.Decrypt(encoded, []byte(sid))
+		if err != nil {
+			return make(map[interface{}]interface{}), nil
+		}
+		encoded = data
+	}
+	return DecodeGob(encoded)
+}
+
 // generateRandomKey creates a random key with the given strength.
 func generateRandomKey(strength int) []byte {
 	k := make([]byte, strength)
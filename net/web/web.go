@@ -18,6 +18,7 @@
 package web
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"io"
@@ -26,13 +27,17 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"syscall"
 	"time"
 
 	"landzero.net/x/log"
 
 	"landzero.net/x/com"
+	"landzero.net/x/net/graceful"
 	"landzero.net/x/net/ivy"
 	"landzero.net/x/net/web/inject"
+	"landzero.net/x/os/osext"
+	"landzero.net/x/os/systemd"
 )
 
 var (
@@ -51,6 +56,8 @@ const (
 	CridHeaderName = "X-Correlation-ID"
 	// CridParamName name of correlation id parameter
 	CridParamName = "_crid"
+	// CridFieldName name of correlation id field of request-scoped logger
+	CridFieldName = "crid"
 )
 
 // Handler can be any callable function.
@@ -244,19 +251,23 @@ func (m *Web) Use(handler Handler) {
 }
 
 func (m *Web) createContext(rw http.ResponseWriter, req *http.Request) *Context {
+	crid := extractCrid(req)
+	reqLogger := m.logger.With(CridFieldName, crid)
+	req = req.WithContext(log.NewContext(req.Context(), reqLogger))
 	c := &Context{
-		env:      m.env,
-		Injector: inject.New(),
-		handlers: m.handlers,
-		action:   m.action,
-		index:    0,
-		Router:   m.Router,
-		Req:      Request{req},
-		Resp:     NewResponseWriter(rw),
-		Render:   &DummyRender{rw},
-		Data:     make(map[string]interface{}),
-		crid:     extractCrid(req),
-		logger:   m.logger,
+		env:       m.env,
+		Injector:  inject.New(),
+		handlers:  m.handlers,
+		action:    m.action,
+		index:     0,
+		Router:    m.Router,
+		Req:       Request{req},
+		Resp:      NewResponseWriter(rw),
+		Render:    &DummyRender{rw},
+		Data:      make(map[string]interface{}),
+		crid:      crid,
+		logger:    m.logger,
+		reqLogger: reqLogger,
 	}
 	c.Resp.Header().Set(CridHeaderName, c.crid)
 	c.SetParent(m)
@@ -295,6 +306,77 @@ func GetDefaultListenInfo() (string, int) {
 
 // Run the http server. Listening on os.GetEnv("PORT") or 4000 by default.
 func (m *Web) Run(args ...interface{}) {
+	addr := listenAddr(args...)
+	logger := m.GetVal(reflect.TypeOf(m.logger)).Interface().(*log.Logger)
+	logger.Printf("listening on %s (%s)\n", addr, m.Env())
+	logger.Fatalln(http.ListenAndServe(addr, m))
+}
+
+// RunSystemd run the http server on the socket passed by systemd socket activation, notify
+// readiness and send watchdog pings to systemd. Without socket activation, it listens on the
+// address of arguments, which are same as Run. On SIGINT or SIGTERM, stopping is notified and
+// connections are drained before exit.
+func (m *Web) RunSystemd(args ...interface{}) {
+	addr := listenAddr(args...)
+	logger := m.GetVal(reflect.TypeOf(m.logger)).Interface().(*log.Logger)
+	activated := systemd.Activated()
+	// only fall back to addr if no socket is passed
+	l, err := systemd.Listen("", "tcp", addr)
+	if err != nil {
+		logger.Fatalln(err)
+		return
+	}
+	if activated {
+		logger.Printf("listening on systemd socket %s (%s)\n", l.Addr(), m.Env())
+	} else {
+		logger.Printf("listening on %s (%s)\n", addr, m.Env())
+	}
+	s := &http.Server{Handler: m}
+	go func() {
+		if err := s.Serve(l); err != nil && err != http.ErrServerClosed {
+			logger.Fatalln(err)
+		}
+	}()
+	done := make(chan struct{})
+	defer close(done)
+	systemd.Notify(systemd.Ready)
+	systemd.StartWatchdog(done)
+	osext.WaitSignals(syscall.SIGINT, syscall.SIGTERM)
+	systemd.Notify(systemd.Stopping)
+	ctx, cancel := context.WithTimeout(context.Background(), graceful.DefaultTimeout)
+	defer cancel()
+	if err = s.Shutdown(ctx); err != nil {
+		logger.Println("shutdown:", err)
+	}
+}
+
+// RunGraceful run the http server with graceful restart, arguments are same as Run,
+// on SIGUSR2 a new process takes over the listener and the current one drains connections,
+// on SIGINT or SIGTERM connections are drained before exit
+func (m *Web) RunGraceful(args ...interface{}) {
+	addr := listenAddr(args...)
+	logger := m.GetVal(reflect.TypeOf(m.logger)).Interface().(*log.Logger)
+	g := graceful.New(graceful.Options{})
+	l, err := g.Listen("tcp", addr)
+	if err != nil {
+		logger.Fatalln(err)
+		return
+	}
+	s := &http.Server{Handler: m}
+	g.Add(s)
+	go func() {
+		if err := s.Serve(l); err != nil && err != http.ErrServerClosed {
+			logger.Fatalln(err)
+		}
+	}()
+	logger.Printf("listening on %s (%s), pid %d\n", addr, m.Env(), os.Getpid())
+	g.Ready()
+	if err = g.Wait(); err != nil {
+		logger.Println("graceful shutdown:", err)
+	}
+}
+
+func listenAddr(args ...interface{}) string {
 	host, port := GetDefaultListenInfo()
 	if len(args) == 1 {
 		switch arg := args[0].(type) {
@@ -312,10 +394,7 @@ func (m *Web) Run(args ...interface{}) {
 		}
 	}
 
-	addr := host + ":" + com.ToStr(port)
-	logger := m.GetVal(reflect.TypeOf(m.logger)).Interface().(*log.Logger)
-	logger.Printf("listening on %s (%s)\n", addr, m.Env())
-	logger.Fatalln(http.ListenAndServe(addr, m))
+	return host + ":" + com.ToStr(port)
 }
 
 // RunIvy run the http server with Ivy Protocol
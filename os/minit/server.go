@@ -2,6 +2,7 @@ package minit
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"encoding/gob"
 	"errors"
@@ -13,6 +14,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 	"sync/atomic"
 
 	"landzero.net/x/io/ioext"
@@ -23,6 +25,8 @@ import (
 var (
 	// ErrEmptyCommand empty command
 	ErrEmptyCommand = errors.New("empty command")
+	// ErrServerClosed Serve is called after Shutdown
+	ErrServerClosed = errors.New("server closed")
 )
 
 type winsizeWriter struct {
@@ -51,17 +55,102 @@ func newWinsizeWriter(p *os.File) io.Writer {
 
 // Serve serve on a net.Listener and blocks
 func Serve(l net.Listener) (err error) {
-	var id uint64
+	return NewServer().Serve(l)
+}
+
+// Server serves connections and tracks them, so it can be drained by Shutdown,
+// it implements graceful.Server
+type Server struct {
+	id        uint64
+	mtx       *sync.Mutex
+	wg        *sync.WaitGroup
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+}
+
+// NewServer create a new Server
+func NewServer() *Server {
+	return &Server{
+		mtx:       &sync.Mutex{},
+		wg:        &sync.WaitGroup{},
+		listeners: map[net.Listener]struct{}{},
+		conns:     map[net.Conn]struct{}{},
+	}
+}
+
+// Serve serve on a net.Listener and blocks until the listener is closed,
+// returns ErrServerClosed if called after Shutdown
+func (s *Server) Serve(l net.Listener) (err error) {
+	s.mtx.Lock()
+	if s.closed {
+		s.mtx.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.mtx.Unlock()
+	defer func() {
+		s.mtx.Lock()
+		delete(s.listeners, l)
+		s.mtx.Unlock()
+	}()
 	for {
 		var c net.Conn
 		if c, err = l.Accept(); err != nil {
 			break
 		}
-		go NewServerConn(c, atomic.AddUint64(&id, 1)).Handle()
+		s.mtx.Lock()
+		// accepted right before Shutdown closed the listener, WaitGroup may be waited already
+		if s.closed {
+			s.mtx.Unlock()
+			c.Close()
+			continue
+		}
+		s.conns[c] = struct{}{}
+		s.wg.Add(1)
+		s.mtx.Unlock()
+		go s.handle(c)
 	}
 	return
 }
 
+func (s *Server) handle(c net.Conn) {
+	defer s.wg.Done()
+	NewServerConn(c, atomic.AddUint64(&s.id, 1)).Handle()
+	s.mtx.Lock()
+	delete(s.conns, c)
+	s.mtx.Unlock()
+}
+
+// Shutdown closes all listeners and waits for active connections to finish, connections
+// still active when ctx is done are closed
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mtx.Lock()
+	s.closed = true
+	for l := range s.listeners {
+		l.Close()
+	}
+	s.mtx.Unlock()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+	s.mtx.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mtx.Unlock()
+	<-done
+	return ctx.Err()
+}
+
 // ServerConn server side connection
 type ServerConn interface {
 	// Handle the connection and blocks
@@ -0,0 +1,335 @@
+package osext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"landzero.net/x/log"
+)
+
+const (
+	// DefaultStartTimeout default timeout of starting a component
+	DefaultStartTimeout = time.Second * 30
+	// DefaultStopTimeout default timeout of stopping a component
+	DefaultStopTimeout = time.Second * 30
+
+	// FailureExitCode exit code of a failed component without ExitCode()
+	FailureExitCode = 1
+	// ForceExitCode exit code when a second terminating signal arrives while stopping
+	ForceExitCode = 2
+)
+
+var (
+	// ErrDuplicateComponent component with same name is already added
+	ErrDuplicateComponent = errors.New("lifecycle: duplicate component")
+	// ErrUnknownDependency component depends on a component not added
+	ErrUnknownDependency = errors.New("lifecycle: unknown dependency")
+	// ErrDependencyCycle components depend on each other
+	ErrDependencyCycle = errors.New("lifecycle: dependency cycle")
+	// ErrAlreadyStarted Lifecycle can only be started once
+	ErrAlreadyStarted = errors.New("lifecycle: already started")
+)
+
+// ExitCoder an error carries a process exit code
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// Component a part of the process with start and stop functions, all functions are optional
+type Component struct {
+	// Name unique name of the component
+	Name string
+	// DependsOn names of components must be started before, and stopped after this one
+	DependsOn []string
+	// Start starts the component and returns, long running work should be done in goroutines
+	Start func(ctx context.Context) error
+	// Stop stops the component, ctx is canceled after StopTimeout
+	Stop func(ctx context.Context) error
+	// Reload reloads configuration of the component, called on SIGHUP
+	Reload func(ctx context.Context) error
+	// StartTimeout timeout of Start, defaults to DefaultStartTimeout
+	StartTimeout time.Duration
+	// StopTimeout timeout of Stop, defaults to DefaultStopTimeout
+	StopTimeout time.Duration
+}
+
+// ComponentError error of a component
+type ComponentError struct {
+	Name string
+	Op   string
+	Err  error
+}
+
+func (e *ComponentError) Error() string {
+	return fmt.Sprintf("lifecycle: failed to %s %s: %v", e.Op, e.Name, e.Err)
+}
+
+// ExitCode implements ExitCoder, returns exit code of the underlying error or FailureExitCode
+func (e *ComponentError) ExitCode() int {
+	if ec, ok := e.Err.(ExitCoder); ok {
+		return ec.ExitCode()
+	}
+	return FailureExitCode
+}
+
+// Lifecycle starts components in dependency order, and stops them in reverse order
+type Lifecycle struct {
+	mtx        *sync.Mutex
+	components []Component
+	started    []Component
+	errs       []error
+	running    bool
+	failCh     chan struct{}
+	exit       func(code int)
+}
+
+// NewLifecycle create a new Lifecycle
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{
+		mtx:    &sync.Mutex{},
+		failCh: make(chan struct{}, 1),
+		exit:   os.Exit,
+	}
+}
+
+// Add add a component
+func (l *Lifecycle) Add(c Component) error {
+	if c.StartTimeout <= 0 {
+		c.StartTimeout = DefaultStartTimeout
+	}
+	if c.StopTimeout <= 0 {
+		c.StopTimeout = DefaultStopTimeout
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	for _, e := range l.components {
+		if e.Name == c.Name {
+			return ErrDuplicateComponent
+		}
+	}
+	l.components = append(l.components, c)
+	return nil
+}
+
+// order sorts components by dependencies, keeping the adding order where possible
+func (l *Lifecycle) order() (sorted []Component, err error) {
+	byName := map[string]Component{}
+	for _, c := range l.components {
+		byName[c.Name] = c
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var visit func(c Component) error
+	visit = func(c Component) error {
+		switch state[c.Name] {
+		case visiting:
+			return ErrDependencyCycle
+		case visited:
+			return nil
+		}
+		state[c.Name] = visiting
+		for _, name := range c.DependsOn {
+			dep, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("%v: %s depends on %s", ErrUnknownDependency, c.Name, name)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[c.Name] = visited
+		sorted = append(sorted, c)
+		return nil
+	}
+	for _, c := range l.components {
+		if err = visit(c); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Start starts all components in dependency order, if any fails, started ones are stopped
+func (l *Lifecycle) Start(ctx context.Context) (err error) {
+	l.mtx.Lock()
+	if l.running || len(l.started) > 0 {
+		l.mtx.Unlock()
+		return ErrAlreadyStarted
+	}
+	var sorted []Component
+	if sorted, err = l.order(); err != nil {
+		l.mtx.Unlock()
+		l.record(err)
+		return
+	}
+	l.running = true
+	l.mtx.Unlock()
+	for _, c := range sorted {
+		if c.Start != nil {
+			sctx, cancel := context.WithTimeout(ctx, c.StartTimeout)
+			err = c.Start(sctx)
+			cancel()
+			if err != nil {
+				err = &ComponentError{Name: c.Name, Op: "start", Err: err}
+				l.record(err)
+				l.Stop()
+				return
+			}
+		}
+		log.Println("lifecycle: started", c.Name)
+		l.mtx.Lock()
+		l.started = append(l.started, c)
+		l.mtx.Unlock()
+	}
+	return
+}
+
+// Stop stops started components in reverse order, each with its own timeout, returns the first error
+func (l *Lifecycle) Stop() (err error) {
+	l.mtx.Lock()
+	started := l.started
+	l.started = nil
+	l.running = false
+	l.mtx.Unlock()
+	for i := len(started) - 1; i >= 0; i-- {
+		c := started[i]
+		if c.Stop != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), c.StopTimeout)
+			serr := c.Stop(ctx)
+			cancel()
+			if serr != nil {
+				serr = &ComponentError{Name: c.Name, Op: "stop", Err: serr}
+				l.record(serr)
+				if err == nil {
+					err = serr
+				}
+				continue
+			}
+		}
+		log.Println("lifecycle: stopped", c.Name)
+	}
+	return
+}
+
+// Reload reloads started components in start order, returns the first error
+func (l *Lifecycle) Reload() (err error) {
+	l.mtx.Lock()
+	started := append([]Component(nil), l.started...)
+	l.mtx.Unlock()
+	for _, c := range started {
+		if c.Reload == nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), c.StartTimeout)
+		rerr := c.Reload(ctx)
+		cancel()
+		if rerr != nil {
+			rerr = &ComponentError{Name: c.Name, Op: "reload", Err: rerr}
+			log.Println(rerr.Error())
+			if err == nil {
+				err = rerr
+			}
+		}
+	}
+	return
+}
+
+// Fail reports a component failed while running, for example a server stopped serving,
+// the error is recorded and Run starts stopping
+func (l *Lifecycle) Fail(name string, err error) {
+	if err == nil {
+		return
+	}
+	l.record(&ComponentError{Name: name, Op: "run", Err: err})
+	select {
+	case l.failCh <- struct{}{}:
+	default:
+	}
+}
+
+func (l *Lifecycle) record(err error) {
+	log.Println(err.Error())
+	l.mtx.Lock()
+	l.errs = append(l.errs, err)
+	l.mtx.Unlock()
+}
+
+// Errors returns all recorded errors
+func (l *Lifecycle) Errors() []error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return append([]error(nil), l.errs...)
+}
+
+// ExitCode returns exit code derived from the first recorded error, 0 if no error
+func (l *Lifecycle) ExitCode() int {
+	errs := l.Errors()
+	if len(errs) == 0 {
+		return 0
+	}
+	if ec, ok := errs[0].(ExitCoder); ok {
+		return ec.ExitCode()
+	}
+	return FailureExitCode
+}
+
+// Run starts all components, reloads on SIGHUP, stops on SIGINT or SIGTERM or Fail, and returns the exit code,
+// which is also set by WillExit, a second SIGINT or SIGTERM while stopping exits immediately with ForceExitCode
+func (l *Lifecycle) Run() int {
+	c := make(chan os.Signal, 2)
+	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(c)
+	return l.run(c)
+}
+
+func (l *Lifecycle) run(c chan os.Signal) (code int) {
+	defer func() {
+		WillExit(code)
+	}()
+	if err := l.Start(context.Background()); err != nil {
+		return l.ExitCode()
+	}
+wait:
+	for {
+		select {
+		case sig := <-c:
+			if sig == syscall.SIGHUP {
+				log.Println("lifecycle: reloading")
+				l.Reload()
+				continue
+			}
+			log.Println("lifecycle: stopping on", sig)
+			break wait
+		case <-l.failCh:
+			log.Println("lifecycle: stopping on failure")
+			break wait
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-c:
+				if sig == syscall.SIGHUP {
+					continue
+				}
+				log.Println("lifecycle: force quit on", sig)
+				l.exit(ForceExitCode)
+			case <-done:
+				return
+			}
+		}
+	}()
+	l.Stop()
+	close(done)
+	return l.ExitCode()
+}
@@ -0,0 +1,165 @@
+package osext
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type exitError int
+
+func (e exitError) Error() string { return "exit" }
+
+func (e exitError) ExitCode() int { return int(e) }
+
+type recorder struct {
+	mtx   *sync.Mutex
+	calls []string
+}
+
+func (r *recorder) add(s string) {
+	r.mtx.Lock()
+	r.calls = append(r.calls, s)
+	r.mtx.Unlock()
+}
+
+func (r *recorder) get() []string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+func (r *recorder) component(name string, deps ...string) Component {
+	return Component{
+		Name:      name,
+		DependsOn: deps,
+		Start:     func(ctx context.Context) error { r.add("start " + name); return nil },
+		Stop:      func(ctx context.Context) error { r.add("stop " + name); return nil },
+		Reload:    func(ctx context.Context) error { r.add("reload " + name); return nil },
+	}
+}
+
+func TestLifecycleOrder(t *testing.T) {
+	r := &recorder{mtx: &sync.Mutex{}}
+	l := NewLifecycle()
+	l.Add(r.component("http", "db", "cache"))
+	l.Add(r.component("cache", "db"))
+	l.Add(r.component("db"))
+	if err := l.Add(r.component("db")); err != ErrDuplicateComponent {
+		t.Fatal(err)
+	}
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	l.Reload()
+	if err := l.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"start db", "start cache", "start http",
+		"reload db", "reload cache", "reload http",
+		"stop http", "stop cache", "stop db",
+	}
+	if !reflect.DeepEqual(r.get(), expected) {
+		t.Fatal(r.get())
+	}
+	if l.ExitCode() != 0 {
+		t.Fatal(l.ExitCode())
+	}
+}
+
+func TestLifecycleDependencyErrors(t *testing.T) {
+	r := &recorder{mtx: &sync.Mutex{}}
+	l := NewLifecycle()
+	l.Add(r.component("a", "b"))
+	l.Add(r.component("b", "a"))
+	if err := l.Start(context.Background()); err != ErrDependencyCycle {
+		t.Fatal(err)
+	}
+	l = NewLifecycle()
+	l.Add(r.component("a", "missing"))
+	if err := l.Start(context.Background()); err == nil {
+		t.Fatal("should fail")
+	}
+	if len(r.get()) != 0 {
+		t.Fatal(r.get())
+	}
+}
+
+func TestLifecycleStartFailure(t *testing.T) {
+	r := &recorder{mtx: &sync.Mutex{}}
+	l := NewLifecycle()
+	l.Add(r.component("db"))
+	bad := r.component("http", "db")
+	bad.Start = func(ctx context.Context) error { return exitError(3) }
+	l.Add(bad)
+	l.Add(r.component("worker", "http"))
+	if code := l.run(make(chan os.Signal)); code != 3 {
+		t.Fatal(code)
+	}
+	if ExitCode != 3 {
+		t.Fatal(ExitCode)
+	}
+	WillExit(0)
+	if !reflect.DeepEqual(r.get(), []string{"start db", "stop db"}) {
+		t.Fatal(r.get())
+	}
+}
+
+func TestLifecycleRun(t *testing.T) {
+	r := &recorder{mtx: &sync.Mutex{}}
+	l := NewLifecycle()
+	l.Add(r.component("db"))
+	c := make(chan os.Signal, 2)
+	c <- syscall.SIGHUP
+	c <- syscall.SIGTERM
+	if code := l.run(c); code != 0 {
+		t.Fatal(code)
+	}
+	if !reflect.DeepEqual(r.get(), []string{"start db", "reload db", "stop db"}) {
+		t.Fatal(r.get())
+	}
+}
+
+func TestLifecycleFailAndForceQuit(t *testing.T) {
+	l := NewLifecycle()
+	forced := make(chan int, 1)
+	l.exit = func(code int) { forced <- code }
+	stopping := make(chan struct{})
+	l.Add(Component{
+		Name:        "slow",
+		StopTimeout: time.Second,
+		Stop: func(ctx context.Context) error {
+			close(stopping)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	c := make(chan os.Signal, 1)
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		l.Fail("slow", errors.New("boom"))
+		<-stopping
+		c <- syscall.SIGINT
+	}()
+	if code := l.run(c); code != FailureExitCode {
+		t.Fatal(code)
+	}
+	WillExit(0)
+	select {
+	case code := <-forced:
+		if code != ForceExitCode {
+			t.Fatal(code)
+		}
+	default:
+		t.Fatal("should force quit")
+	}
+	if errs := l.Errors(); len(errs) != 2 {
+		t.Fatal(errs)
+	}
+}
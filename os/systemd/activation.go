@@ -0,0 +1,112 @@
+package systemd
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// EnvListenPID pid the passed file descriptors are intended for
+	EnvListenPID = "LISTEN_PID"
+	// EnvListenFDs count of passed file descriptors
+	EnvListenFDs = "LISTEN_FDS"
+	// EnvListenFDNames colon separated names of passed file descriptors, set by FileDescriptorName= of the socket unit
+	EnvListenFDNames = "LISTEN_FDNAMES"
+
+	// ListenFDsStart first passed file descriptor
+	ListenFDsStart = 3
+
+	// UnknownName name of a file descriptor without FileDescriptorName=
+	UnknownName = "unknown"
+)
+
+var (
+	// ErrListenerNotFound no passed file descriptor with the name
+	ErrListenerNotFound = errors.New("systemd: listener not found")
+)
+
+var (
+	filesOnce = &sync.Once{}
+	filesMtx  = &sync.Mutex{}
+	files     []*os.File
+)
+
+// loadFiles collects passed file descriptors once, environment variables are unset
+// and file descriptors are marked close-on-exec, so that child processes don't inherit them
+func loadFiles() {
+	filesOnce.Do(func() {
+		defer os.Unsetenv(EnvListenPID)
+		defer os.Unsetenv(EnvListenFDs)
+		defer os.Unsetenv(EnvListenFDNames)
+		if pid, err := strconv.Atoi(os.Getenv(EnvListenPID)); err != nil || pid != os.Getpid() {
+			return
+		}
+		n, err := strconv.Atoi(os.Getenv(EnvListenFDs))
+		if err != nil || n <= 0 {
+			return
+		}
+		names := strings.Split(os.Getenv(EnvListenFDNames), ":")
+		for i := 0; i < n; i++ {
+			name := UnknownName
+			if i < len(names) && len(names[i]) > 0 {
+				name = names[i]
+			}
+			fd := ListenFDsStart + i
+			closeOnExec(fd)
+			files = append(files, os.NewFile(uintptr(fd), name))
+		}
+	})
+}
+
+// Files returns file descriptors passed by socket activation and not taken yet,
+// File.Name() returns the name from LISTEN_FDNAMES
+func Files() []*os.File {
+	loadFiles()
+	filesMtx.Lock()
+	defer filesMtx.Unlock()
+	return append([]*os.File(nil), files...)
+}
+
+// Activated returns true if any file descriptor is passed by socket activation and not taken yet
+func Activated() bool {
+	return len(Files()) > 0
+}
+
+// takeFile removes and returns the first file with the name, empty name matches any file
+func takeFile(name string) *os.File {
+	loadFiles()
+	filesMtx.Lock()
+	defer filesMtx.Unlock()
+	for i, f := range files {
+		if len(name) == 0 || f.Name() == name {
+			files = append(files[:i], files[i+1:]...)
+			return f
+		}
+	}
+	return nil
+}
+
+// Listener takes the first passed listener with the name, empty name matches any listener,
+// returns ErrListenerNotFound if not found
+func Listener(name string) (l net.Listener, err error) {
+	f := takeFile(name)
+	if f == nil {
+		err = ErrListenerNotFound
+		return
+	}
+	defer f.Close()
+	l, err = net.FileListener(f)
+	return
+}
+
+// Listen takes the passed listener with the name, or creates a new one if not found
+func Listen(name, network, address string) (l net.Listener, err error) {
+	if l, err = Listener(name); err != ErrListenerNotFound {
+		return
+	}
+	return net.Listen(network, address)
+}
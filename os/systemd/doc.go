@@ -0,0 +1,6 @@
+// Package systemd implements socket activation and service notification of systemd.
+//
+// Listeners passed by socket activation are discovered from LISTEN_FDS and LISTEN_FDNAMES,
+// and notifications like READY=1 are sent to NOTIFY_SOCKET, all functions are no-op when
+// the process is not started by systemd.
+package systemd
@@ -0,0 +1,93 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// EnvNotifySocket socket to send notifications
+	EnvNotifySocket = "NOTIFY_SOCKET"
+	// EnvWatchdogUSec watchdog timeout in microseconds
+	EnvWatchdogUSec = "WATCHDOG_USEC"
+	// EnvWatchdogPID pid the watchdog is intended for
+	EnvWatchdogPID = "WATCHDOG_PID"
+)
+
+const (
+	// Ready service startup is finished
+	Ready = "READY=1"
+	// Stopping service is beginning its shutdown
+	Stopping = "STOPPING=1"
+	// Reloading service is reloading its configuration
+	Reloading = "RELOADING=1"
+	// Watchdog keep-alive ping of the watchdog
+	Watchdog = "WATCHDOG=1"
+)
+
+// Status returns a notification of free-form status text
+func Status(s string) string {
+	return "STATUS=" + s
+}
+
+// Notify send notifications to NOTIFY_SOCKET, returns false without error if NOTIFY_SOCKET is not set
+func Notify(states ...string) (sent bool, err error) {
+	name := os.Getenv(EnvNotifySocket)
+	if len(name) == 0 {
+		return
+	}
+	// abstract namespace
+	if name[0] == '@' {
+		name = "\x00" + name[1:]
+	}
+	var c *net.UnixConn
+	if c, err = net.DialUnix("unixgram", nil, &net.UnixAddr{Name: name, Net: "unixgram"}); err != nil {
+		return
+	}
+	defer c.Close()
+	if _, err = c.Write([]byte(strings.Join(states, "\n"))); err != nil {
+		return
+	}
+	sent = true
+	return
+}
+
+// WatchdogInterval returns the watchdog timeout, returns false if watchdog is not enabled for current process
+func WatchdogInterval() (d time.Duration, ok bool) {
+	usec, err := strconv.ParseInt(os.Getenv(EnvWatchdogUSec), 10, 64)
+	if err != nil || usec <= 0 {
+		return
+	}
+	if s := os.Getenv(EnvWatchdogPID); len(s) > 0 {
+		if pid, err := strconv.Atoi(s); err != nil || pid != os.Getpid() {
+			return
+		}
+	}
+	d, ok = time.Duration(usec)*time.Microsecond, true
+	return
+}
+
+// StartWatchdog send watchdog pings at half of the watchdog timeout in a goroutine, until done is closed,
+// returns false if watchdog is not enabled for current process
+func StartWatchdog(done <-chan struct{}) bool {
+	d, ok := WatchdogInterval()
+	if !ok {
+		return false
+	}
+	go func() {
+		t := time.NewTicker(d / 2)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				Notify(Watchdog)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return true
+}
@@ -0,0 +1,161 @@
+// +build !windows
+
+package systemd
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const envHelper = "SYSTEMD_TEST_HELPER"
+
+func fakeNotifySocket(t *testing.T) (c *net.UnixConn, cleanup func()) {
+	dir, err := ioutil.TempDir("", "systemd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(dir, "notify.sock")
+	if c, err = net.ListenUnixgram("unixgram", &net.UnixAddr{Name: name, Net: "unixgram"}); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(EnvNotifySocket, name)
+	cleanup = func() {
+		os.Unsetenv(EnvNotifySocket)
+		c.Close()
+		os.RemoveAll(dir)
+	}
+	return
+}
+
+func readNotification(t *testing.T, c *net.UnixConn) string {
+	buf := make([]byte, 1024)
+	c.SetReadDeadline(time.Now().Add(time.Second * 5))
+	n, err := c.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf[:n])
+}
+
+func TestNotify(t *testing.T) {
+	if sent, err := Notify(Ready); sent || err != nil {
+		t.Fatal("should not send without NOTIFY_SOCKET", sent, err)
+	}
+	c, cleanup := fakeNotifySocket(t)
+	defer cleanup()
+	if sent, err := Notify(Ready, Status("serving")); !sent || err != nil {
+		t.Fatal(sent, err)
+	}
+	if s := readNotification(t, c); s != "READY=1\nSTATUS=serving" {
+		t.Fatal(s)
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	if StartWatchdog(nil) {
+		t.Fatal("watchdog should not be enabled")
+	}
+	c, cleanup := fakeNotifySocket(t)
+	defer cleanup()
+	os.Setenv(EnvWatchdogUSec, "20000")
+	defer os.Unsetenv(EnvWatchdogUSec)
+	os.Setenv(EnvWatchdogPID, "1")
+	if _, ok := WatchdogInterval(); ok {
+		t.Fatal("watchdog of other process should not be enabled")
+	}
+	os.Unsetenv(EnvWatchdogPID)
+	if d, ok := WatchdogInterval(); !ok || d != time.Millisecond*20 {
+		t.Fatal(d, ok)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	if !StartWatchdog(done) {
+		t.Fatal("watchdog should be enabled")
+	}
+	if s := readNotification(t, c); s != Watchdog {
+		t.Fatal(s)
+	}
+}
+
+// TestHelperProcess the process activated by TestListener
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv(envHelper) != "1" {
+		return
+	}
+	if !Activated() {
+		t.Fatal("not activated")
+	}
+	if len(os.Getenv(EnvListenFDs)) > 0 {
+		t.Fatal("environment variables should be unset")
+	}
+	if _, err := Listener("missing"); err != ErrListenerNotFound {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"http", UnknownName} {
+		l, err := Listener(name)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		c, err := l.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Write([]byte(name))
+		c.Close()
+		l.Close()
+	}
+	if Activated() {
+		t.Fatal("all listeners should be taken")
+	}
+}
+
+func TestListener(t *testing.T) {
+	if Activated() {
+		t.Fatal("should not be activated")
+	}
+	var addrs []string
+	var fs []*os.File
+	for i := 0; i < 2; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		f, err := l.(*net.TCPListener).File()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		addrs = append(addrs, l.Addr().String())
+		fs = append(fs, f)
+	}
+	// LISTEN_PID must be the pid of the activated process, sh execs the test binary in place
+	cmd := exec.Command("sh", "-c", `LISTEN_PID=$$ exec "$0" -test.run=TestHelperProcess`, os.Args[0])
+	cmd.Env = append(os.Environ(), envHelper+"=1", EnvListenFDs+"=2", EnvListenFDNames+"=http:")
+	cmd.ExtraFiles = fs
+	out := &strings.Builder{}
+	cmd.Stdout, cmd.Stderr = out, out
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range []string{"http", UnknownName} {
+		c, err := net.Dial("tcp", addrs[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf, _ := ioutil.ReadAll(c)
+		c.Close()
+		if string(buf) != name {
+			t.Fatal(string(buf), "!=", name)
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatal(err, out.String())
+	}
+}
@@ -0,0 +1,435 @@
+package structs
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var errNotPointer = errors.New("structs: destination must be a pointer to struct")
+
+// Decode is the reverse of Map, it fills the struct from the given map. The
+// struct must be passed as a pointer, ie: New(&s). Keys are matched against
+// the same names used by Map, then case insensitively. Keys not found in the
+// struct are ignored, fields without a key are left untouched. Example:
+//
+//   // Field is decoded from key "myName".
+//   Name string `structs:"myName"`
+//
+// Values are weakly typed, numbers, strings and booleans are converted to
+// each other, ie: "42" decodes into an int, 1 into a bool and float64 (as
+// decoded by encoding/json) into any numeric field. Strings are decoded by
+// encoding.TextUnmarshaler if the field implements it, ie: time.Time.
+//
+// A nested map decodes into a nested struct, leaving its missing keys
+// untouched. Nil pointers are allocated on demand. A nil value sets the field
+// to its zero value.
+//
+// A tag value with the option of "flatten" decodes the nested struct from the
+// same map. A tag value with the option of "omitnested" assigns the value as
+// is, it must be assignable to the field.
+func (s *Struct) Decode(m map[string]interface{}) error {
+	if !s.value.CanSet() {
+		return errNotPointer
+	}
+	d := &decoder{tagName: s.TagName}
+	return d.decodeStruct(s.value, m, "")
+}
+
+// Decode fills the struct s from the map m. For more info refer to Struct
+// types Decode() method. It panics if s's kind is not struct.
+func Decode(m map[string]interface{}, s interface{}) error {
+	return New(s).Decode(m)
+}
+
+// DecodeError is returned when a value can not be decoded into a field.
+type DecodeError struct {
+	// Path dotted path of the field, ie: "Address.City"
+	Path  string
+	Value interface{}
+	Type  reflect.Type
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	msg := fmt.Sprintf("structs: cannot decode %T into %s at %q", e.Value, e.Type, e.Path)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+type decoder struct {
+	tagName string
+	// merge merges maps instead of replacing them, nil values delete keys
+	merge bool
+}
+
+func joinPath(path, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+	return path + "." + name
+}
+
+// lookup finds the key exactly, then case insensitively
+func lookup(m map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := m[name]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func (d *decoder) decodeStruct(v reflect.Value, m map[string]interface{}, path string) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		// we can't set unexported fields
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tagName, tagOpts := parseTag(field.Tag.Get(d.tagName))
+		if tagName == "-" {
+			continue
+		}
+
+		name := field.Name
+		if tagName != "" {
+			name = tagName
+		}
+
+		fv := v.Field(i)
+
+		if tagOpts.Has("flatten") && isStructType(field.Type) {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					fv.Set(reflect.New(field.Type.Elem()))
+				}
+				fv = fv.Elem()
+			}
+			if err := d.decodeStruct(fv, m, path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, ok := lookup(m, name)
+		if !ok {
+			continue
+		}
+
+		if err := d.decodeValue(fv, raw, joinPath(path, name), tagOpts.Has("omitnested")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isStructType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+func (d *decoder) decodeValue(v reflect.Value, raw interface{}, path string, omitnested bool) error {
+	if raw == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	rv := reflect.ValueOf(raw)
+
+	if v.Kind() == reflect.Ptr {
+		if rv.Type().AssignableTo(v.Type()) {
+			v.Set(rv)
+			return nil
+		}
+		// decode into a copy, the old value may be shared
+		n := reflect.New(v.Type().Elem())
+		if !v.IsNil() {
+			n.Elem().Set(v.Elem())
+		}
+		if err := d.decodeValue(n.Elem(), raw, path, omitnested); err != nil {
+			return err
+		}
+		v.Set(n)
+		return nil
+	}
+
+	fail := func(err error) error {
+		return &DecodeError{Path: path, Value: raw, Type: v.Type(), Err: err}
+	}
+
+	if omitnested {
+		if !rv.Type().AssignableTo(v.Type()) {
+			return fail(nil)
+		}
+		v.Set(rv)
+		return nil
+	}
+
+	if s, ok := raw.(string); ok && v.CanAddr() {
+		if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			if err := u.UnmarshalText([]byte(s)); err != nil {
+				return fail(err)
+			}
+			return nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if m, ok := stringMap(rv); ok {
+			return d.decodeStruct(v, m, path)
+		}
+	case reflect.Map:
+		if rv.Kind() == reflect.Map {
+			return d.decodeMap(v, rv, path)
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 && rv.Kind() == reflect.String {
+			v.SetBytes([]byte(rv.String()))
+			return nil
+		}
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			n := reflect.MakeSlice(v.Type(), rv.Len(), rv.Len())
+			for i := 0; i < rv.Len(); i++ {
+				if err := d.decodeValue(n.Index(i), rv.Index(i).Interface(), path+"["+strconv.Itoa(i)+"]", false); err != nil {
+					return err
+				}
+			}
+			v.Set(n)
+			return nil
+		}
+	case reflect.Array:
+		if (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && rv.Len() <= v.Len() {
+			n := reflect.New(v.Type()).Elem()
+			for i := 0; i < rv.Len(); i++ {
+				if err := d.decodeValue(n.Index(i), rv.Index(i).Interface(), path+"["+strconv.Itoa(i)+"]", false); err != nil {
+					return err
+				}
+			}
+			v.Set(n)
+			return nil
+		}
+	case reflect.String:
+		if s, ok := weakString(rv); ok {
+			v.SetString(s)
+			return nil
+		}
+	case reflect.Bool:
+		b, err := weakBool(rv)
+		if err != nil {
+			return fail(err)
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := weakInt(rv)
+		if err == nil && v.OverflowInt(i) {
+			err = errors.New("overflow")
+		}
+		if err != nil {
+			return fail(err)
+		}
+		v.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := weakUint(rv)
+		if err == nil && v.OverflowUint(u) {
+			err = errors.New("overflow")
+		}
+		if err != nil {
+			return fail(err)
+		}
+		v.SetUint(u)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := weakFloat(rv)
+		if err != nil {
+			return fail(err)
+		}
+		v.SetFloat(f)
+		return nil
+	}
+
+	if rv.Type().AssignableTo(v.Type()) {
+		v.Set(rv)
+		return nil
+	}
+	if rv.Type().ConvertibleTo(v.Type()) && rv.Kind() == v.Kind() {
+		v.Set(rv.Convert(v.Type()))
+		return nil
+	}
+
+	return fail(nil)
+}
+
+func (d *decoder) decodeMap(v reflect.Value, rv reflect.Value, path string) error {
+	t := v.Type()
+	n := reflect.MakeMapWithSize(t, rv.Len())
+	// copy the old map instead of modifying it, it may be shared
+	if d.merge && !v.IsNil() {
+		for _, k := range v.MapKeys() {
+			n.SetMapIndex(k, v.MapIndex(k))
+		}
+	}
+
+	for _, rk := range rv.MapKeys() {
+		k := reflect.New(t.Key()).Elem()
+		if err := d.decodeValue(k, rk.Interface(), path, false); err != nil {
+			return err
+		}
+		raw := rv.MapIndex(rk).Interface()
+		if d.merge && raw == nil {
+			n.SetMapIndex(k, reflect.Value{})
+			continue
+		}
+		e := reflect.New(t.Elem()).Elem()
+		if old := n.MapIndex(k); d.merge && old.IsValid() {
+			e.Set(old)
+		}
+		if err := d.decodeValue(e, raw, joinPath(path, fmt.Sprint(rk.Interface())), false); err != nil {
+			return err
+		}
+		n.SetMapIndex(k, e)
+	}
+
+	v.Set(n)
+	return nil
+}
+
+// stringMap converts a map with string keys to map[string]interface{}
+func stringMap(rv reflect.Value) (map[string]interface{}, bool) {
+	if m, ok := rv.Interface().(map[string]interface{}); ok {
+		return m, true
+	}
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	m := make(map[string]interface{}, rv.Len())
+	for _, k := range rv.MapKeys() {
+		m[k.String()] = rv.MapIndex(k).Interface()
+	}
+	return m, true
+}
+
+func weakString(rv reflect.Value) (string, bool) {
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), true
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 32), true
+	case reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), true
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return string(rv.Bytes()), true
+		}
+	}
+	return "", false
+}
+
+func weakBool(rv reflect.Value) (bool, error) {
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() != 0, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() != 0, nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() != 0, nil
+	case reflect.String:
+		s := strings.TrimSpace(rv.String())
+		if len(s) == 0 {
+			return false, nil
+		}
+		return strconv.ParseBool(s)
+	}
+	return false, errors.New("not a bool")
+}
+
+func weakInt(rv reflect.Value) (int64, error) {
+	switch rv.Kind() {
+	case reflect.Bool:
+		if rv.Bool() {
+			return 1, nil
+		}
+		return 0, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		if int64(u) < 0 {
+			return 0, errors.New("overflow")
+		}
+		return int64(u), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != float64(int64(f)) {
+			return 0, errors.New("not an integer")
+		}
+		return int64(f), nil
+	case reflect.String:
+		s := strings.TrimSpace(rv.String())
+		if len(s) == 0 {
+			return 0, nil
+		}
+		return strconv.ParseInt(s, 0, 64)
+	}
+	return 0, errors.New("not a number")
+}
+
+func weakUint(rv reflect.Value) (uint64, error) {
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint(), nil
+	case reflect.String:
+		s := strings.TrimSpace(rv.String())
+		if len(s) == 0 {
+			return 0, nil
+		}
+		return strconv.ParseUint(s, 0, 64)
+	}
+	i, err := weakInt(rv)
+	if err == nil && i < 0 {
+		err = errors.New("negative")
+	}
+	return uint64(i), err
+}
+
+func weakFloat(rv reflect.Value) (float64, error) {
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), nil
+	case reflect.String:
+		s := strings.TrimSpace(rv.String())
+		if len(s) == 0 {
+			return 0, nil
+		}
+		return strconv.ParseFloat(s, 64)
+	}
+	i, err := weakInt(rv)
+	return float64(i), err
+}
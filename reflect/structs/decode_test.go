@@ -0,0 +1,115 @@
+package structs
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	type Address struct {
+		City string `structs:"city"`
+		Zip  int    `structs:"zip"`
+	}
+	type Base struct {
+		ID uint64
+	}
+	type User struct {
+		Base    `structs:",flatten"`
+		Name    string `structs:"name"`
+		Age     int
+		Admin   bool
+		Score   float64
+		Tags    []string
+		Address Address  `structs:"address"`
+		Home    *Address `structs:"home"`
+		Meta    map[string]int
+		Created time.Time
+		Raw     interface{} `structs:",omitnested"`
+		secret  string
+	}
+
+	var m map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"ID": "7",
+		"name": 42,
+		"age": 30,
+		"Admin": "true",
+		"Score": "1.5",
+		"Tags": ["a", 1],
+		"address": {"city": "Paris", "zip": "75001"},
+		"home": {"city": "Lyon"},
+		"Meta": {"x": 1.0},
+		"Created": "2020-01-02T03:04:05Z",
+		"Raw": {"k": "v"},
+		"secret": "s",
+		"unknown": 1
+	}`), &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := User{secret: "keep"}
+	if err := Decode(m, &u); err != nil {
+		t.Fatal(err)
+	}
+
+	created, _ := time.Parse(time.RFC3339, "2020-01-02T03:04:05Z")
+	expected := User{
+		Base:    Base{ID: 7},
+		Name:    "42",
+		Age:     30,
+		Admin:   true,
+		Score:   1.5,
+		Tags:    []string{"a", "1"},
+		Address: Address{City: "Paris", Zip: 75001},
+		Home:    &Address{City: "Lyon"},
+		Meta:    map[string]int{"x": 1},
+		Created: created,
+		Raw:     map[string]interface{}{"k": "v"},
+		secret:  "keep",
+	}
+	if !reflect.DeepEqual(u, expected) {
+		t.Errorf("Decode should give %+v, got %+v", expected, u)
+	}
+
+	// missing keys are left untouched, nil sets zero value
+	if err := Decode(map[string]interface{}{"address": map[string]interface{}{"zip": 1}, "home": nil}, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Address.City != "Paris" || u.Address.Zip != 1 || u.Home != nil || u.Name != "42" {
+		t.Errorf("Decode should merge nested structs, got %+v", u)
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	type A struct {
+		Age   int8
+		Count uint
+		Tags  []int
+	}
+
+	if err := Decode(map[string]interface{}{}, A{}); err != errNotPointer {
+		t.Errorf("Decode into a non pointer should fail, got %v", err)
+	}
+
+	for _, m := range []map[string]interface{}{
+		{"Age": 1000},
+		{"Age": 1.5},
+		{"Age": "abc"},
+		{"Count": -1},
+		{"Tags": []interface{}{1, "x"}},
+		{"Tags": map[string]interface{}{}},
+	} {
+		err := Decode(m, &A{})
+		if _, ok := err.(*DecodeError); !ok {
+			t.Errorf("Decode %v should fail with DecodeError, got %v", m, err)
+		}
+	}
+
+	err := Decode(map[string]interface{}{"Tags": []interface{}{1, "x"}}, &A{})
+	if de, ok := err.(*DecodeError); !ok || de.Path != "Tags[1]" {
+		t.Errorf("DecodeError should have path Tags[1], got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package structs
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Diff returns the paths of fields which differ between the struct s and
+// other, in sorted order. Fields are compared by their Map outputs, so the
+// same tag options apply, and paths are the map keys joined by dots, ie:
+// "Name" or "Address.City". Nested structs are compared field by field unless
+// the field is marked as "omitnested". It panics if either kind is not struct.
+func (s *Struct) Diff(other interface{}) []string {
+	o := New(other)
+	o.TagName = s.TagName
+
+	var paths []string
+	diffMaps(s.Map(), o.Map(), "", &paths)
+	sort.Strings(paths)
+	return paths
+}
+
+// Diff returns the paths of fields which differ between a and b. For more
+// info refer to Struct types Diff() method. It panics if either kind is not
+// struct.
+func Diff(a, b interface{}) []string {
+	return New(a).Diff(b)
+}
+
+func diffMaps(a, b map[string]interface{}, path string, paths *[]string) {
+	for k, av := range a {
+		bv, ok := b[k]
+		if !ok {
+			*paths = append(*paths, joinPath(path, k))
+			continue
+		}
+		am, aok := av.(map[string]interface{})
+		bm, bok := bv.(map[string]interface{})
+		if aok && bok {
+			diffMaps(am, bm, joinPath(path, k), paths)
+			continue
+		}
+		if !reflect.DeepEqual(av, bv) {
+			*paths = append(*paths, joinPath(path, k))
+		}
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			*paths = append(*paths, joinPath(path, k))
+		}
+	}
+}
+
+// Apply updates the struct with the patch in the manner of JSON Merge Patch
+// (RFC 7386), and returns the paths of changed fields as Diff does. The
+// struct must be passed as a pointer, ie: New(&s).
+//
+// Keys and values are decoded as Decode does, except that a nested map is
+// merged into a map field, where a nil value deletes the key. A nil value of
+// a field sets it to its zero value.
+//
+// For flat structs the changed paths are the changed columns, which is handy
+// for partial updates, ie:
+//
+//   changed, err := structs.Apply(&user, patch)
+//   db.Model(&user).Select(changed).Updates(&user)
+func (s *Struct) Apply(patch map[string]interface{}) (changed []string, err error) {
+	if !s.value.CanSet() {
+		err = errNotPointer
+		return
+	}
+	before := s.Map()
+	d := &decoder{tagName: s.TagName, merge: true}
+	if err = d.decodeStruct(s.value, patch, ""); err != nil {
+		return
+	}
+	diffMaps(before, s.Map(), "", &changed)
+	sort.Strings(changed)
+	return
+}
+
+// Apply updates the struct s with the patch. For more info refer to Struct
+// types Apply() method. It panics if s's kind is not struct.
+func Apply(s interface{}, patch map[string]interface{}) ([]string, error) {
+	return New(s).Apply(patch)
+}
@@ -0,0 +1,88 @@
+package structs
+
+import (
+	"reflect"
+	"testing"
+)
+
+type diffAddress struct {
+	City string `structs:"city"`
+	Zip  int    `structs:"zip"`
+}
+
+type diffUser struct {
+	Name    string `structs:"name"`
+	Email   string `structs:"email,omitempty"`
+	Address diffAddress
+	Home    diffAddress `structs:",omitnested"`
+	Meta    map[string]int
+	Tags    []string
+}
+
+func TestDiff(t *testing.T) {
+	a := diffUser{
+		Name:    "a",
+		Address: diffAddress{City: "Paris"},
+		Home:    diffAddress{City: "Lyon"},
+		Meta:    map[string]int{"x": 1},
+	}
+	b := a
+	b.Email = "b@example.com"
+	b.Address.Zip = 1
+	b.Home.Zip = 1
+	b.Tags = []string{"t"}
+
+	expected := []string{"Address.zip", "Home", "Tags", "email"}
+	if d := Diff(a, &b); !reflect.DeepEqual(d, expected) {
+		t.Errorf("Diff should give %v, got %v", expected, d)
+	}
+
+	if d := Diff(a, a); len(d) != 0 {
+		t.Errorf("Diff of equal structs should be empty, got %v", d)
+	}
+}
+
+func TestApply(t *testing.T) {
+	u := diffUser{
+		Name:    "a",
+		Email:   "a@example.com",
+		Address: diffAddress{City: "Paris", Zip: 1},
+		Meta:    map[string]int{"x": 1, "y": 2},
+		Tags:    []string{"t"},
+	}
+	meta := u.Meta
+
+	changed, err := Apply(&u, map[string]interface{}{
+		"name":    "a",
+		"email":   nil,
+		"Address": map[string]interface{}{"zip": 2},
+		"Meta":    map[string]interface{}{"x": nil, "z": 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := diffUser{
+		Name:    "a",
+		Address: diffAddress{City: "Paris", Zip: 2},
+		Meta:    map[string]int{"y": 2, "z": 3},
+		Tags:    []string{"t"},
+	}
+	if !reflect.DeepEqual(u, expected) {
+		t.Errorf("Apply should give %+v, got %+v", expected, u)
+	}
+
+	expectedChanged := []string{"Address.zip", "Meta", "email"}
+	if !reflect.DeepEqual(changed, expectedChanged) {
+		t.Errorf("Apply should change %v, got %v", expectedChanged, changed)
+	}
+
+	// the old map is not modified
+	if len(meta) != 2 || meta["x"] != 1 {
+		t.Errorf("Apply should not modify the old map, got %v", meta)
+	}
+
+	if _, err := Apply(u, nil); err != errNotPointer {
+		t.Errorf("Apply to a non pointer should fail, got %v", err)
+	}
+}
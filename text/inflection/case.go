@@ -0,0 +1,95 @@
+package inflection
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Words splits an identifier or phrase into words, at non letters or digits and
+// case changes, acronyms are kept together, like "HTTPServer" => "HTTP", "Server"
+func Words(str string) []string {
+	var words []string
+	rs := []rune(str)
+	start := -1
+	for i, r := range rs {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, string(rs[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		prev := rs[i-1]
+		// "userID" => "user", "ID"
+		lowerUpper := unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev))
+		// "HTTPServer" => "HTTP", "Server"
+		acronymEnd := unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(rs) && unicode.IsLower(rs[i+1])
+		if lowerUpper || acronymEnd {
+			words = append(words, string(rs[start:i]))
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(rs[start:]))
+	}
+	return words
+}
+
+func upperFirst(word string) string {
+	r, l := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(r)) + strings.ToLower(word[l:])
+}
+
+// Camel converts to upper camel case, like "user_id" => "UserId"
+func Camel(str string) string {
+	var buf strings.Builder
+	for _, w := range Words(str) {
+		buf.WriteString(upperFirst(w))
+	}
+	return buf.String()
+}
+
+// LowerCamel converts to lower camel case, like "user_id" => "userId"
+func LowerCamel(str string) string {
+	var buf strings.Builder
+	for i, w := range Words(str) {
+		if i == 0 {
+			buf.WriteString(strings.ToLower(w))
+		} else {
+			buf.WriteString(upperFirst(w))
+		}
+	}
+	return buf.String()
+}
+
+func join(str string, sep string) string {
+	words := Words(str)
+	for i, w := range words {
+		words[i] = strings.ToLower(w)
+	}
+	return strings.Join(words, sep)
+}
+
+// Snake converts to snake case, like "HTTPServer" => "http_server"
+func Snake(str string) string {
+	return join(str, "_")
+}
+
+// Kebab converts to kebab case, like "HTTPServer" => "http-server"
+func Kebab(str string) string {
+	return join(str, "-")
+}
+
+// Title converts to title case, like "user_name" => "User Name"
+func Title(str string) string {
+	words := Words(str)
+	for i, w := range words {
+		words[i] = upperFirst(w)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,44 @@
+package inflection
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	for in, out := range map[string][]string{
+		"HTTPServer":      {"HTTP", "Server"},
+		"userID":          {"user", "ID"},
+		"user_id":         {"user", "id"},
+		"  hello-world  ": {"hello", "world"},
+		"version2Beta":    {"version2", "Beta"},
+		"ÉcoleNormale":    {"École", "Normale"},
+		"":                nil,
+	} {
+		if v := Words(in); !reflect.DeepEqual(v, out) {
+			t.Errorf("%q should be split into %q, but got %q", in, out, v)
+		}
+	}
+}
+
+func TestCase(t *testing.T) {
+	for _, c := range []struct {
+		fn  func(string) string
+		in  string
+		out string
+	}{
+		{Camel, "user_id", "UserId"},
+		{Camel, "http server", "HttpServer"},
+		{LowerCamel, "UserID", "userId"},
+		{LowerCamel, "HTTP-server", "httpServer"},
+		{Snake, "HTTPServer", "http_server"},
+		{Snake, "userID", "user_id"},
+		{Kebab, "UserName", "user-name"},
+		{Title, "user_name", "User Name"},
+		{Title, "hello WORLD", "Hello World"},
+	} {
+		if v := c.fn(c.in); v != c.out {
+			t.Errorf("%q should be converted to %q, but got %q", c.in, c.out, v)
+		}
+	}
+}
@@ -1,31 +1,45 @@
 /*
-Package inflection pluralizes and singularizes English nouns.
+Package inflection pluralizes and singularizes nouns, English by default.
 
-		inflection.Plural("person") => "people"
-		inflection.Plural("Person") => "People"
-		inflection.Plural("PERSON") => "PEOPLE"
+	inflection.Plural("person") => "people"
+	inflection.Plural("Person") => "People"
+	inflection.Plural("PERSON") => "PEOPLE"
 
-		inflection.Singular("people") => "person"
-		inflection.Singular("People") => "Person"
-		inflection.Singular("PEOPLE") => "PERSON"
+	inflection.Singular("people") => "person"
+	inflection.Singular("People") => "Person"
+	inflection.Singular("PEOPLE") => "PERSON"
 
-		inflection.Plural("FancyPerson") => "FancydPeople"
-		inflection.Singular("FancyPeople") => "FancydPerson"
+	inflection.Plural("FancyPerson") => "FancydPeople"
+	inflection.Singular("FancyPeople") => "FancydPerson"
 
 Standard rules are from Rails's ActiveSupport (https://github.com/rails/rails/blob/master/activesupport/lib/active_support/inflections.rb)
 
 If you want to register more rules, follow:
 
-		inflection.AddUncountable("fish")
-		inflection.AddIrregular("person", "people")
-		inflection.AddPlural("(bu)s$", "${1}ses") # "bus" => "buses" / "BUS" => "BUSES" / "Bus" => "Buses"
-		inflection.AddSingular("(bus)(es)?$", "${1}") # "buses" => "bus" / "Buses" => "Bus" / "BUSES" => "BUS"
+	inflection.AddUncountable("fish")
+	inflection.AddIrregular("person", "people")
+	inflection.AddPlural("(bu)s$", "${1}ses") # "bus" => "buses" / "BUS" => "BUSES" / "Bus" => "Buses"
+	inflection.AddSingular("(bus)(es)?$", "${1}") # "buses" => "bus" / "Buses" => "Bus" / "BUSES" => "BUS"
+
+Package level functions use the Default inflector, which is shared by the process. For rules of your own,
+or of other languages, create an Inflector:
+
+	in := inflection.New("es")
+	in.Plural("canción") => "canciones"
+	in.Ordinalize(1) => "1º"
+
+Case conversion functions are language neutral:
+
+	inflection.Snake("HTTPServer") => "http_server"
+	inflection.Camel("user_id") => "UserId"
 */
 package inflection
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 type inflection struct {
@@ -118,143 +132,209 @@ var irregularInflections = IrregularSlice{
 
 var uncountableInflections = []string{"equipment", "information", "rice", "money", "species", "series", "fish", "sheep", "jeans", "police"}
 
-var compiledPluralMaps []inflection
-var compiledSingularMaps []inflection
+// Inflector inflects words with its own rule set, it is safe for concurrent use
+type Inflector struct {
+	lang    string
+	ordinal func(n int) string
+
+	mtx              *sync.RWMutex
+	plurals          RegularSlice
+	singulars        RegularSlice
+	irregulars       IrregularSlice
+	uncountables     []string
+	compiledPlural   []inflection
+	compiledSingular []inflection
+}
+
+// Default the default English inflector, used by package level functions
+var Default = New("en")
+
+// New create a new Inflector with the bundled rules of language, like "en", "es"
+// or "pt-BR", an unknown language gets an Inflector without rules, see Languages
+func New(lang string) *Inflector {
+	i := &Inflector{mtx: &sync.RWMutex{}}
+	if l, ok := lookupLanguage(lang); ok {
+		i.lang = l.lang
+		i.ordinal = l.ordinal
+		i.plurals = append(RegularSlice{}, l.plurals...)
+		i.singulars = append(RegularSlice{}, l.singulars...)
+		i.irregulars = append(IrregularSlice{}, l.irregulars...)
+		i.uncountables = append([]string{}, l.uncountables...)
+	}
+	i.compile()
+	return i
+}
+
+// Clone returns a copy of the Inflector, changes of rules do not affect each other
+func (i *Inflector) Clone() *Inflector {
+	i.mtx.RLock()
+	defer i.mtx.RUnlock()
+	c := &Inflector{
+		lang:         i.lang,
+		ordinal:      i.ordinal,
+		mtx:          &sync.RWMutex{},
+		plurals:      append(RegularSlice{}, i.plurals...),
+		singulars:    append(RegularSlice{}, i.singulars...),
+		irregulars:   append(IrregularSlice{}, i.irregulars...),
+		uncountables: append([]string{}, i.uncountables...),
+	}
+	c.compile()
+	return c
+}
+
+// Language returns the language of bundled rules, empty if none
+func (i *Inflector) Language() string {
+	return i.lang
+}
 
-func compile() {
-	compiledPluralMaps = []inflection{}
-	compiledSingularMaps = []inflection{}
-	for _, uncountable := range uncountableInflections {
+func (i *Inflector) compile() {
+	i.compiledPlural = []inflection{}
+	i.compiledSingular = []inflection{}
+	for _, uncountable := range i.uncountables {
 		inf := inflection{
 			regexp:  regexp.MustCompile("^(?i)(" + uncountable + ")$"),
 			replace: "${1}",
 		}
-		compiledPluralMaps = append(compiledPluralMaps, inf)
-		compiledSingularMaps = append(compiledSingularMaps, inf)
+		i.compiledPlural = append(i.compiledPlural, inf)
+		i.compiledSingular = append(i.compiledSingular, inf)
 	}
 
-	for _, value := range irregularInflections {
+	for _, value := range i.irregulars {
 		infs := []inflection{
 			{regexp: regexp.MustCompile(strings.ToUpper(value.singular) + "$"), replace: strings.ToUpper(value.plural)},
 			{regexp: regexp.MustCompile(strings.Title(value.singular) + "$"), replace: strings.Title(value.plural)},
 			{regexp: regexp.MustCompile(value.singular + "$"), replace: value.plural},
 		}
-		compiledPluralMaps = append(compiledPluralMaps, infs...)
+		i.compiledPlural = append(i.compiledPlural, infs...)
 	}
 
-	for _, value := range irregularInflections {
+	for _, value := range i.irregulars {
 		infs := []inflection{
 			{regexp: regexp.MustCompile(strings.ToUpper(value.plural) + "$"), replace: strings.ToUpper(value.singular)},
 			{regexp: regexp.MustCompile(strings.Title(value.plural) + "$"), replace: strings.Title(value.singular)},
 			{regexp: regexp.MustCompile(value.plural + "$"), replace: value.singular},
 		}
-		compiledSingularMaps = append(compiledSingularMaps, infs...)
+		i.compiledSingular = append(i.compiledSingular, infs...)
 	}
 
-	for i := len(pluralInflections) - 1; i >= 0; i-- {
-		value := pluralInflections[i]
+	for j := len(i.plurals) - 1; j >= 0; j-- {
+		value := i.plurals[j]
 		infs := []inflection{
 			{regexp: regexp.MustCompile(strings.ToUpper(value.find)), replace: strings.ToUpper(value.replace)},
 			{regexp: regexp.MustCompile(value.find), replace: value.replace},
 			{regexp: regexp.MustCompile("(?i)" + value.find), replace: value.replace},
 		}
-		compiledPluralMaps = append(compiledPluralMaps, infs...)
+		i.compiledPlural = append(i.compiledPlural, infs...)
 	}
 
-	for i := len(singularInflections) - 1; i >= 0; i-- {
-		value := singularInflections[i]
+	for j := len(i.singulars) - 1; j >= 0; j-- {
+		value := i.singulars[j]
 		infs := []inflection{
 			{regexp: regexp.MustCompile(strings.ToUpper(value.find)), replace: strings.ToUpper(value.replace)},
 			{regexp: regexp.MustCompile(value.find), replace: value.replace},
 			{regexp: regexp.MustCompile("(?i)" + value.find), replace: value.replace},
 		}
-		compiledSingularMaps = append(compiledSingularMaps, infs...)
+		i.compiledSingular = append(i.compiledSingular, infs...)
 	}
 }
 
-func init() {
-	compile()
-}
-
 // AddPlural adds a plural inflection
-func AddPlural(find, replace string) {
-	pluralInflections = append(pluralInflections, Regular{find, replace})
-	compile()
+func (i *Inflector) AddPlural(find, replace string) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	i.plurals = append(i.plurals, Regular{find, replace})
+	i.compile()
 }
 
 // AddSingular adds a singular inflection
-func AddSingular(find, replace string) {
-	singularInflections = append(singularInflections, Regular{find, replace})
-	compile()
+func (i *Inflector) AddSingular(find, replace string) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	i.singulars = append(i.singulars, Regular{find, replace})
+	i.compile()
 }
 
 // AddIrregular adds an irregular inflection
-func AddIrregular(singular, plural string) {
-	irregularInflections = append(irregularInflections, Irregular{singular, plural})
-	compile()
+func (i *Inflector) AddIrregular(singular, plural string) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	i.irregulars = append(i.irregulars, Irregular{singular, plural})
+	i.compile()
 }
 
 // AddUncountable adds an uncountable inflection
-func AddUncountable(values ...string) {
-	uncountableInflections = append(uncountableInflections, values...)
-	compile()
+func (i *Inflector) AddUncountable(values ...string) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	i.uncountables = append(i.uncountables, values...)
+	i.compile()
 }
 
 // GetPlural retrieves the plural inflection values
-func GetPlural() RegularSlice {
-	plurals := make(RegularSlice, len(pluralInflections))
-	copy(plurals, pluralInflections)
-	return plurals
+func (i *Inflector) GetPlural() RegularSlice {
+	i.mtx.RLock()
+	defer i.mtx.RUnlock()
+	return append(RegularSlice{}, i.plurals...)
 }
 
 // GetSingular retrieves the singular inflection values
-func GetSingular() RegularSlice {
-	singulars := make(RegularSlice, len(singularInflections))
-	copy(singulars, singularInflections)
-	return singulars
+func (i *Inflector) GetSingular() RegularSlice {
+	i.mtx.RLock()
+	defer i.mtx.RUnlock()
+	return append(RegularSlice{}, i.singulars...)
 }
 
 // GetIrregular retrieves the irregular inflection values
-func GetIrregular() IrregularSlice {
-	irregular := make(IrregularSlice, len(irregularInflections))
-	copy(irregular, irregularInflections)
-	return irregular
+func (i *Inflector) GetIrregular() IrregularSlice {
+	i.mtx.RLock()
+	defer i.mtx.RUnlock()
+	return append(IrregularSlice{}, i.irregulars...)
 }
 
 // GetUncountable retrieves the uncountable inflection values
-func GetUncountable() []string {
-	uncountables := make([]string, len(uncountableInflections))
-	copy(uncountables, uncountableInflections)
-	return uncountables
+func (i *Inflector) GetUncountable() []string {
+	i.mtx.RLock()
+	defer i.mtx.RUnlock()
+	return append([]string{}, i.uncountables...)
 }
 
 // SetPlural sets the plural inflections slice
-func SetPlural(inflections RegularSlice) {
-	pluralInflections = inflections
-	compile()
+func (i *Inflector) SetPlural(inflections RegularSlice) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	i.plurals = inflections
+	i.compile()
 }
 
 // SetSingular sets the singular inflections slice
-func SetSingular(inflections RegularSlice) {
-	singularInflections = inflections
-	compile()
+func (i *Inflector) SetSingular(inflections RegularSlice) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	i.singulars = inflections
+	i.compile()
 }
 
 // SetIrregular sets the irregular inflections slice
-func SetIrregular(inflections IrregularSlice) {
-	irregularInflections = inflections
-	compile()
+func (i *Inflector) SetIrregular(inflections IrregularSlice) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	i.irregulars = inflections
+	i.compile()
 }
 
 // SetUncountable sets the uncountable inflections slice
-func SetUncountable(inflections []string) {
-	uncountableInflections = inflections
-	compile()
+func (i *Inflector) SetUncountable(inflections []string) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	i.uncountables = inflections
+	i.compile()
 }
 
 // Plural converts a word to its plural form
-func Plural(str string) string {
-	for _, inflection := range compiledPluralMaps {
+func (i *Inflector) Plural(str string) string {
+	i.mtx.RLock()
+	defer i.mtx.RUnlock()
+	for _, inflection := range i.compiledPlural {
 		if inflection.regexp.MatchString(str) {
 			return inflection.regexp.ReplaceAllString(str, inflection.replace)
 		}
@@ -263,11 +343,101 @@ func Plural(str string) string {
 }
 
 // Singular converts a word to its singular form
-func Singular(str string) string {
-	for _, inflection := range compiledSingularMaps {
+func (i *Inflector) Singular(str string) string {
+	i.mtx.RLock()
+	defer i.mtx.RUnlock()
+	for _, inflection := range i.compiledSingular {
 		if inflection.regexp.MatchString(str) {
 			return inflection.regexp.ReplaceAllString(str, inflection.replace)
 		}
 	}
 	return str
 }
+
+// Ordinal returns the suffix of the ordinal number, like "nd" of "2nd" in English
+func (i *Inflector) Ordinal(n int) string {
+	if i.ordinal == nil {
+		return ""
+	}
+	return i.ordinal(n)
+}
+
+// Ordinalize returns the ordinal number, like "2nd" in English
+func (i *Inflector) Ordinalize(n int) string {
+	return strconv.Itoa(n) + i.Ordinal(n)
+}
+
+// AddPlural adds a plural inflection to Default
+func AddPlural(find, replace string) {
+	Default.AddPlural(find, replace)
+}
+
+// AddSingular adds a singular inflection to Default
+func AddSingular(find, replace string) {
+	Default.AddSingular(find, replace)
+}
+
+// AddIrregular adds an irregular inflection to Default
+func AddIrregular(singular, plural string) {
+	Default.AddIrregular(singular, plural)
+}
+
+// AddUncountable adds an uncountable inflection to Default
+func AddUncountable(values ...string) {
+	Default.AddUncountable(values...)
+}
+
+// GetPlural retrieves the plural inflection values of Default
+func GetPlural() RegularSlice {
+	return Default.GetPlural()
+}
+
+// GetSingular retrieves the singular inflection values of Default
+func GetSingular() RegularSlice {
+	return Default.GetSingular()
+}
+
+// GetIrregular retrieves the irregular inflection values of Default
+func GetIrregular() IrregularSlice {
+	return Default.GetIrregular()
+}
+
+// GetUncountable retrieves the uncountable inflection values of Default
+func GetUncountable() []string {
+	return Default.GetUncountable()
+}
+
+// SetPlural sets the plural inflections slice of Default
+func SetPlural(inflections RegularSlice) {
+	Default.SetPlural(inflections)
+}
+
+// SetSingular sets the singular inflections slice of Default
+func SetSingular(inflections RegularSlice) {
+	Default.SetSingular(inflections)
+}
+
+// SetIrregular sets the irregular inflections slice of Default
+func SetIrregular(inflections IrregularSlice) {
+	Default.SetIrregular(inflections)
+}
+
+// SetUncountable sets the uncountable inflections slice of Default
+func SetUncountable(inflections []string) {
+	Default.SetUncountable(inflections)
+}
+
+// Plural converts a word to its plural form by Default
+func Plural(str string) string {
+	return Default.Plural(str)
+}
+
+// Singular converts a word to its singular form by Default
+func Singular(str string) string {
+	return Default.Singular(str)
+}
+
+// Ordinalize returns the ordinal number by Default, like "2nd"
+func Ordinalize(n int) string {
+	return Default.Ordinalize(n)
+}
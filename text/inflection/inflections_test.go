@@ -58,30 +58,17 @@ var inflections = map[string]string{
 	"criterion":   "criteria",
 }
 
-// storage is used to restore the state of the global variables
+// backup is used to restore the state of the Default inflector
 // on each test execution, to ensure no global state pollution
-type storage struct {
-	singulars    RegularSlice
-	plurals      RegularSlice
-	irregulars   IrregularSlice
-	uncountables []string
-}
-
-var backup = storage{}
+var backup *Inflector
 
 func init() {
 	AddIrregular("criterion", "criteria")
-	copy(backup.singulars, singularInflections)
-	copy(backup.plurals, pluralInflections)
-	copy(backup.irregulars, irregularInflections)
-	copy(backup.uncountables, uncountableInflections)
+	backup = Default.Clone()
 }
 
 func restore() {
-	copy(singularInflections, backup.singulars)
-	copy(pluralInflections, backup.plurals)
-	copy(irregularInflections, backup.irregulars)
-	copy(uncountableInflections, backup.uncountables)
+	Default = backup.Clone()
 }
 
 func TestPlural(t *testing.T) {
@@ -118,96 +105,181 @@ func TestSingular(t *testing.T) {
 
 func TestAddPlural(t *testing.T) {
 	defer restore()
-	ln := len(pluralInflections)
+	ln := len(Default.plurals)
 	AddPlural("", "")
-	if ln+1 != len(pluralInflections) {
-		t.Errorf("Expected len %d, got %d", ln+1, len(pluralInflections))
+	if ln+1 != len(Default.plurals) {
+		t.Errorf("Expected len %d, got %d", ln+1, len(Default.plurals))
 	}
 }
 
 func TestAddSingular(t *testing.T) {
 	defer restore()
-	ln := len(singularInflections)
+	ln := len(Default.singulars)
 	AddSingular("", "")
-	if ln+1 != len(singularInflections) {
-		t.Errorf("Expected len %d, got %d", ln+1, len(singularInflections))
+	if ln+1 != len(Default.singulars) {
+		t.Errorf("Expected len %d, got %d", ln+1, len(Default.singulars))
 	}
 }
 
 func TestAddIrregular(t *testing.T) {
 	defer restore()
-	ln := len(irregularInflections)
+	ln := len(Default.irregulars)
 	AddIrregular("", "")
-	if ln+1 != len(irregularInflections) {
-		t.Errorf("Expected len %d, got %d", ln+1, len(irregularInflections))
+	if ln+1 != len(Default.irregulars) {
+		t.Errorf("Expected len %d, got %d", ln+1, len(Default.irregulars))
 	}
 }
 
 func TestAddUncountable(t *testing.T) {
 	defer restore()
-	ln := len(uncountableInflections)
+	ln := len(Default.uncountables)
 	AddUncountable("", "")
-	if ln+2 != len(uncountableInflections) {
-		t.Errorf("Expected len %d, got %d", ln+2, len(uncountableInflections))
+	if ln+2 != len(Default.uncountables) {
+		t.Errorf("Expected len %d, got %d", ln+2, len(Default.uncountables))
 	}
 }
 
 func TestGetPlural(t *testing.T) {
 	plurals := GetPlural()
-	if len(plurals) != len(pluralInflections) {
-		t.Errorf("Expected len %d, got %d", len(plurals), len(pluralInflections))
+	if len(plurals) != len(Default.plurals) {
+		t.Errorf("Expected len %d, got %d", len(plurals), len(Default.plurals))
 	}
 }
 
 func TestGetSingular(t *testing.T) {
 	singular := GetSingular()
-	if len(singular) != len(singularInflections) {
-		t.Errorf("Expected len %d, got %d", len(singular), len(singularInflections))
+	if len(singular) != len(Default.singulars) {
+		t.Errorf("Expected len %d, got %d", len(singular), len(Default.singulars))
 	}
 }
 
 func TestGetIrregular(t *testing.T) {
 	irregular := GetIrregular()
-	if len(irregular) != len(irregularInflections) {
-		t.Errorf("Expected len %d, got %d", len(irregular), len(irregularInflections))
+	if len(irregular) != len(Default.irregulars) {
+		t.Errorf("Expected len %d, got %d", len(irregular), len(Default.irregulars))
 	}
 }
 
 func TestGetUncountable(t *testing.T) {
 	uncountables := GetUncountable()
-	if len(uncountables) != len(uncountableInflections) {
-		t.Errorf("Expected len %d, got %d", len(uncountables), len(uncountableInflections))
+	if len(uncountables) != len(Default.uncountables) {
+		t.Errorf("Expected len %d, got %d", len(uncountables), len(Default.uncountables))
 	}
 }
 
 func TestSetPlural(t *testing.T) {
 	defer restore()
 	SetPlural(RegularSlice{{}, {}})
-	if len(pluralInflections) != 2 {
-		t.Errorf("Expected len 2, got %d", len(pluralInflections))
+	if len(Default.plurals) != 2 {
+		t.Errorf("Expected len 2, got %d", len(Default.plurals))
 	}
 }
 
 func TestSetSingular(t *testing.T) {
 	defer restore()
 	SetSingular(RegularSlice{{}, {}})
-	if len(singularInflections) != 2 {
-		t.Errorf("Expected len 2, got %d", len(singularInflections))
+	if len(Default.singulars) != 2 {
+		t.Errorf("Expected len 2, got %d", len(Default.singulars))
 	}
 }
 
 func TestSetIrregular(t *testing.T) {
 	defer restore()
 	SetIrregular(IrregularSlice{{}, {}})
-	if len(irregularInflections) != 2 {
-		t.Errorf("Expected len 2, got %d", len(irregularInflections))
+	if len(Default.irregulars) != 2 {
+		t.Errorf("Expected len 2, got %d", len(Default.irregulars))
 	}
 }
 
 func TestSetUncountable(t *testing.T) {
 	defer restore()
 	SetUncountable([]string{"", ""})
-	if len(uncountableInflections) != 2 {
-		t.Errorf("Expected len 2, got %d", len(uncountableInflections))
+	if len(Default.uncountables) != 2 {
+		t.Errorf("Expected len 2, got %d", len(Default.uncountables))
+	}
+}
+
+func TestInflectorIsolated(t *testing.T) {
+	in := New("en")
+	in.AddIrregular("foot", "feet")
+	if v := in.Plural("foot"); v != "feet" {
+		t.Errorf("foot's plural should be feet, but got %v", v)
+	}
+	if v := Plural("foot"); v != "foots" {
+		t.Errorf("Default should not be affected, but got %v", v)
+	}
+	if v := New("unknown").Plural("foot"); v != "foot" {
+		t.Errorf("Inflector without rules should keep the word, but got %v", v)
+	}
+}
+
+var languageInflections = map[string]map[string]string{
+	"es": {
+		"casa":    "casas",
+		"árbol":   "árboles",
+		"reloj":   "relojes",
+		"luz":     "luces",
+		"canción": "canciones",
+		"inglés":  "ingleses",
+		"café":    "cafés",
+		"coche":   "coches",
+		"imagen":  "imágenes",
+		"lunes":   "lunes",
+		"usuario": "usuarios",
+		"pared":   "paredes",
+	},
+	"pt-BR": {
+		"casa":    "casas",
+		"mulher":  "mulheres",
+		"homem":   "homens",
+		"animal":  "animais",
+		"papel":   "papéis",
+		"canção":  "canções",
+		"mão":     "mãos",
+		"pão":     "pães",
+		"lápis":   "lápis",
+		"usuário": "usuários",
+	},
+	"fr": {
+		"maison":      "maisons",
+		"bateau":      "bateaux",
+		"jeu":         "jeux",
+		"cheval":      "chevaux",
+		"festival":    "festivals",
+		"travail":     "travaux",
+		"œil":         "yeux",
+		"prix":        "prix",
+		"utilisateur": "utilisateurs",
+	},
+}
+
+func TestLanguages(t *testing.T) {
+	for lang, words := range languageInflections {
+		in := New(lang)
+		for key, value := range words {
+			if v := in.Plural(key); v != value {
+				t.Errorf("%s: %v's plural should be %v, but got %v", lang, key, value, v)
+			}
+			if v := in.Plural(strings.ToUpper(key)); v != strings.ToUpper(value) {
+				t.Errorf("%s: %v's plural should be %v, but got %v", lang, strings.ToUpper(key), strings.ToUpper(value), v)
+			}
+			if v := in.Singular(value); v != key {
+				t.Errorf("%s: %v's singular should be %v, but got %v", lang, value, key, v)
+			}
+		}
+	}
+}
+
+func TestOrdinalize(t *testing.T) {
+	for n, s := range map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 13: "13th", 21: "21st", 102: "102nd", 111: "111th", -1: "-1st"} {
+		if v := Ordinalize(n); v != s {
+			t.Errorf("%d should be ordinalized to %v, but got %v", n, s, v)
+		}
+	}
+	if v := New("fr").Ordinalize(1); v != "1er" {
+		t.Errorf("1 should be ordinalized to 1er, but got %v", v)
+	}
+	if v := New("es").Ordinalize(2); v != "2º" {
+		t.Errorf("2 should be ordinalized to 2º, but got %v", v)
 	}
 }
@@ -0,0 +1,184 @@
+package inflection
+
+import (
+	"sort"
+	"strings"
+)
+
+// language bundled rules of a language
+type language struct {
+	lang         string
+	plurals      RegularSlice
+	singulars    RegularSlice
+	irregulars   IrregularSlice
+	uncountables []string
+	ordinal      func(n int) string
+}
+
+var languages = map[string]*language{
+	"en": {
+		lang:         "en",
+		plurals:      pluralInflections,
+		singulars:    singularInflections,
+		irregulars:   irregularInflections,
+		uncountables: uncountableInflections,
+		ordinal:      ordinalEnglish,
+	},
+	"es": {
+		lang: "es",
+		plurals: RegularSlice{
+			{"([a-záéíóúü])$", "${1}s"},
+			{"([bcdfghjklmnpqrstvwxyñ])$", "${1}es"},
+			{"z$", "ces"},
+			{"ón$", "ones"},
+			{"és$", "eses"},
+		},
+		singulars: RegularSlice{
+			{"s$", ""},
+			{"([aeiou][dlnrjy])es$", "${1}"},
+			{"ces$", "z"},
+			{"ones$", "ón"},
+			{"eses$", "és"},
+		},
+		irregulars: IrregularSlice{
+			{"carácter", "caracteres"},
+			{"régimen", "regímenes"},
+			{"examen", "exámenes"},
+			{"origen", "orígenes"},
+			{"imagen", "imágenes"},
+			{"joven", "jóvenes"},
+			{"mes", "meses"},
+		},
+		uncountables: []string{"lunes", "martes", "miércoles", "jueves", "viernes", "crisis", "análisis", "tesis", "virus", "paraguas", "cumpleaños"},
+		ordinal:      ordinalIndicator,
+	},
+	"pt": {
+		lang: "pt",
+		plurals: RegularSlice{
+			{"([a-záàâãéêíóôõúç])$", "${1}s"},
+			{"([rz])$", "${1}es"},
+			{"m$", "ns"},
+			{"al$", "ais"},
+			{"el$", "éis"},
+			{"ol$", "óis"},
+			{"ul$", "uis"},
+			{"ão$", "ões"},
+		},
+		singulars: RegularSlice{
+			{"s$", ""},
+			{"([rz])es$", "${1}"},
+			{"ns$", "m"},
+			{"ais$", "al"},
+			{"éis$", "el"},
+			{"óis$", "ol"},
+			{"uis$", "ul"},
+			{"ões$", "ão"},
+			{"ães$", "ão"},
+		},
+		irregulars: IrregularSlice{
+			{"mão", "mãos"},
+			{"irmão", "irmãos"},
+			{"cidadão", "cidadãos"},
+			{"cristão", "cristãos"},
+			{"cão", "cães"},
+			{"pão", "pães"},
+			{"alemão", "alemães"},
+			{"capitão", "capitães"},
+			{"cônsul", "cônsules"},
+		},
+		uncountables: []string{"lápis", "ônibus", "vírus", "tórax", "pires", "óculos"},
+		ordinal:      ordinalIndicator,
+	},
+	"fr": {
+		lang: "fr",
+		plurals: RegularSlice{
+			{"([a-zàâçéèêëîïôûùüÿœæ])$", "${1}s"},
+			{"([sxz])$", "${1}"},
+			{"(eau|au|eu)$", "${1}x"},
+			{"al$", "aux"},
+		},
+		singulars: RegularSlice{
+			{"s$", ""},
+			{"(au|eu)x$", "${1}"},
+			{"aux$", "al"},
+			{"eaux$", "eau"},
+		},
+		irregulars: IrregularSlice{
+			{"œil", "yeux"},
+			{"ciel", "cieux"},
+			{"bal", "bals"},
+			{"carnaval", "carnavals"},
+			{"festival", "festivals"},
+			{"récital", "récitals"},
+			{"pneu", "pneus"},
+			{"bleu", "bleus"},
+			{"travail", "travaux"},
+			{"bail", "baux"},
+			{"corail", "coraux"},
+			{"émail", "émaux"},
+			{"vitrail", "vitraux"},
+			{"bijou", "bijoux"},
+			{"caillou", "cailloux"},
+			{"chou", "choux"},
+			{"genou", "genoux"},
+			{"hibou", "hiboux"},
+			{"joujou", "joujoux"},
+			{"pou", "poux"},
+			{"madame", "mesdames"},
+			{"mademoiselle", "mesdemoiselles"},
+			{"monsieur", "messieurs"},
+		},
+		uncountables: []string{"bras", "fois", "pays", "prix", "souris", "temps", "voix", "nez", "gaz", "choix"},
+		ordinal:      ordinalFrench,
+	},
+}
+
+// lookupLanguage finds bundled rules by the primary subtag of lang, like "pt" of "pt-BR"
+func lookupLanguage(lang string) (*language, bool) {
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	l, ok := languages[lang]
+	return l, ok
+}
+
+// Languages returns the languages of bundled rules
+func Languages() []string {
+	var l []string
+	for k := range languages {
+		l = append(l, k)
+	}
+	sort.Strings(l)
+	return l
+}
+
+func ordinalEnglish(n int) string {
+	if n < 0 {
+		n = -n
+	}
+	if n%100 >= 11 && n%100 <= 13 {
+		return "th"
+	}
+	switch n % 10 {
+	case 1:
+		return "st"
+	case 2:
+		return "nd"
+	case 3:
+		return "rd"
+	}
+	return "th"
+}
+
+// ordinalIndicator the masculine ordinal indicator of Spanish and Portuguese
+func ordinalIndicator(n int) string {
+	return "º"
+}
+
+func ordinalFrench(n int) string {
+	if n == 1 {
+		return "er"
+	}
+	return "e"
+}
@@ -0,0 +1,151 @@
+package shellquote
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+)
+
+// ErrUnquotable the argument can not be quoted for the dialect, ie: newlines
+// for cmd.exe
+var ErrUnquotable = errors.New("shellquote: argument can not be quoted")
+
+// Dialect shell dialect of quoting
+type Dialect int
+
+const (
+	// Sh POSIX sh, same as Join
+	Sh Dialect = iota
+	// Cmd Windows cmd.exe, for programs parsing arguments as
+	// CommandLineToArgvW does, which most programs do
+	Cmd
+	// PowerShell Windows PowerShell and PowerShell Core
+	PowerShell
+)
+
+// String returns the name of the dialect
+func (d Dialect) String() string {
+	switch d {
+	case Sh:
+		return "sh"
+	case Cmd:
+		return "cmd"
+	case PowerShell:
+		return "powershell"
+	}
+	return "unknown"
+}
+
+// Quote quotes a single argument
+func (d Dialect) Quote(arg string) (string, error) {
+	var buf bytes.Buffer
+	if err := d.quote(arg, &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Join quotes each argument and joins them with a space. The resulting string
+// will be split back into the original arguments by the shell.
+func (d Dialect) Join(args ...string) (string, error) {
+	var buf bytes.Buffer
+	for i, arg := range args {
+		if i != 0 {
+			buf.WriteByte(' ')
+		}
+		if err := d.quote(arg, &buf); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+func (d Dialect) quote(arg string, buf *bytes.Buffer) error {
+	switch d {
+	case Sh:
+		quote(arg, buf)
+	case Cmd:
+		return quoteCmd(arg, buf)
+	case PowerShell:
+		quotePowerShell(arg, buf)
+	default:
+		return ErrUnquotable
+	}
+	return nil
+}
+
+const cmdSpecialChars = "()%!^\"<>&|"
+
+// quoteCmd quotes the argument by the rules of CommandLineToArgvW, then
+// escapes cmd.exe metacharacters with ^, including the quotes, so cmd.exe
+// passes the argument through untouched. %VAR% is not expanded since ^
+// breaks the variable name.
+func quoteCmd(arg string, buf *bytes.Buffer) error {
+	if strings.ContainsAny(arg, "\r\n\x00") {
+		return ErrUnquotable
+	}
+	var argv bytes.Buffer
+	if len(arg) > 0 && !strings.ContainsAny(arg, " \t\"") {
+		argv.WriteString(arg)
+	} else {
+		argv.WriteByte('"')
+		slashes := 0
+		for i := 0; i < len(arg); i++ {
+			c := arg[i]
+			switch c {
+			case '\\':
+				slashes++
+			case '"':
+				// backslashes before a quote are doubled, plus one for the quote
+				argv.WriteString(strings.Repeat("\\", slashes+1))
+				slashes = 0
+			default:
+				slashes = 0
+			}
+			argv.WriteByte(c)
+		}
+		// backslashes before the closing quote are doubled
+		argv.WriteString(strings.Repeat("\\", slashes))
+		argv.WriteByte('"')
+	}
+	for _, c := range argv.Bytes() {
+		if strings.IndexByte(cmdSpecialChars, c) >= 0 {
+			buf.WriteByte('^')
+		}
+		buf.WriteByte(c)
+	}
+	return nil
+}
+
+func isPowerShellSafe(arg string) bool {
+	if len(arg) == 0 || arg[0] == '-' {
+		return false
+	}
+	for i := 0; i < len(arg); i++ {
+		c := arg[i]
+		if !(isNameChar(c) || strings.IndexByte("-./\\:=+", c) >= 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// quotePowerShell uses a verbatim single-quoted string, in which only single
+// quotes, including the typographic ones PowerShell accepts, need doubling.
+// Note that PowerShell before 7.3 does not escape double quotes of arguments
+// passed to native programs.
+func quotePowerShell(arg string, buf *bytes.Buffer) {
+	if isPowerShellSafe(arg) {
+		buf.WriteString(arg)
+		return
+	}
+	buf.WriteByte('\'')
+	for _, c := range arg {
+		switch c {
+		case '\'', '‘', '’', '‚', '‛':
+			buf.WriteRune(c)
+		}
+		buf.WriteRune(c)
+	}
+	buf.WriteByte('\'')
+}
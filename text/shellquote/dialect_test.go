@@ -0,0 +1,32 @@
+package shellquote
+
+import (
+	"testing"
+)
+
+func TestDialectJoin(t *testing.T) {
+	for _, elem := range dialectJoinTest {
+		output, err := elem.dialect.Join(elem.input...)
+		if err != nil {
+			t.Errorf("Dialect %s, input %q, got error %v", elem.dialect, elem.input, err)
+		} else if output != elem.output {
+			t.Errorf("Dialect %s, input %q, got %q, expected %q", elem.dialect, elem.input, output, elem.output)
+		}
+	}
+
+	if _, err := Cmd.Quote("a\nb"); err != ErrUnquotable {
+		t.Errorf("Newline should be unquotable for cmd, got %v", err)
+	}
+}
+
+var dialectJoinTest = []struct {
+	dialect Dialect
+	input   []string
+	output  string
+}{
+	{Sh, []string{"hello goodbye", "$x"}, "'hello goodbye' \\$x"},
+	{Cmd, []string{"test", "C:\\Program Files\\", ""}, "test ^\"C:\\Program Files\\\\^\" ^\"^\""},
+	{Cmd, []string{"a\"b", "a\\\"b c", "100%", "%PATH%", "x&y|z"}, "^\"a\\^\"b^\" ^\"a\\\\\\^\"b c^\" 100^% ^%PATH^% x^&y^|z"},
+	{PowerShell, []string{"Get-Item", "C:\\tmp\\a.txt", "-la", ""}, "Get-Item C:\\tmp\\a.txt '-la' ''"},
+	{PowerShell, []string{"it's", "$env:PATH", "a b;c", "‘q’"}, "'it''s' '$env:PATH' 'a b;c' '‘‘q’’'"},
+}
@@ -1,3 +1,4 @@
 // Shellquote provides utilities for joining/splitting strings using sh's
-// word-splitting rules.
+// word-splitting rules, with optional variable and tilde expansion, and
+// quoting for Windows cmd.exe and PowerShell.
 package shellquote
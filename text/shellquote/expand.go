@@ -0,0 +1,218 @@
+package shellquote
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"strings"
+	"unicode/utf8"
+)
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
+
+func (s *splitter) lookup(name string) (string, bool) {
+	if v, ok := s.vars[name]; ok {
+		return v, true
+	}
+	if s.opts.Lookup != nil {
+		return s.opts.Lookup(name)
+	}
+	return os.LookupEnv(name)
+}
+
+// name reads a variable name, or a single digit as positional parameters
+func (s *splitter) name() string {
+	start := s.pos
+	if s.pos < len(s.input) && s.input[s.pos] >= '0' && s.input[s.pos] <= '9' {
+		s.pos++
+		return s.input[start:s.pos]
+	}
+	if s.pos < len(s.input) && isNameStart(s.input[s.pos]) {
+		for s.pos < len(s.input) && isNameChar(s.input[s.pos]) {
+			s.pos++
+		}
+	}
+	return s.input[start:s.pos]
+}
+
+// dollar expands $NAME or ${...} at s.pos, a "$" not followed by a name is
+// kept as is
+func (s *splitter) dollar(quoted bool) error {
+	open := s.pos
+	s.pos++
+	if s.pos < len(s.input) && s.input[s.pos] == '{' {
+		s.pos++
+		return s.brace(open, quoted)
+	}
+	name := s.name()
+	if len(name) == 0 {
+		s.buf.WriteByte('$')
+		return nil
+	}
+	v, ok := s.lookup(name)
+	if !ok && s.opts.Strict && !s.skip {
+		return s.fail(open, name, UnsetVariableError)
+	}
+	s.buf.WriteString(v)
+	return nil
+}
+
+func (s *splitter) brace(open int, quoted bool) error {
+	name := s.name()
+	if len(name) == 0 {
+		return s.fail(open, "", BadSubstitutionError)
+	}
+	if s.pos >= len(s.input) {
+		return s.fail(open, name, UnterminatedBraceError)
+	}
+
+	v, set := s.lookup(name)
+
+	if s.input[s.pos] == '}' {
+		s.pos++
+		if !set && s.opts.Strict && !s.skip {
+			return s.fail(open, name, UnsetVariableError)
+		}
+		s.buf.WriteString(v)
+		return nil
+	}
+
+	null := !set
+	if s.input[s.pos] == ':' {
+		null = !set || len(v) == 0
+		s.pos++
+	}
+	if s.pos >= len(s.input) {
+		return s.fail(open, name, UnterminatedBraceError)
+	}
+	op := s.input[s.pos]
+	s.pos++
+
+	var use bool
+	switch op {
+	case '-', '=', '?':
+		use = null
+	case '+':
+		use = !null
+	default:
+		return s.fail(open, name, BadSubstitutionError)
+	}
+
+	// the word is only expanded if used
+	mark := s.buf.Len()
+	skip := s.skip
+	s.skip = skip || !use
+	err := s.braceWord(open, name, quoted)
+	s.skip = skip
+	if err != nil {
+		return err
+	}
+	word := s.buf.String()[mark:]
+	s.buf.Truncate(mark)
+
+	switch {
+	case op == '?' && use:
+		if s.skip {
+			return nil
+		}
+		if len(word) == 0 {
+			word = "parameter null or not set"
+		}
+		return s.fail(open, name, errors.New(word))
+	case op == '=' && use:
+		if !s.skip {
+			if s.vars == nil {
+				s.vars = map[string]string{}
+			}
+			s.vars[name] = word
+		}
+		s.buf.WriteString(word)
+	case use:
+		s.buf.WriteString(word)
+	case op != '+':
+		s.buf.WriteString(v)
+	}
+	return nil
+}
+
+// braceWord reads the word of ${NAME:-word} up to the closing brace
+func (s *splitter) braceWord(open int, name string, quoted bool) error {
+	for s.pos < len(s.input) {
+		c := s.input[s.pos]
+		switch {
+		case c == '}':
+			s.pos++
+			return nil
+		case c == '\\':
+			if s.pos+1 == len(s.input) {
+				return s.fail(s.pos, "", UnterminatedEscapeError)
+			}
+			_, l := utf8.DecodeRuneInString(s.input[s.pos+1:])
+			if s.input[s.pos+1] != '\n' {
+				s.buf.WriteString(s.input[s.pos+1 : s.pos+1+l])
+			}
+			s.pos += 1 + l
+		case c == '$':
+			if err := s.dollar(quoted); err != nil {
+				return err
+			}
+		case c == '\'' && !quoted:
+			if err := s.single(); err != nil {
+				return err
+			}
+		case c == '"' && !quoted:
+			if err := s.double(); err != nil {
+				return err
+			}
+		default:
+			s.buf.WriteByte(c)
+			s.pos++
+		}
+	}
+	return s.fail(open, name, UnterminatedBraceError)
+}
+
+// tilde expands ~ or ~user followed by "/" or the end of word, unknown users
+// are kept as is
+func (s *splitter) tilde() {
+	end := s.pos + 1
+	for end < len(s.input) && (isNameChar(s.input[end]) || s.input[end] == '.' || s.input[end] == '-') {
+		end++
+	}
+	if end < len(s.input) && s.input[end] != '/' && !strings.ContainsRune(splitChars, rune(s.input[end])) {
+		s.buf.WriteByte('~')
+		s.pos++
+		return
+	}
+	home, ok := s.homeDir(s.input[s.pos+1 : end])
+	if !ok {
+		home = s.input[s.pos:end]
+	}
+	s.buf.WriteString(home)
+	s.pos = end
+}
+
+func (s *splitter) homeDir(name string) (string, bool) {
+	if s.opts.HomeDir != nil {
+		return s.opts.HomeDir(name)
+	}
+	if len(name) == 0 {
+		if home, ok := s.lookup("HOME"); ok && len(home) > 0 {
+			return home, true
+		}
+		if u, err := user.Current(); err == nil {
+			return u.HomeDir, true
+		}
+		return "", false
+	}
+	if u, err := user.Lookup(name); err == nil {
+		return u.HomeDir, true
+	}
+	return "", false
+}
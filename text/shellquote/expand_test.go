@@ -0,0 +1,96 @@
+package shellquote
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testEnv = map[string]string{
+	"HOME":  "/home/me",
+	"NAME":  "world",
+	"EMPTY": "",
+	"SPACE": "a b",
+}
+
+func testOptions() Options {
+	return Options{
+		Expand: true,
+		Tilde:  true,
+		Lookup: func(name string) (string, bool) {
+			v, ok := testEnv[name]
+			return v, ok
+		},
+		HomeDir: func(user string) (string, bool) {
+			switch user {
+			case "":
+				return "/home/me", true
+			case "bob":
+				return "/home/bob", true
+			}
+			return "", false
+		},
+	}
+}
+
+func TestExpandSplit(t *testing.T) {
+	for _, elem := range expandSplitTest {
+		output, err := SplitWithOptions(elem.input, testOptions())
+		if err != nil {
+			t.Errorf("Input %q, got error %v", elem.input, err)
+		} else if !reflect.DeepEqual(output, elem.output) {
+			t.Errorf("Input %q, got %q, expected %q", elem.input, output, elem.output)
+		}
+	}
+}
+
+func TestExpandError(t *testing.T) {
+	opts := testOptions()
+	opts.Strict = true
+	for _, elem := range expandErrorTest {
+		_, err := SplitWithOptions(elem.input, opts)
+		if err == nil || err.Error() != elem.error {
+			t.Errorf("Input %q, got error %v, expected %s", elem.input, err, elem.error)
+		}
+	}
+
+	_, err := SplitWithOptions("a\n  'b", Options{})
+	if pe, ok := err.(*ParseError); !ok || pe.Err != UnterminatedSingleQuoteError || pe.Offset != 4 || pe.Line != 2 || pe.Column != 3 {
+		t.Errorf("Unexpected error %#v", err)
+	}
+}
+
+var expandSplitTest = []struct {
+	input  string
+	output []string
+}{
+	{"hello $NAME", []string{"hello", "world"}},
+	{"hello ${NAME}s", []string{"hello", "worlds"}},
+	{"$SPACE", []string{"a b"}},
+	{"a $EMPTY b", []string{"a", "b"}},
+	{"a \"$EMPTY\" b", []string{"a", "", "b"}},
+	{"'$NAME' \\$NAME \"$NAME\"", []string{"$NAME", "$NAME", "world"}},
+	{"$ $1 $$ $@ 5$", []string{"$", "$$", "$@", "5$"}},
+	{"${UNSET:-x y} ${UNSET-'a b'} \"${UNSET:-'q'}\"", []string{"x y", "a b", "'q'"}},
+	{"${EMPTY:-d} ${EMPTY-d} ${NAME:+set} ${UNSET:+set}x", []string{"d", "set", "x"}},
+	{"${UNSET:-${NAME}} ${NAME:-${MISSING:?never}}", []string{"world", "world"}},
+	{"${NEW:=v} $NEW", []string{"v", "v"}},
+	{"${NAME:-a\\}b}", []string{"world"}},
+	{"${UNSET:-a\\}b}", []string{"a}b"}},
+	{"~ ~/src ~bob/x ~nobody/x a~ '~' ~\"x\"", []string{"/home/me", "/home/me/src", "/home/bob/x", "~nobody/x", "a~", "~", "~x"}},
+	{"text with\\\na backslash-escaped newline", []string{"text", "witha", "backslash-escaped", "newline"}},
+}
+
+var expandErrorTest = []struct {
+	input string
+	error string
+}{
+	{"a $UNSET", "shellquote: 1:3: UNSET: Unset variable"},
+	{"a\n ${UNSET}", "shellquote: 2:2: UNSET: Unset variable"},
+	{"${UNSET:?is required}", "shellquote: 1:1: UNSET: is required"},
+	{"${EMPTY:?}", "shellquote: 1:1: EMPTY: parameter null or not set"},
+	{"${NAME", "shellquote: 1:1: NAME: Unterminated parameter expansion"},
+	{"${NAME:-x", "shellquote: 1:1: NAME: Unterminated parameter expansion"},
+	{"${NAME%x}", "shellquote: 1:1: NAME: Bad substitution"},
+	{"${}", "shellquote: 1:1: Bad substitution"},
+	{"\"$NAME", "shellquote: 1:1: Unterminated double-quoted string"},
+}
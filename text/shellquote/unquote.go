@@ -3,6 +3,7 @@ package shellquote
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"strings"
 	"unicode/utf8"
 )
@@ -11,6 +12,9 @@ var (
 	UnterminatedSingleQuoteError = errors.New("Unterminated single-quoted string")
 	UnterminatedDoubleQuoteError = errors.New("Unterminated double-quoted string")
 	UnterminatedEscapeError      = errors.New("Unterminated backslash-escape")
+	UnterminatedBraceError       = errors.New("Unterminated parameter expansion")
+	BadSubstitutionError         = errors.New("Bad substitution")
+	UnsetVariableError           = errors.New("Unset variable")
 )
 
 var (
@@ -21,124 +25,201 @@ var (
 	doubleEscapeChars = "$`\"\n\\"
 )
 
+// ParseError is returned by SplitWithOptions, it wraps one of the errors
+// above, or the message of ${NAME:?message}, with the position in input.
+type ParseError struct {
+	// Offset byte offset of the failed construct, ie: the opening quote
+	Offset int
+	// Line and Column 1-based position of Offset, Column counts runes
+	Line   int
+	Column int
+	// Name variable name of expansion errors
+	Name string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	if len(e.Name) > 0 {
+		return fmt.Sprintf("shellquote: %d:%d: %s: %v", e.Line, e.Column, e.Name, e.Err)
+	}
+	return fmt.Sprintf("shellquote: %d:%d: %v", e.Line, e.Column, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Options options of SplitWithOptions
+type Options struct {
+	// Expand expands $NAME and ${NAME} outside single-quotes, along with
+	// ${NAME:-word}, ${NAME:=word}, ${NAME:+word}, ${NAME:?word} and their
+	// forms without colon which only test if NAME is set. Expanded values
+	// are not split into words nor globbed, as if they were double-quoted.
+	Expand bool
+	// Lookup looks up variables, default os.LookupEnv
+	Lookup func(name string) (string, bool)
+	// Strict fails on unset variables, like "set -u"
+	Strict bool
+	// Tilde expands ~ and ~user at the start of words
+	Tilde bool
+	// HomeDir looks up the home directory of user, "" for the current user,
+	// default $HOME by Lookup, then os/user
+	HomeDir func(user string) (string, bool)
+}
+
 // Split splits a string according to /bin/sh's word-splitting rules. It
 // supports backslash-escapes, single-quotes, and double-quotes. Notably it does
 // not support the $'' style of quoting. It also doesn't attempt to perform any
 // other sort of expansion, including brace expansion, shell expansion, or
-// pathname expansion.
+// pathname expansion, see SplitWithOptions for variable and tilde expansion.
 //
 // If the given input has an unterminated quoted string or ends in a
 // backslash-escape, one of UnterminatedSingleQuoteError,
 // UnterminatedDoubleQuoteError, or UnterminatedEscapeError is returned.
 func Split(input string) (words []string, err error) {
-	var buf bytes.Buffer
+	words, err = SplitWithOptions(input, Options{})
+	if pe, ok := err.(*ParseError); ok {
+		err = pe.Err
+	}
+	return
+}
+
+// SplitWithOptions is the same as Split, with optional variable and tilde
+// expansion. Errors are returned as *ParseError with the position.
+func SplitWithOptions(input string, opts Options) (words []string, err error) {
+	s := &splitter{input: input, opts: opts}
 	words = make([]string, 0)
 
-	for len(input) > 0 {
+	for s.pos < len(input) {
 		// skip any splitChars at the start
-		c, l := utf8.DecodeRuneInString(input)
+		c, l := utf8.DecodeRuneInString(input[s.pos:])
 		if strings.ContainsRune(splitChars, c) {
-			input = input[l:]
+			s.pos += l
 			continue
 		}
 
 		var word string
-		word, input, err = splitWord(input, &buf)
-		if err != nil {
+		var ok bool
+		if word, ok, err = s.word(); err != nil {
 			return
 		}
-		words = append(words, word)
+		if ok {
+			words = append(words, word)
+		}
 	}
 	return
 }
 
-func splitWord(input string, buf *bytes.Buffer) (word string, remainder string, err error) {
-	buf.Reset()
-
-raw:
-	{
-		cur := input
-		for len(cur) > 0 {
-			c, l := utf8.DecodeRuneInString(cur)
-			cur = cur[l:]
-			if c == singleChar {
-				buf.WriteString(input[0 : len(input)-len(cur)-l])
-				input = cur
-				goto single
-			} else if c == doubleChar {
-				buf.WriteString(input[0 : len(input)-len(cur)-l])
-				input = cur
-				goto double
-			} else if c == escapeChar {
-				buf.WriteString(input[0 : len(input)-len(cur)-l])
-				input = cur
-				goto escape
-			} else if strings.ContainsRune(splitChars, c) {
-				buf.WriteString(input[0 : len(input)-len(cur)-l])
-				return buf.String(), cur, nil
-			}
-		}
-		if len(input) > 0 {
-			buf.WriteString(input)
-			input = ""
-		}
-		goto done
-	}
+type splitter struct {
+	input string
+	pos   int
+	opts  Options
+	buf   bytes.Buffer
+	// vars assigned by ${NAME:=word}
+	vars map[string]string
+	// skip parses the word of an unused ${NAME:-word} without side effects
+	skip bool
+}
 
-escape:
-	{
-		if len(input) == 0 {
-			return "", "", UnterminatedEscapeError
-		}
-		c, l := utf8.DecodeRuneInString(input)
+func (s *splitter) fail(offset int, name string, err error) error {
+	pe := &ParseError{Offset: offset, Line: 1, Column: 1, Name: name, Err: err}
+	for _, c := range s.input[:offset] {
 		if c == '\n' {
-			// a backslash-escaped newline is elided from the output entirely
+			pe.Line++
+			pe.Column = 1
 		} else {
-			buf.WriteString(input[:l])
+			pe.Column++
 		}
-		input = input[l:]
 	}
-	goto raw
+	return pe
+}
+
+// word reads a word, ok is false if the word is empty and has no quotes, ie:
+// an escaped newline or an unquoted variable expanded to nothing
+func (s *splitter) word() (word string, ok bool, err error) {
+	s.buf.Reset()
+	start := s.pos
 
-single:
-	{
-		i := strings.IndexRune(input, singleChar)
-		if i == -1 {
-			return "", "", UnterminatedSingleQuoteError
+	for s.pos < len(s.input) {
+		c, l := utf8.DecodeRuneInString(s.input[s.pos:])
+		switch {
+		case strings.ContainsRune(splitChars, c):
+			s.pos += l
+			return s.buf.String(), ok || s.buf.Len() > 0, nil
+		case c == singleChar:
+			ok = true
+			if err = s.single(); err != nil {
+				return
+			}
+		case c == doubleChar:
+			ok = true
+			if err = s.double(); err != nil {
+				return
+			}
+		case c == escapeChar:
+			if s.pos+l == len(s.input) {
+				err = s.fail(s.pos, "", UnterminatedEscapeError)
+				return
+			}
+			s.pos += l
+			c, l = utf8.DecodeRuneInString(s.input[s.pos:])
+			// a backslash-escaped newline is elided from the output entirely
+			if c != '\n' {
+				s.buf.WriteRune(c)
+			}
+			s.pos += l
+		case c == '~' && s.pos == start && s.opts.Tilde:
+			s.tilde()
+		case c == '$' && s.opts.Expand:
+			if err = s.dollar(false); err != nil {
+				return
+			}
+		default:
+			s.buf.WriteRune(c)
+			s.pos += l
 		}
-		buf.WriteString(input[0:i])
-		input = input[i+1:]
-		goto raw
 	}
 
-double:
-	{
-		cur := input
-		for len(cur) > 0 {
-			c, l := utf8.DecodeRuneInString(cur)
-			cur = cur[l:]
-			if c == doubleChar {
-				buf.WriteString(input[0 : len(input)-len(cur)-l])
-				input = cur
-				goto raw
-			} else if c == escapeChar {
-				// bash only supports certain escapes in double-quoted strings
-				c2, l2 := utf8.DecodeRuneInString(cur)
-				cur = cur[l2:]
-				if strings.ContainsRune(doubleEscapeChars, c2) {
-					buf.WriteString(input[0 : len(input)-len(cur)-l-l2])
-					if c2 == '\n' {
-						// newline is special, skip the backslash entirely
-					} else {
-						buf.WriteRune(c2)
-					}
-					input = cur
-				}
+	return s.buf.String(), ok || s.buf.Len() > 0, nil
+}
+
+func (s *splitter) single() error {
+	open := s.pos
+	i := strings.IndexRune(s.input[open+1:], singleChar)
+	if i == -1 {
+		return s.fail(open, "", UnterminatedSingleQuoteError)
+	}
+	s.buf.WriteString(s.input[open+1 : open+1+i])
+	s.pos = open + i + 2
+	return nil
+}
+
+func (s *splitter) double() error {
+	open := s.pos
+	s.pos++
+	// all special chars are ASCII, so bytes can be copied as is
+	for s.pos < len(s.input) {
+		c := s.input[s.pos]
+		switch {
+		case c == '"':
+			s.pos++
+			return nil
+		case c == '\\' && s.pos+1 < len(s.input) && strings.IndexByte(doubleEscapeChars, s.input[s.pos+1]) >= 0:
+			// bash only supports certain escapes in double-quoted strings,
+			// newline is special, skip the backslash entirely
+			if s.input[s.pos+1] != '\n' {
+				s.buf.WriteByte(s.input[s.pos+1])
 			}
+			s.pos += 2
+		case c == '$' && s.opts.Expand:
+			if err := s.dollar(true); err != nil {
+				return err
+			}
+		default:
+			s.buf.WriteByte(c)
+			s.pos++
 		}
-		return "", "", UnterminatedDoubleQuoteError
 	}
-
-done:
-	return buf.String(), input, nil
+	return s.fail(open, "", UnterminatedDoubleQuoteError)
 }
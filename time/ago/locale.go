@@ -0,0 +1,268 @@
+package ago
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"landzero.net/x/encoding/yaml"
+)
+
+// Unit names of Locale.Units, from largest to smallest
+var Units = []string{"year", "month", "day", "hour", "minute", "second"}
+
+var unitDurations = []time.Duration{Year, Month, Day, time.Hour, time.Minute, time.Second}
+
+// Locale a locale pack with CLDR plural forms, templates use "{{0}}" as the placeholder, same as net/web/i18n
+type Locale struct {
+	// Name locale name, like "en-US"
+	Name string
+	// Plural plural rule, defaults to PluralRuleFor(Name)
+	Plural PluralRule
+	// Past template of past time, like "{{0}} ago"
+	Past string
+	// Future template of future time, like "in {{0}}"
+	Future string
+	// Now text of durations less than a second, like "just now"
+	Now string
+	// Units templates of units by plural category, like Units["hour"][PluralOther] = "{{0}} hours",
+	// PluralOther is used if a category is missing
+	Units map[string]map[PluralCategory]string
+	// Separator separator between units of compound formats, defaults to " "
+	Separator string
+	// Max max duration formatted relatively, zero means unlimited
+	Max time.Duration
+	// Layout layout of times exceeding Max
+	Layout string
+}
+
+// FormatOptions options of formatting
+type FormatOptions struct {
+	// Precise truncate instead of rounding the smallest unit, e.g. 1h50m is "1 hour" instead of "2 hours"
+	Precise bool
+	// MaxUnits max count of units, e.g. 2 gives "2 hours 5 minutes", zero means 1
+	MaxUnits int
+}
+
+// Approximate options of a single rounded unit, e.g. "2 hours"
+var Approximate = FormatOptions{MaxUnits: 1}
+
+// Precise options of all units truncated, e.g. "2 hours 5 minutes 3 seconds"
+var Precise = FormatOptions{Precise: true, MaxUnits: len(Units)}
+
+func fill(tpl string, v string) string {
+	return strings.Replace(tpl, "{{0}}", v, -1)
+}
+
+// unit formats n units
+func (l *Locale) unit(name string, n int64) string {
+	forms := l.Units[name]
+	rule := l.Plural
+	if rule == nil {
+		rule = PluralRuleFor(l.Name)
+	}
+	tpl, ok := forms[rule(n)]
+	if !ok {
+		tpl = forms[PluralOther]
+	}
+	return fill(tpl, strconv.FormatInt(n, 10))
+}
+
+// FormatDuration formats a duration like "2 hours 5 minutes", sign is ignored
+func (l *Locale) FormatDuration(d time.Duration, opts FormatOptions) string {
+	if d < 0 {
+		d = -d
+	}
+	if opts.MaxUnits <= 0 {
+		opts.MaxUnits = 1
+	}
+	// find the leading unit, and the smallest unit included
+	lead := -1
+	for i, ud := range unitDurations {
+		if d >= ud {
+			lead = i
+			break
+		}
+	}
+	if lead < 0 {
+		return l.Now
+	}
+	last := lead + opts.MaxUnits - 1
+	if last >= len(unitDurations) {
+		last = len(unitDurations) - 1
+	}
+	step := unitDurations[last]
+	if opts.Precise {
+		d = d / step * step
+	} else {
+		d = time.Duration(math.Round(float64(d)/float64(step))) * step
+	}
+	// decompose again, rounding may carry into a larger unit
+	var parts []string
+	count := 0
+	for i, ud := range unitDurations {
+		if i > last || count >= opts.MaxUnits {
+			break
+		}
+		n := d / ud
+		if n == 0 && count == 0 {
+			continue
+		}
+		count++
+		d -= n * ud
+		if n > 0 {
+			parts = append(parts, l.unit(Units[i], int64(n)))
+		}
+	}
+	sep := l.Separator
+	if len(sep) == 0 {
+		sep = " "
+	}
+	return strings.Join(parts, sep)
+}
+
+// FormatRelativeDuration formats a duration relatively, positive duration is in the past
+func (l *Locale) FormatRelativeDuration(d time.Duration, opts FormatOptions) string {
+	s := l.FormatDuration(d, opts)
+	if s == l.Now {
+		return s
+	}
+	if d >= 0 {
+		return fill(l.Past, s)
+	}
+	return fill(l.Future, s)
+}
+
+// FormatReference formats t relative to reference, times exceeding Max are formatted with Layout
+func (l *Locale) FormatReference(t time.Time, reference time.Time, opts FormatOptions) string {
+	d := reference.Sub(t)
+	if l.Max > 0 && (d >= l.Max || -d >= l.Max) {
+		return t.Format(l.Layout)
+	}
+	return l.FormatRelativeDuration(d, opts)
+}
+
+// Format formats t relative to now, approximately
+func (l *Locale) Format(t time.Time) string {
+	return l.FormatReference(t, time.Now(), Approximate)
+}
+
+// LocaleKeyPrefix prefix of keys in locale files
+const LocaleKeyPrefix = "ago."
+
+// NewLocaleFromLookup create a Locale from flattened keys, like keys loaded by net/web/i18n,
+// returns false if LocaleKeyPrefix+"past" is missing:
+//
+//  ago.plural                plural rule name, see PluralRules, defaults to PluralRuleFor(name)
+//  ago.past, ago.future      templates, "{{0}} ago", "in {{0}}"
+//  ago.now                   "just now"
+//  ago.separator             " "
+//  ago.layout                "2006-01-02"
+//  ago.max                   "72h", parsed by time.ParseDuration
+//  ago.units.hour.one        "{{0}} hour", same for other units and categories
+func NewLocaleFromLookup(name string, lookup func(key string) string) (l *Locale, ok bool) {
+	get := func(key string) string { return lookup(LocaleKeyPrefix + key) }
+	if len(get("past")) == 0 {
+		return
+	}
+	l = &Locale{
+		Name:      name,
+		Plural:    PluralRuleFor(name),
+		Past:      get("past"),
+		Future:    get("future"),
+		Now:       get("now"),
+		Separator: get("separator"),
+		Layout:    get("layout"),
+		Units:     map[string]map[PluralCategory]string{},
+	}
+	if p := get("plural"); len(p) > 0 {
+		l.Plural = PluralRuleFor(p)
+	}
+	if m := get("max"); len(m) > 0 {
+		l.Max, _ = time.ParseDuration(m)
+	}
+	categories := []PluralCategory{PluralZero, PluralOne, PluralTwo, PluralFew, PluralMany, PluralOther}
+	for _, u := range Units {
+		forms := map[PluralCategory]string{}
+		for _, c := range categories {
+			if v := get("units." + u + "." + string(c)); len(v) > 0 {
+				forms[c] = v
+			}
+		}
+		l.Units[u] = forms
+	}
+	ok = true
+	return
+}
+
+// ParseLocale create a Locale from a YAML locale file, the same format used by net/web/i18n,
+// see NewLocaleFromLookup for keys
+func ParseLocale(name string, data []byte) (l *Locale, err error) {
+	m := map[interface{}]interface{}{}
+	if err = yaml.Unmarshal(data, &m); err != nil {
+		return
+	}
+	flat := map[string]string{}
+	flattenYAML("", m, flat)
+	var ok bool
+	if l, ok = NewLocaleFromLookup(name, func(key string) string { return flat[key] }); !ok {
+		err = errors.New("ago: missing " + LocaleKeyPrefix + "past in locale " + name)
+	}
+	return
+}
+
+func flattenYAML(prefix string, in map[interface{}]interface{}, out map[string]string) {
+	for k, v := range in {
+		key := prefix + yamlString(k)
+		if m, ok := v.(map[interface{}]interface{}); ok {
+			flattenYAML(key+".", m, out)
+		} else {
+			out[key] = yamlString(v)
+		}
+	}
+}
+
+func yamlString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case int:
+		return strconv.Itoa(s)
+	case bool:
+		return strconv.FormatBool(s)
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case nil:
+		return ""
+	}
+	return ""
+}
+
+var (
+	localesMtx = &sync.RWMutex{}
+	locales    = map[string]*Locale{}
+)
+
+// RegisterLocale register a locale for LookupLocale
+func RegisterLocale(l *Locale) {
+	localesMtx.Lock()
+	defer localesMtx.Unlock()
+	locales[strings.ToLower(l.Name)] = l
+}
+
+// LookupLocale find a registered locale by tag like "pt-BR", falls back to the base language
+func LookupLocale(tag string) (l *Locale, ok bool) {
+	tag = strings.ToLower(strings.Replace(tag, "_", "-", -1))
+	localesMtx.RLock()
+	defer localesMtx.RUnlock()
+	if l, ok = locales[tag]; ok {
+		return
+	}
+	if i := strings.Index(tag, "-"); i > 0 {
+		l, ok = locales[tag[:i]]
+	}
+	return
+}
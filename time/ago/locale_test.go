@@ -0,0 +1,120 @@
+package ago
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPluralRules(t *testing.T) {
+	cases := []struct {
+		rule     PluralRule
+		n        int64
+		expected PluralCategory
+	}{
+		{PluralRuleOne, 1, PluralOne},
+		{PluralRuleOne, 0, PluralOther},
+		{PluralRuleZeroOne, 0, PluralOne},
+		{PluralRuleSlavic, 1, PluralOne},
+		{PluralRuleSlavic, 11, PluralMany},
+		{PluralRuleSlavic, 21, PluralOne},
+		{PluralRuleSlavic, 3, PluralFew},
+		{PluralRuleSlavic, 13, PluralMany},
+		{PluralRuleSlavic, 24, PluralFew},
+		{PluralRuleSlavic, 5, PluralMany},
+		{PluralRulePolish, 21, PluralMany},
+		{PluralRulePolish, 22, PluralFew},
+		{PluralRuleCzech, 3, PluralFew},
+		{PluralRuleArabic, 2, PluralTwo},
+		{PluralRuleArabic, 103, PluralFew},
+		{PluralRuleArabic, 111, PluralMany},
+		{PluralRuleArabic, 100, PluralOther},
+		{PluralRuleNone, 1, PluralOther},
+	}
+	for i, c := range cases {
+		if r := c.rule(c.n); r != c.expected {
+			t.Errorf("case %d: %d should be %s, got %s", i, c.n, c.expected, r)
+		}
+	}
+	if PluralRuleFor("pt_BR")(0) != PluralOne || PluralRuleFor("pt-PT")(0) != PluralOther || PluralRuleFor("ru-RU")(5) != PluralMany {
+		t.Error("PluralRuleFor")
+	}
+}
+
+func TestLocaleFormat(t *testing.T) {
+	cases := []struct {
+		l        *Locale
+		d        time.Duration
+		opts     FormatOptions
+		expected string
+	}{
+		{LocaleEnglish, 0, Approximate, "just now"},
+		{LocaleEnglish, time.Second, Approximate, "1 second ago"},
+		{LocaleEnglish, -90 * time.Minute, Approximate, "in 2 hours"},
+		{LocaleEnglish, 90 * time.Minute, FormatOptions{Precise: true}, "1 hour ago"},
+		{LocaleEnglish, 125*time.Minute + 40*time.Second, FormatOptions{MaxUnits: 2}, "2 hours 6 minutes ago"},
+		{LocaleEnglish, 125*time.Minute + 40*time.Second, Precise, "2 hours 5 minutes 40 seconds ago"},
+		{LocaleEnglish, 2*time.Hour + 40*time.Second, Precise, "2 hours 40 seconds ago"},
+		{LocaleEnglish, 59*time.Minute + 40*time.Second, Approximate, "1 hour ago"},
+		{LocaleEnglish, 23*time.Hour + 59*time.Minute + 40*time.Second, FormatOptions{MaxUnits: 2}, "1 day ago"},
+		{LocaleRussian, 21 * time.Minute, Approximate, "21 минуту назад"},
+		{LocaleRussian, 3 * time.Hour, Approximate, "3 часа назад"},
+		{LocaleRussian, -5 * Day, Approximate, "через 5 дней"},
+		{LocaleChinese, 2*time.Hour + 5*time.Minute, FormatOptions{MaxUnits: 2}, "2 小时 5 分钟前"},
+		{LocaleFrench, 2 * Month, Approximate, "il y a 2 mois"},
+	}
+	for i, c := range cases {
+		if s := c.l.FormatRelativeDuration(c.d, c.opts); s != c.expected {
+			t.Errorf("case %d: expected %q, got %q", i, c.expected, s)
+		}
+	}
+	if s := LocaleEnglish.FormatDuration(-3*time.Minute, Approximate); s != "3 minutes" {
+		t.Error(s)
+	}
+	if s := LocaleEnglish.FormatReference(tBase, tBase.Add(100*time.Hour), Approximate); s != "2013-08-30" {
+		t.Error(s)
+	}
+}
+
+func TestParseLocale(t *testing.T) {
+	l, err := ParseLocale("uk-UA", []byte(`
+hello: world
+ago:
+  past: "{{0}} тому"
+  future: "через {{0}}"
+  now: "щойно"
+  max: 48h
+  layout: "02.01.2006"
+  units:
+    hour:
+      one: "{{0}} годину"
+      few: "{{0}} години"
+      many: "{{0}} годин"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := l.FormatRelativeDuration(22*time.Hour, Approximate); s != "22 години тому" {
+		t.Error(s)
+	}
+	if s := l.FormatRelativeDuration(-11*time.Hour, Approximate); s != "через 11 годин" {
+		t.Error(s)
+	}
+	if s := l.FormatReference(tBase, tBase.Add(48*time.Hour), Approximate); s != "30.08.2013" {
+		t.Error(s)
+	}
+	if _, err = ParseLocale("xx", []byte("hello: world")); err == nil {
+		t.Error("missing ago.past should fail")
+	}
+}
+
+func TestLookupLocale(t *testing.T) {
+	if l, ok := LookupLocale("ru_RU"); !ok || l != LocaleRussian {
+		t.Error("ru_RU")
+	}
+	if l, ok := LookupLocale("zh-CN"); !ok || l != LocaleChinese {
+		t.Error("zh-CN")
+	}
+	if _, ok := LookupLocale("xx-XX"); ok {
+		t.Error("xx-XX")
+	}
+}
@@ -0,0 +1,123 @@
+package ago
+
+import "time"
+
+func forms(kv ...string) map[PluralCategory]string {
+	m := map[PluralCategory]string{}
+	for i := 0; i+1 < len(kv); i += 2 {
+		m[PluralCategory(kv[i])] = kv[i+1]
+	}
+	return m
+}
+
+// Predefined locale packs, registered for LookupLocale
+var (
+	LocaleEnglish = &Locale{
+		Name:   "en",
+		Past:   "{{0}} ago",
+		Future: "in {{0}}",
+		Now:    "just now",
+		Units: map[string]map[PluralCategory]string{
+			"year":   forms("one", "{{0}} year", "other", "{{0}} years"),
+			"month":  forms("one", "{{0}} month", "other", "{{0}} months"),
+			"day":    forms("one", "{{0}} day", "other", "{{0}} days"),
+			"hour":   forms("one", "{{0}} hour", "other", "{{0}} hours"),
+			"minute": forms("one", "{{0}} minute", "other", "{{0}} minutes"),
+			"second": forms("one", "{{0}} second", "other", "{{0}} seconds"),
+		},
+		Max:    73 * time.Hour,
+		Layout: "2006-01-02",
+	}
+
+	LocalePortuguese = &Locale{
+		Name:   "pt",
+		Past:   "há {{0}}",
+		Future: "daqui a {{0}}",
+		Now:    "agora mesmo",
+		Units: map[string]map[PluralCategory]string{
+			"year":   forms("one", "{{0}} ano", "other", "{{0}} anos"),
+			"month":  forms("one", "{{0}} mês", "other", "{{0}} meses"),
+			"day":    forms("one", "{{0}} dia", "other", "{{0}} dias"),
+			"hour":   forms("one", "{{0}} hora", "other", "{{0}} horas"),
+			"minute": forms("one", "{{0}} minuto", "other", "{{0}} minutos"),
+			"second": forms("one", "{{0}} segundo", "other", "{{0}} segundos"),
+		},
+		Max:    73 * time.Hour,
+		Layout: "02-01-2006",
+	}
+
+	LocaleChinese = &Locale{
+		Name:      "zh",
+		Past:      "{{0}}前",
+		Future:    "{{0}}后",
+		Now:       "刚刚",
+		Separator: " ",
+		Units: map[string]map[PluralCategory]string{
+			"year":   forms("other", "{{0}} 年"),
+			"month":  forms("other", "{{0}} 个月"),
+			"day":    forms("other", "{{0}} 天"),
+			"hour":   forms("other", "{{0}} 小时"),
+			"minute": forms("other", "{{0}} 分钟"),
+			"second": forms("other", "{{0}} 秒"),
+		},
+		Max:    73 * time.Hour,
+		Layout: "2006-01-02",
+	}
+
+	LocaleFrench = &Locale{
+		Name:   "fr",
+		Past:   "il y a {{0}}",
+		Future: "dans {{0}}",
+		Now:    "à l'instant",
+		Units: map[string]map[PluralCategory]string{
+			"year":   forms("one", "{{0}} an", "other", "{{0}} ans"),
+			"month":  forms("other", "{{0}} mois"),
+			"day":    forms("one", "{{0}} jour", "other", "{{0}} jours"),
+			"hour":   forms("one", "{{0}} heure", "other", "{{0}} heures"),
+			"minute": forms("one", "{{0}} minute", "other", "{{0}} minutes"),
+			"second": forms("one", "{{0}} seconde", "other", "{{0}} secondes"),
+		},
+		Max:    73 * time.Hour,
+		Layout: "02/01/2006",
+	}
+
+	LocaleGerman = &Locale{
+		Name:   "de",
+		Past:   "vor {{0}}",
+		Future: "in {{0}}",
+		Now:    "gerade eben",
+		Units: map[string]map[PluralCategory]string{
+			"year":   forms("one", "{{0}} Jahr", "other", "{{0}} Jahren"),
+			"month":  forms("one", "{{0}} Monat", "other", "{{0}} Monaten"),
+			"day":    forms("one", "{{0}} Tag", "other", "{{0}} Tagen"),
+			"hour":   forms("one", "{{0}} Stunde", "other", "{{0}} Stunden"),
+			"minute": forms("one", "{{0}} Minute", "other", "{{0}} Minuten"),
+			"second": forms("one", "{{0}} Sekunde", "other", "{{0}} Sekunden"),
+		},
+		Max:    73 * time.Hour,
+		Layout: "02.01.2006",
+	}
+
+	LocaleRussian = &Locale{
+		Name:   "ru",
+		Past:   "{{0}} назад",
+		Future: "через {{0}}",
+		Now:    "только что",
+		Units: map[string]map[PluralCategory]string{
+			"year":   forms("one", "{{0}} год", "few", "{{0}} года", "many", "{{0}} лет"),
+			"month":  forms("one", "{{0}} месяц", "few", "{{0}} месяца", "many", "{{0}} месяцев"),
+			"day":    forms("one", "{{0}} день", "few", "{{0}} дня", "many", "{{0}} дней"),
+			"hour":   forms("one", "{{0}} час", "few", "{{0}} часа", "many", "{{0}} часов"),
+			"minute": forms("one", "{{0}} минуту", "few", "{{0}} минуты", "many", "{{0}} минут"),
+			"second": forms("one", "{{0}} секунду", "few", "{{0}} секунды", "many", "{{0}} секунд"),
+		},
+		Max:    73 * time.Hour,
+		Layout: "02.01.2006",
+	}
+)
+
+func init() {
+	for _, l := range []*Locale{LocaleEnglish, LocalePortuguese, LocaleChinese, LocaleFrench, LocaleGerman, LocaleRussian} {
+		RegisterLocale(l)
+	}
+}
@@ -0,0 +1,129 @@
+package ago
+
+import "strings"
+
+// PluralCategory CLDR plural category
+type PluralCategory string
+
+// CLDR plural categories
+const (
+	PluralZero  PluralCategory = "zero"
+	PluralOne   PluralCategory = "one"
+	PluralTwo   PluralCategory = "two"
+	PluralFew   PluralCategory = "few"
+	PluralMany  PluralCategory = "many"
+	PluralOther PluralCategory = "other"
+)
+
+// PluralRule returns plural category of an integer
+type PluralRule func(n int64) PluralCategory
+
+// PluralRuleNone no plural forms, e.g. Chinese, Japanese, Korean
+func PluralRuleNone(n int64) PluralCategory {
+	return PluralOther
+}
+
+// PluralRuleOne one for 1, e.g. English, German, Dutch, Italian, Spanish
+func PluralRuleOne(n int64) PluralCategory {
+	if n == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+// PluralRuleZeroOne one for 0 and 1, e.g. French, Brazilian Portuguese
+func PluralRuleZeroOne(n int64) PluralCategory {
+	if n == 0 || n == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+// PluralRuleSlavic one, few and many, e.g. Russian, Ukrainian
+func PluralRuleSlavic(n int64) PluralCategory {
+	m10, m100 := n%10, n%100
+	switch {
+	case m10 == 1 && m100 != 11:
+		return PluralOne
+	case m10 >= 2 && m10 <= 4 && (m100 < 12 || m100 > 14):
+		return PluralFew
+	}
+	return PluralMany
+}
+
+// PluralRulePolish one, few and many of Polish
+func PluralRulePolish(n int64) PluralCategory {
+	m10, m100 := n%10, n%100
+	switch {
+	case n == 1:
+		return PluralOne
+	case m10 >= 2 && m10 <= 4 && (m100 < 12 || m100 > 14):
+		return PluralFew
+	}
+	return PluralMany
+}
+
+// PluralRuleCzech one, few and other of Czech and Slovak
+func PluralRuleCzech(n int64) PluralCategory {
+	switch {
+	case n == 1:
+		return PluralOne
+	case n >= 2 && n <= 4:
+		return PluralFew
+	}
+	return PluralOther
+}
+
+// PluralRuleArabic all six categories of Arabic
+func PluralRuleArabic(n int64) PluralCategory {
+	m100 := n % 100
+	switch {
+	case n == 0:
+		return PluralZero
+	case n == 1:
+		return PluralOne
+	case n == 2:
+		return PluralTwo
+	case m100 >= 3 && m100 <= 10:
+		return PluralFew
+	case m100 >= 11:
+		return PluralMany
+	}
+	return PluralOther
+}
+
+// PluralRules plural rules by language, see PluralRuleFor
+var PluralRules = map[string]PluralRule{
+	"en":    PluralRuleOne,
+	"de":    PluralRuleOne,
+	"nl":    PluralRuleOne,
+	"it":    PluralRuleOne,
+	"es":    PluralRuleOne,
+	"pt":    PluralRuleOne,
+	"pt-br": PluralRuleZeroOne,
+	"fr":    PluralRuleZeroOne,
+	"ru":    PluralRuleSlavic,
+	"uk":    PluralRuleSlavic,
+	"pl":    PluralRulePolish,
+	"cs":    PluralRuleCzech,
+	"sk":    PluralRuleCzech,
+	"ar":    PluralRuleArabic,
+	"zh":    PluralRuleNone,
+	"ja":    PluralRuleNone,
+	"ko":    PluralRuleNone,
+}
+
+// PluralRuleFor returns plural rule of a language tag like "pt-BR", falls back to the base language,
+// and PluralRuleOne if not found
+func PluralRuleFor(tag string) PluralRule {
+	tag = strings.ToLower(strings.Replace(tag, "_", "-", -1))
+	if r := PluralRules[tag]; r != nil {
+		return r
+	}
+	if i := strings.Index(tag, "-"); i > 0 {
+		if r := PluralRules[tag[:i]]; r != nil {
+			return r
+		}
+	}
+	return PluralRuleOne
+}
@@ -0,0 +1,242 @@
+package now
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"landzero.net/x/encoding/yaml"
+)
+
+const (
+	calendarDateFormat   = "2006-01-02"
+	calendarAnnualFormat = "01-02"
+
+	// maxNonBusinessDays business day methods give up after so many consecutive non-business days
+	maxNonBusinessDays = 366
+)
+
+// Calendar decides business days
+type Calendar interface {
+	IsBusinessDay(t time.Time) bool
+}
+
+// BusinessCalendar calendar used by business day methods of Now, default is weekends only
+var BusinessCalendar Calendar = NewHolidayCalendar("default")
+
+// HolidayCalendar a calendar with weekends, holidays and make-up workdays,
+// workdays override holidays, holidays override weekends
+type HolidayCalendar struct {
+	Name     string
+	Weekend  []time.Weekday
+	holidays map[string]string
+	annual   map[string]string
+	workdays map[string]string
+}
+
+// NewHolidayCalendar create a calendar without holidays, Saturday and Sunday are weekend
+func NewHolidayCalendar(name string) *HolidayCalendar {
+	return &HolidayCalendar{
+		Name:     name,
+		Weekend:  []time.Weekday{time.Saturday, time.Sunday},
+		holidays: map[string]string{},
+		annual:   map[string]string{},
+		workdays: map[string]string{},
+	}
+}
+
+// AddHoliday add a holiday on the date of t
+func (c *HolidayCalendar) AddHoliday(t time.Time, name string) {
+	c.holidays[t.Format(calendarDateFormat)] = name
+}
+
+// AddAnnualHoliday add a holiday on the month and day of every year
+func (c *HolidayCalendar) AddAnnualHoliday(month time.Month, day int, name string) {
+	c.annual[fmt.Sprintf("%02d-%02d", month, day)] = name
+}
+
+// AddWorkday add a make-up workday on the date of t, even if it's weekend or holiday
+func (c *HolidayCalendar) AddWorkday(t time.Time, name string) {
+	c.workdays[t.Format(calendarDateFormat)] = name
+}
+
+// Holiday returns name of the holiday on the date of t
+func (c *HolidayCalendar) Holiday(t time.Time) (name string, ok bool) {
+	if name, ok = c.holidays[t.Format(calendarDateFormat)]; ok {
+		return
+	}
+	name, ok = c.annual[t.Format(calendarAnnualFormat)]
+	return
+}
+
+// IsWeekend returns true if t is weekend
+func (c *HolidayCalendar) IsWeekend(t time.Time) bool {
+	for _, wd := range c.Weekend {
+		if t.Weekday() == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBusinessDay implements Calendar
+func (c *HolidayCalendar) IsBusinessDay(t time.Time) bool {
+	if _, ok := c.workdays[t.Format(calendarDateFormat)]; ok {
+		return true
+	}
+	if _, ok := c.Holiday(t); ok {
+		return false
+	}
+	return !c.IsWeekend(t)
+}
+
+type calendarDay struct {
+	Date string `yaml:"date"`
+	Name string `yaml:"name"`
+}
+
+type calendarFile struct {
+	Name     string        `yaml:"name"`
+	Weekend  []string      `yaml:"weekend"`
+	Holidays []calendarDay `yaml:"holidays"`
+	Workdays []calendarDay `yaml:"workdays"`
+}
+
+// ParseCalendar parse a HolidayCalendar from YAML, for example
+//
+//	name: cn
+//	weekend: [saturday, sunday]
+//	holidays:
+//	  - date: 01-01          # every year
+//	    name: New Year
+//	  - date: 2019-10-01     # only in 2019
+//	    name: National Day
+//	workdays:
+//	  - date: 2019-09-29
+//	    name: National Day
+//
+// weekend defaults to Saturday and Sunday if omitted, and can not be all seven days
+func ParseCalendar(data []byte) (c *HolidayCalendar, err error) {
+	var cf calendarFile
+	if err = yaml.Unmarshal(data, &cf); err != nil {
+		return
+	}
+	cal := NewHolidayCalendar(cf.Name)
+	if cf.Weekend != nil {
+		cal.Weekend = nil
+		weekend := map[time.Weekday]bool{}
+		for _, s := range cf.Weekend {
+			var wd time.Weekday
+			if wd, err = parseWeekday(s); err != nil {
+				return
+			}
+			weekend[wd] = true
+			cal.Weekend = append(cal.Weekend, wd)
+		}
+		if len(weekend) == 7 {
+			err = fmt.Errorf("now: no business day in weekend %v", cf.Weekend)
+			return
+		}
+	}
+	for _, d := range cf.Holidays {
+		var t time.Time
+		if t, err = time.Parse(calendarDateFormat, d.Date); err == nil {
+			cal.AddHoliday(t, d.Name)
+			continue
+		}
+		if t, err = time.Parse(calendarAnnualFormat, d.Date); err != nil {
+			err = fmt.Errorf("now: invalid holiday date %q", d.Date)
+			return
+		}
+		cal.AddAnnualHoliday(t.Month(), t.Day(), d.Name)
+	}
+	for _, d := range cf.Workdays {
+		var t time.Time
+		if t, err = time.Parse(calendarDateFormat, d.Date); err != nil {
+			err = fmt.Errorf("now: invalid workday date %q", d.Date)
+			return
+		}
+		cal.AddWorkday(t, d.Name)
+	}
+	c = cal
+	return
+}
+
+// LoadCalendar load a HolidayCalendar from YAML file, see ParseCalendar
+func LoadCalendar(filename string) (c *HolidayCalendar, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(filename); err != nil {
+		return
+	}
+	return ParseCalendar(data)
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		name := strings.ToLower(wd.String())
+		if l := strings.ToLower(s); l == name || l == name[:3] {
+			return wd, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("now: invalid weekday %q", s)
+}
+
+// IsBusinessDay returns true if now is a business day of BusinessCalendar
+func (now *Now) IsBusinessDay() bool {
+	return BusinessCalendar.IsBusinessDay(now.Time)
+}
+
+// NextBusinessDay beginning of the next business day after now,
+// zero time if there is no business day within a year
+func (now *Now) NextBusinessDay() time.Time {
+	return addBusinessDays(now.BeginningOfDay(), 1)
+}
+
+// PreviousBusinessDay beginning of the last business day before now,
+// zero time if there is no business day within a year
+func (now *Now) PreviousBusinessDay() time.Time {
+	return addBusinessDays(now.BeginningOfDay(), -1)
+}
+
+// AddBusinessDays add n business days, negative n goes backward, clock time is kept,
+// e.g. both Friday and Saturday plus 1 business day are Monday,
+// zero time if there is a year without business day on the way
+func (now *Now) AddBusinessDays(n int) time.Time {
+	return addBusinessDays(now.Time, n)
+}
+
+func addBusinessDays(t time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for gap := 0; n > 0; {
+		if gap++; gap > maxNonBusinessDays {
+			return time.Time{}
+		}
+		t = t.AddDate(0, 0, step)
+		if BusinessCalendar.IsBusinessDay(t) {
+			n--
+			gap = 0
+		}
+	}
+	return t
+}
+
+// BusinessDaysUntil count business days in [now, end), by date, negative if end is before now
+func (now *Now) BusinessDaysUntil(end time.Time) (n int) {
+	begin := now.BeginningOfDay()
+	end = New(end.In(now.Location())).BeginningOfDay()
+	sign := 1
+	if end.Before(begin) {
+		begin, end, sign = end, begin, -1
+	}
+	for t := begin; t.Before(end); t = t.AddDate(0, 0, 1) {
+		if BusinessCalendar.IsBusinessDay(t) {
+			n++
+		}
+	}
+	n *= sign
+	return
+}
@@ -0,0 +1,161 @@
+package now
+
+import (
+	"testing"
+	"time"
+)
+
+var testCalendarYAML = []byte(`
+name: test
+weekend: [saturday, sun]
+holidays:
+  - date: 01-01
+    name: New Year
+  - date: 2019-10-01
+    name: National Day
+  - date: 2019-10-02
+    name: National Day
+workdays:
+  - date: 2019-09-29
+    name: National Day
+`)
+
+func withCalendar(t *testing.T) func() {
+	c, err := ParseCalendar(testCalendarYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := BusinessCalendar
+	BusinessCalendar = c
+	return func() { BusinessCalendar = old }
+}
+
+func TestParseCalendar(t *testing.T) {
+	c, err := ParseCalendar(testCalendarYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "test" || len(c.Weekend) != 2 {
+		t.Fatal(c)
+	}
+	if name, ok := c.Holiday(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)); !ok || name != "New Year" {
+		t.Error("annual holiday not found")
+	}
+	cases := map[string]bool{
+		"2019-09-27": true,  // friday
+		"2019-09-28": false, // saturday
+		"2019-09-29": true,  // make-up workday on sunday
+		"2019-09-30": true,
+		"2019-10-01": false, // holiday
+		"2020-10-01": true,  // not annual
+	}
+	for s, expected := range cases {
+		d, _ := time.Parse("2006-01-02", s)
+		if c.IsBusinessDay(d) != expected {
+			t.Errorf("IsBusinessDay(%s) should be %v", s, expected)
+		}
+	}
+	if _, err := ParseCalendar([]byte("weekend: [someday]")); err == nil {
+		t.Error("invalid weekday should fail")
+	}
+	if _, err := ParseCalendar([]byte("weekend: [mon, tue, wed, thu, fri, sat, sun]\n")); err == nil {
+		t.Error("weekend of seven days should fail")
+	}
+	if _, err := ParseCalendar([]byte("holidays: [{date: 2019/10/01}]")); err == nil {
+		t.Error("invalid date should fail")
+	}
+}
+
+func TestBusinessDays(t *testing.T) {
+	defer withCalendar(t)()
+	assert := assertT(t)
+
+	fri := time.Date(2019, 9, 27, 9, 30, 0, 0, time.UTC)
+	sat := time.Date(2019, 9, 28, 9, 30, 0, 0, time.UTC)
+	assert(New(fri).AddBusinessDays(1), "2019-09-29 09:30:00", "AddBusinessDays to make-up workday")
+	assert(New(fri).AddBusinessDays(3), "2019-10-03 09:30:00", "AddBusinessDays over holidays")
+	assert(New(sat).AddBusinessDays(1), "2019-09-29 09:30:00", "AddBusinessDays from weekend")
+	assert(New(fri).AddBusinessDays(-1), "2019-09-26 09:30:00", "AddBusinessDays backward")
+	assert(New(fri).AddBusinessDays(0), "2019-09-27 09:30:00", "AddBusinessDays zero")
+	assert(New(time.Date(2019, 9, 30, 12, 0, 0, 0, time.UTC)).NextBusinessDay(), "2019-10-03 00:00:00", "NextBusinessDay")
+	assert(New(time.Date(2019, 10, 3, 12, 0, 0, 0, time.UTC)).PreviousBusinessDay(), "2019-09-30 00:00:00", "PreviousBusinessDay")
+
+	if !New(fri).IsBusinessDay() || New(sat).IsBusinessDay() {
+		t.Error("IsBusinessDay")
+	}
+	end := time.Date(2019, 10, 4, 0, 0, 0, 0, time.UTC)
+	if n := New(fri).BusinessDaysUntil(end); n != 4 {
+		t.Errorf("BusinessDaysUntil should be 4, got %d", n)
+	}
+	if n := New(end).BusinessDaysUntil(fri); n != -4 {
+		t.Errorf("BusinessDaysUntil should be -4, got %d", n)
+	}
+
+	// no business day at all
+	BusinessCalendar = &HolidayCalendar{Weekend: []time.Weekday{0, 1, 2, 3, 4, 5, 6}}
+	for _, d := range []time.Time{New(fri).NextBusinessDay(), New(fri).PreviousBusinessDay(), New(fri).AddBusinessDays(2)} {
+		if !d.IsZero() {
+			t.Error("expect zero time without business day, got", d)
+		}
+	}
+}
+
+func TestFiscal(t *testing.T) {
+	assert := assertT(t)
+	defer func() { FiscalYearStartMonth = time.January }()
+
+	n := New(time.Date(2019, 11, 18, 17, 51, 49, 0, time.UTC))
+	if n.FiscalYear() != 2019 || n.FiscalQuarter() != 4 {
+		t.Error("calendar fiscal year", n.FiscalYear(), n.FiscalQuarter())
+	}
+
+	FiscalYearStartMonth = time.October
+	if n.FiscalYear() != 2020 || n.FiscalQuarter() != 1 {
+		t.Error("october fiscal year", n.FiscalYear(), n.FiscalQuarter())
+	}
+	assert(n.BeginningOfFiscalYear(), "2019-10-01 00:00:00", "BeginningOfFiscalYear")
+	assert(n.EndOfFiscalYear(), "2020-09-30 23:59:59.999999999", "EndOfFiscalYear")
+	assert(n.BeginningOfFiscalQuarter(), "2019-10-01 00:00:00", "BeginningOfFiscalQuarter")
+	assert(n.EndOfFiscalQuarter(), "2019-12-31 23:59:59.999999999", "EndOfFiscalQuarter")
+
+	FiscalYearStartMonth = time.April
+	n = New(time.Date(2020, 2, 10, 0, 0, 0, 0, time.UTC))
+	if n.FiscalYear() != 2020 || n.FiscalQuarter() != 4 {
+		t.Error("april fiscal year", n.FiscalYear(), n.FiscalQuarter())
+	}
+	assert(n.BeginningOfFiscalQuarter(), "2020-01-01 00:00:00", "BeginningOfFiscalQuarter")
+}
+
+func TestISOWeek(t *testing.T) {
+	assert := assertT(t)
+	// sunday, in ISO week 2019-W52
+	n := New(time.Date(2019, 12, 29, 10, 0, 0, 0, time.UTC))
+	assert(n.BeginningOfISOWeek(), "2019-12-23 00:00:00", "BeginningOfISOWeek")
+	assert(n.EndOfISOWeek(), "2019-12-29 23:59:59.999999999", "EndOfISOWeek")
+	assert(ISOWeekStart(2020, 1, time.UTC), "2019-12-30 00:00:00", "ISOWeekStart")
+	assert(ISOWeekStart(2015, 53, time.UTC), "2015-12-28 00:00:00", "ISOWeekStart week 53")
+	if y, w := ISOWeekStart(2021, 10, time.UTC).ISOWeek(); y != 2021 || w != 10 {
+		t.Error("ISOWeekStart round trip", y, w)
+	}
+}
+
+func TestEach(t *testing.T) {
+	defer withCalendar(t)()
+	begin := time.Date(2019, 9, 27, 15, 0, 0, 0, time.UTC)
+	end := time.Date(2019, 10, 3, 1, 0, 0, 0, time.UTC)
+	if ts := EachDay(begin, end); len(ts) != 7 || ts[0].Format(format) != "2019-09-27 00:00:00" || ts[6].Day() != 3 {
+		t.Error("EachDay", ts)
+	}
+	if ts := EachBusinessDay(begin, end); len(ts) != 4 {
+		t.Error("EachBusinessDay", ts)
+	}
+	if ts := EachWeek(begin, end); len(ts) != 2 || ts[1].Format(format) != "2019-09-29 00:00:00" {
+		t.Error("EachWeek", ts)
+	}
+	if ts := EachMonth(time.Date(2019, 1, 31, 0, 0, 0, 0, time.UTC), end); len(ts) != 10 || ts[1].Month() != time.February {
+		t.Error("EachMonth", ts)
+	}
+	if ts := EachDay(end, begin); len(ts) != 0 {
+		t.Error("EachDay with end before begin", ts)
+	}
+}
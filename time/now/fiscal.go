@@ -0,0 +1,61 @@
+package now
+
+import "time"
+
+// FiscalYearStartMonth set fiscal year start month, default is january
+var FiscalYearStartMonth = time.January
+
+// FiscalYear fiscal year of now, named by the calendar year it ends in,
+// e.g. with FiscalYearStartMonth October, 2019-10-01 is in fiscal year 2020
+func (now *Now) FiscalYear() int {
+	return now.EndOfFiscalYear().Year()
+}
+
+// BeginningOfFiscalYear beginning of fiscal year
+func (now *Now) BeginningOfFiscalYear() time.Time {
+	y, m, _ := now.Date()
+	if m < FiscalYearStartMonth {
+		y--
+	}
+	return time.Date(y, FiscalYearStartMonth, 1, 0, 0, 0, 0, now.Location())
+}
+
+// EndOfFiscalYear end of fiscal year
+func (now *Now) EndOfFiscalYear() time.Time {
+	return now.BeginningOfFiscalYear().AddDate(1, 0, 0).Add(-time.Nanosecond)
+}
+
+// FiscalQuarter fiscal quarter of now, 1 to 4
+func (now *Now) FiscalQuarter() int {
+	offset := (int(now.Month()) - int(FiscalYearStartMonth) + 12) % 12
+	return offset/3 + 1
+}
+
+// BeginningOfFiscalQuarter beginning of fiscal quarter
+func (now *Now) BeginningOfFiscalQuarter() time.Time {
+	return now.BeginningOfFiscalYear().AddDate(0, (now.FiscalQuarter()-1)*3, 0)
+}
+
+// EndOfFiscalQuarter end of fiscal quarter
+func (now *Now) EndOfFiscalQuarter() time.Time {
+	return now.BeginningOfFiscalQuarter().AddDate(0, 3, 0).Add(-time.Nanosecond)
+}
+
+// BeginningOfISOWeek beginning of ISO 8601 week, which always starts on monday regardless of WeekStartDay
+func (now *Now) BeginningOfISOWeek() time.Time {
+	t := now.BeginningOfDay()
+	weekday := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -weekday)
+}
+
+// EndOfISOWeek end of ISO 8601 week
+func (now *Now) EndOfISOWeek() time.Time {
+	return now.BeginningOfISOWeek().AddDate(0, 0, 7).Add(-time.Nanosecond)
+}
+
+// ISOWeekStart beginning of the given ISO 8601 week in loc
+func ISOWeekStart(year, week int, loc *time.Location) time.Time {
+	// January 4th is always in week 1
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	return New(jan4).BeginningOfISOWeek().AddDate(0, 0, (week-1)*7)
+}
@@ -112,6 +112,66 @@ func EndOfSunday() time.Time {
 	return New(time.Now()).EndOfSunday()
 }
 
+// IsBusinessDay is today a business day
+func IsBusinessDay() bool {
+	return New(time.Now()).IsBusinessDay()
+}
+
+// NextBusinessDay next business day
+func NextBusinessDay() time.Time {
+	return New(time.Now()).NextBusinessDay()
+}
+
+// PreviousBusinessDay previous business day
+func PreviousBusinessDay() time.Time {
+	return New(time.Now()).PreviousBusinessDay()
+}
+
+// AddBusinessDays add business days to now
+func AddBusinessDays(n int) time.Time {
+	return New(time.Now()).AddBusinessDays(n)
+}
+
+// FiscalYear fiscal year
+func FiscalYear() int {
+	return New(time.Now()).FiscalYear()
+}
+
+// FiscalQuarter fiscal quarter
+func FiscalQuarter() int {
+	return New(time.Now()).FiscalQuarter()
+}
+
+// BeginningOfFiscalYear beginning of fiscal year
+func BeginningOfFiscalYear() time.Time {
+	return New(time.Now()).BeginningOfFiscalYear()
+}
+
+// EndOfFiscalYear end of fiscal year
+func EndOfFiscalYear() time.Time {
+	return New(time.Now()).EndOfFiscalYear()
+}
+
+// BeginningOfFiscalQuarter beginning of fiscal quarter
+func BeginningOfFiscalQuarter() time.Time {
+	return New(time.Now()).BeginningOfFiscalQuarter()
+}
+
+// EndOfFiscalQuarter end of fiscal quarter
+func EndOfFiscalQuarter() time.Time {
+	return New(time.Now()).EndOfFiscalQuarter()
+}
+
+// BeginningOfISOWeek beginning of ISO 8601 week
+func BeginningOfISOWeek() time.Time {
+	return New(time.Now()).BeginningOfISOWeek()
+}
+
+// EndOfISOWeek end of ISO 8601 week
+func EndOfISOWeek() time.Time {
+	return New(time.Now()).EndOfISOWeek()
+}
+
 // Parse parse string to time
 func Parse(strs ...string) (time.Time, error) {
 	return New(time.Now()).Parse(strs...)
@@ -0,0 +1,481 @@
+package now
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// ErrUnrecognized natural-language string can't be parsed
+	ErrUnrecognized = errors.New("now: unrecognized time expression")
+	// ErrUnknownLocale locale is not registered
+	ErrUnknownLocale = errors.New("now: unknown locale")
+)
+
+// DefaultLocale locale used by ParseNatural
+var DefaultLocale = "en"
+
+// Range a time range, both Begin and End are included, Begin equals End for a point in time
+type Range struct {
+	Begin time.Time
+	End   time.Time
+}
+
+// IsPoint returns true if the range is a point in time
+func (r Range) IsPoint() bool {
+	return r.Begin.Equal(r.End)
+}
+
+// Contains returns true if t is in the range
+func (r Range) Contains(t time.Time) bool {
+	return !t.Before(r.Begin) && !t.After(r.End)
+}
+
+// Locale translates a locale-specific expression into the English expression understood by ParseNatural
+type Locale interface {
+	Normalize(s string) string
+}
+
+// WordsLocale a Locale replacing words or phrases with English ones, the input is lower cased,
+// the longest match wins at every position, and replacements are separated by spaces,
+// so it works with languages without spaces between words. Words of languages with spaces
+// are only replaced as a whole, ie: "mon" doesn't match "month".
+type WordsLocale map[string]string
+
+// Normalize implements Locale
+func (wl WordsLocale) Normalize(s string) string {
+	keys := make([]string, 0, len(wl))
+	for k := range wl {
+		keys = append(keys, strings.ToLower(k))
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+	lower := make(map[string]string, len(wl))
+	for k, v := range wl {
+		lower[strings.ToLower(k)] = v
+	}
+	s = strings.ToLower(s)
+	b := &strings.Builder{}
+outer:
+	for i := 0; i < len(s); {
+		for _, k := range keys {
+			if len(k) > 0 && strings.HasPrefix(s[i:], k) && isWholeWord(s[:i], k, s[i+len(k):]) {
+				b.WriteString(" " + lower[k] + " ")
+				i += len(k)
+				continue outer
+			}
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// isWholeWord returns false if k between before and after is part of a longer word
+func isWholeWord(before, k, after string) bool {
+	first, _ := utf8.DecodeRuneInString(k)
+	last, _ := utf8.DecodeLastRuneInString(k)
+	prev, _ := utf8.DecodeLastRuneInString(before)
+	next, _ := utf8.DecodeRuneInString(after)
+	return !(isWordRune(first) && isWordRune(prev)) && !(isWordRune(last) && isWordRune(next))
+}
+
+// isWordRune returns true if r is a letter or digit of languages separating words by spaces
+func isWordRune(r rune) bool {
+	if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+		return false
+	}
+	return !unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Thai)
+}
+
+var (
+	localesMtx = &sync.RWMutex{}
+	locales    = map[string]Locale{
+		"en": WordsLocale{
+			"tmr":         "tomorrow",
+			"tmrw":        "tomorrow",
+			"yday":        "yesterday",
+			"fortnight":   "2 weeks",
+			"a fortnight": "2 weeks",
+		},
+	}
+)
+
+// RegisterLocale register a locale for ParseNaturalInLocale
+func RegisterLocale(name string, l Locale) {
+	localesMtx.Lock()
+	defer localesMtx.Unlock()
+	locales[name] = l
+}
+
+// ParseNatural parse a natural-language or relative time expression in DefaultLocale, anchored on now, supported:
+//
+//	now, today, yesterday, tomorrow
+//	-90m, +2h30m, 3d, 2w                   durations, d for day, w for week
+//	in 2 weeks, 3 days ago, a month ago, in a fortnight
+//	this|next|last day|week|month|quarter|year
+//	monday, this|next|last monday
+//	first|last day of [this|next|last] week|month|quarter|year
+//	beginning|start|end of [this|next|last] day|week|month|quarter|year
+//
+// all above can be followed by a time of day, like "3pm", "15:04", "at 9:30am", "noon" or "midnight",
+// expressions of day, week and so on return a range, others return a point
+func (now *Now) ParseNatural(s string) (Range, error) {
+	return now.ParseNaturalInLocale(DefaultLocale, s)
+}
+
+// ParseNaturalInLocale parse a natural-language expression in the given locale, see ParseNatural
+func (now *Now) ParseNaturalInLocale(locale string, s string) (r Range, err error) {
+	localesMtx.RLock()
+	l := locales[locale]
+	localesMtx.RUnlock()
+	if l == nil {
+		err = ErrUnknownLocale
+		return
+	}
+	s = strings.TrimSpace(strings.ToLower(l.Normalize(s)))
+	if d, ok := parseShortDuration(s); ok {
+		t := now.Add(d)
+		r = Range{t, t}
+		return
+	}
+	var tokens []string
+	for _, tk := range strings.Fields(s) {
+		if tk != "at" && tk != "the" && tk != "," {
+			tokens = append(tokens, strings.TrimSuffix(tk, ","))
+		}
+	}
+	// time of day suffix
+	var clock []int
+	var ok bool
+	if n := len(tokens); n > 1 && (tokens[n-1] == "am" || tokens[n-1] == "pm") {
+		tokens = append(tokens[:n-2], tokens[n-2]+tokens[n-1])
+	}
+	if n := len(tokens); n > 0 {
+		if clock, ok = parseClock(tokens[n-1]); ok {
+			tokens = tokens[:n-1]
+		}
+	}
+	if len(tokens) == 0 {
+		if clock == nil {
+			err = ErrUnrecognized
+			return
+		}
+		tokens = []string{"today"}
+	}
+	if r, ok = now.parseNaturalDay(tokens); !ok {
+		err = ErrUnrecognized
+		return
+	}
+	if clock != nil {
+		y, m, d := r.Begin.Date()
+		t := time.Date(y, m, d, clock[0], clock[1], clock[2], 0, now.Location())
+		r = Range{t, t}
+	}
+	return
+}
+
+// ParseNatural parse a natural-language expression anchored on current time, see Now.ParseNatural
+func ParseNatural(s string) (Range, error) {
+	return New(time.Now()).ParseNatural(s)
+}
+
+var naturalNumbers = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+	"seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+}
+
+func parseNaturalNumber(s string) (int, bool) {
+	if n, ok := naturalNumbers[s]; ok {
+		return n, true
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n >= 0
+}
+
+// parseShortDuration parse "-90m", "+2h30m", "3d", "2w"
+func parseShortDuration(s string) (d time.Duration, ok bool) {
+	if len(s) < 2 || strings.ContainsAny(s, " \t") {
+		return
+	}
+	sign := time.Duration(1)
+	body := s
+	switch s[0] {
+	case '-':
+		sign, body = -1, s[1:]
+	case '+':
+		body = s[1:]
+	}
+	switch {
+	case len(body) < 2 || body[0] < '0' || body[0] > '9':
+		return
+	case strings.HasSuffix(body, "d") || strings.HasSuffix(body, "w"):
+		n, err := strconv.Atoi(body[:len(body)-1])
+		if err != nil {
+			return
+		}
+		d = time.Duration(n) * time.Hour * 24
+		if strings.HasSuffix(body, "w") {
+			d *= 7
+		}
+	default:
+		var err error
+		if d, err = time.ParseDuration(body); err != nil {
+			return
+		}
+	}
+	d, ok = d*sign, true
+	return
+}
+
+// parseClock parse "3pm", "3:30pm", "15:04", "15:04:05", "noon", "midnight"
+func parseClock(s string) (c []int, ok bool) {
+	switch s {
+	case "noon":
+		return []int{12, 0, 0}, true
+	case "midnight":
+		return []int{0, 0, 0}, true
+	}
+	ampm := ""
+	if strings.HasSuffix(s, "am") || strings.HasSuffix(s, "pm") {
+		ampm, s = s[len(s)-2:], s[:len(s)-2]
+	} else if !strings.Contains(s, ":") {
+		// a bare number is not a clock
+		return
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return
+	}
+	c = []int{0, 0, 0}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || (i > 0 && (len(p) != 2 || n > 59)) {
+			return nil, false
+		}
+		c[i] = n
+	}
+	switch ampm {
+	case "":
+		if c[0] > 23 {
+			return nil, false
+		}
+	default:
+		if c[0] < 1 || c[0] > 12 {
+			return nil, false
+		}
+		c[0] %= 12
+		if ampm == "pm" {
+			c[0] += 12
+		}
+	}
+	return c, true
+}
+
+func normalizeUnit(s string) string {
+	switch s {
+	case "s", "sec", "secs", "second", "seconds":
+		return "second"
+	case "m", "min", "mins", "minute", "minutes":
+		return "minute"
+	case "h", "hr", "hrs", "hour", "hours":
+		return "hour"
+	case "d", "day", "days":
+		return "day"
+	case "w", "wk", "wks", "week", "weeks":
+		return "week"
+	case "mo", "month", "months":
+		return "month"
+	case "q", "quarter", "quarters":
+		return "quarter"
+	case "y", "yr", "yrs", "year", "years":
+		return "year"
+	}
+	return ""
+}
+
+func parseWeekdayName(s string) (time.Weekday, bool) {
+	wd, err := parseWeekday(s)
+	return wd, err == nil
+}
+
+// addUnit add n units to t
+func addUnit(t time.Time, unit string, n int) time.Time {
+	switch unit {
+	case "second":
+		return t.Add(time.Duration(n) * time.Second)
+	case "minute":
+		return t.Add(time.Duration(n) * time.Minute)
+	case "hour":
+		return t.Add(time.Duration(n) * time.Hour)
+	case "day":
+		return t.AddDate(0, 0, n)
+	case "week":
+		return t.AddDate(0, 0, 7*n)
+	case "month":
+		return t.AddDate(0, n, 0)
+	case "quarter":
+		return t.AddDate(0, 3*n, 0)
+	case "year":
+		return t.AddDate(n, 0, 0)
+	}
+	return t
+}
+
+// periodRange range of the period containing t
+func periodRange(t time.Time, unit string) (r Range, ok bool) {
+	n := New(t)
+	switch unit {
+	case "day":
+		r = Range{n.BeginningOfDay(), n.EndOfDay()}
+	case "week":
+		r = Range{n.BeginningOfWeek(), n.EndOfWeek()}
+	case "month":
+		r = Range{n.BeginningOfMonth(), n.EndOfMonth()}
+	case "quarter":
+		r = Range{n.BeginningOfQuarter(), n.EndOfQuarter()}
+	case "year":
+		r = Range{n.BeginningOfYear(), n.EndOfYear()}
+	default:
+		return
+	}
+	ok = true
+	return
+}
+
+func directionOffset(s string) (int, bool) {
+	switch s {
+	case "this", "current":
+		return 0, true
+	case "next", "following":
+		return 1, true
+	case "last", "previous", "prev":
+		return -1, true
+	}
+	return 0, false
+}
+
+// shiftedPeriod parse "[this|next|last] unit" into the range of the period
+func (now *Now) shiftedPeriod(tokens []string) (r Range, ok bool) {
+	offset := 0
+	switch len(tokens) {
+	case 1:
+	case 2:
+		if offset, ok = directionOffset(tokens[0]); !ok {
+			return
+		}
+	default:
+		return
+	}
+	unit := normalizeUnit(tokens[len(tokens)-1])
+	// monthly and larger periods are shifted from the beginning, to avoid overflow of day of month
+	base := now.BeginningOfDay()
+	if unit == "month" || unit == "quarter" || unit == "year" {
+		base = now.BeginningOfMonth()
+	}
+	return periodRange(addUnit(base, unit, offset), unit)
+}
+
+func (now *Now) parseNaturalDay(tokens []string) (r Range, ok bool) {
+	point := func(t time.Time) (Range, bool) { return Range{t, t}, true }
+	day := func(t time.Time) (Range, bool) { return periodRange(t, "day") }
+	switch len(tokens) {
+	case 1:
+		switch tokens[0] {
+		case "now":
+			return point(now.Time)
+		case "today":
+			return day(now.Time)
+		case "yesterday":
+			return day(now.AddDate(0, 0, -1))
+		case "tomorrow":
+			return day(now.AddDate(0, 0, 1))
+		}
+		if wd, ok := parseWeekdayName(tokens[0]); ok {
+			return now.weekday(0, wd)
+		}
+	case 2:
+		if wd, isWeekday := parseWeekdayName(tokens[1]); isWeekday {
+			if offset, isDirection := directionOffset(tokens[0]); isDirection {
+				return now.weekday(offset, wd)
+			}
+			return
+		}
+		return now.shiftedPeriod(tokens)
+	case 3:
+		// in N units
+		if tokens[0] == "in" {
+			if n, ok := parseNaturalNumber(tokens[1]); ok {
+				if unit := normalizeUnit(tokens[2]); len(unit) > 0 {
+					return point(addUnit(now.Time, unit, n))
+				}
+			}
+			return
+		}
+		// N units ago
+		if tokens[2] == "ago" {
+			if n, ok := parseNaturalNumber(tokens[0]); ok {
+				if unit := normalizeUnit(tokens[1]); len(unit) > 0 {
+					return point(addUnit(now.Time, unit, -n))
+				}
+			}
+			return
+		}
+	}
+	// first|last day of [this|next|last] period, beginning|start|end of [this|next|last] period
+	if len(tokens) >= 3 && len(tokens) <= 5 {
+		var pr Range
+		switch {
+		case len(tokens) >= 4 && tokens[1] == "day" && tokens[2] == "of":
+			if pr, ok = now.shiftedPeriod(tokens[3:]); !ok {
+				return
+			}
+			switch tokens[0] {
+			case "first":
+				return day(pr.Begin)
+			case "last":
+				return day(pr.End)
+			}
+		case tokens[1] == "of" && len(tokens) <= 4:
+			if pr, ok = now.shiftedPeriod(tokens[2:]); !ok {
+				return
+			}
+			switch tokens[0] {
+			case "beginning", "start":
+				return point(pr.Begin)
+			case "end":
+				return point(pr.End)
+			}
+		}
+	}
+	ok = false
+	return
+}
+
+// weekday the day of weekday in the week shifted by offset, or for next and last,
+// the nearest weekday strictly after or before today
+func (now *Now) weekday(offset int, wd time.Weekday) (Range, bool) {
+	today := now.BeginningOfDay()
+	switch offset {
+	case 1:
+		diff := (int(wd) - int(today.Weekday()) + 7) % 7
+		if diff == 0 {
+			diff = 7
+		}
+		return periodRange(today.AddDate(0, 0, diff), "day")
+	case -1:
+		diff := (int(today.Weekday()) - int(wd) + 7) % 7
+		if diff == 0 {
+			diff = 7
+		}
+		return periodRange(today.AddDate(0, 0, -diff), "day")
+	}
+	begin := now.BeginningOfWeek()
+	diff := (int(wd) - int(begin.Weekday()) + 7) % 7
+	return periodRange(begin.AddDate(0, 0, diff), "day")
+}
@@ -0,0 +1,121 @@
+package now
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseNatural(t *testing.T) {
+	assert := assertT(t)
+	// wednesday
+	n := New(time.Date(2019, 11, 20, 17, 51, 49, 123456789, time.UTC))
+
+	points := map[string]string{
+		"now":                        "2019-11-20 17:51:49.123456789",
+		"-90m":                       "2019-11-20 16:21:49.123456789",
+		"+2h30m":                     "2019-11-20 20:21:49.123456789",
+		"3d":                         "2019-11-23 17:51:49.123456789",
+		"-1w":                        "2019-11-13 17:51:49.123456789",
+		"in 2 weeks":                 "2019-12-04 17:51:49.123456789",
+		"In Two Hours":               "2019-11-20 19:51:49.123456789",
+		"3 days ago":                 "2019-11-17 17:51:49.123456789",
+		"a month ago":                "2019-10-20 17:51:49.123456789",
+		"in a fortnight":             "2019-12-04 17:51:49.123456789",
+		"a fortnight ago":            "2019-11-06 17:51:49.123456789",
+		"yesterday 3pm":              "2019-11-19 15:00:00",
+		"tomorrow at 9:30am":         "2019-11-21 09:30:00",
+		"tmr noon":                   "2019-11-21 12:00:00",
+		"15:04":                      "2019-11-20 15:04:00",
+		"3 pm":                       "2019-11-20 15:00:00",
+		"next monday 12am":           "2019-11-25 00:00:00",
+		"beginning of next month":    "2019-12-01 00:00:00",
+		"end of the year":            "2019-12-31 23:59:59.999999999",
+		"start of last quarter":      "2019-07-01 00:00:00",
+		"last day of month midnight": "2019-11-30 00:00:00",
+	}
+	for s, expected := range points {
+		r, err := n.ParseNatural(s)
+		if err != nil {
+			t.Errorf("%q: %v", s, err)
+			continue
+		}
+		if !r.IsPoint() {
+			t.Errorf("%q should be a point, got %v", s, r)
+		}
+		assert(r.Begin, expected, s)
+	}
+
+	ranges := map[string][2]string{
+		"today":                    {"2019-11-20 00:00:00", "2019-11-20 23:59:59.999999999"},
+		"yesterday":                {"2019-11-19 00:00:00", "2019-11-19 23:59:59.999999999"},
+		"next monday":              {"2019-11-25 00:00:00", "2019-11-25 23:59:59.999999999"},
+		"last wednesday":           {"2019-11-13 00:00:00", "2019-11-13 23:59:59.999999999"},
+		"next wed":                 {"2019-11-27 00:00:00", "2019-11-27 23:59:59.999999999"},
+		"friday":                   {"2019-11-22 00:00:00", "2019-11-22 23:59:59.999999999"},
+		"this week":                {"2019-11-17 00:00:00", "2019-11-23 23:59:59.999999999"},
+		"last month":               {"2019-10-01 00:00:00", "2019-10-31 23:59:59.999999999"},
+		"next year":                {"2020-01-01 00:00:00", "2020-12-31 23:59:59.999999999"},
+		"last day of month":        {"2019-11-30 00:00:00", "2019-11-30 23:59:59.999999999"},
+		"first day of next month":  {"2019-12-01 00:00:00", "2019-12-01 23:59:59.999999999"},
+		"last day of last quarter": {"2019-09-30 00:00:00", "2019-09-30 23:59:59.999999999"},
+	}
+	for s, expected := range ranges {
+		r, err := n.ParseNatural(s)
+		if err != nil {
+			t.Errorf("%q: %v", s, err)
+			continue
+		}
+		assert(r.Begin, expected[0], s+" begin")
+		assert(r.End, expected[1], s+" end")
+	}
+
+	for _, s := range []string{"", "someday", "in two", "next fortnight day", "25:00", "13pm", "3 weeks later"} {
+		if r, err := n.ParseNatural(s); err != ErrUnrecognized {
+			t.Errorf("%q should be unrecognized, got %v %v", s, r, err)
+		}
+	}
+}
+
+func TestParseNaturalMonthOverflow(t *testing.T) {
+	assert := assertT(t)
+	n := New(time.Date(2019, 1, 31, 10, 0, 0, 0, time.UTC))
+	r, _ := n.ParseNatural("next month")
+	assert(r.Begin, "2019-02-01 00:00:00", "next month from Jan 31")
+	assert(r.End, "2019-02-28 23:59:59.999999999", "next month from Jan 31")
+}
+
+func TestParseNaturalLocale(t *testing.T) {
+	assert := assertT(t)
+	RegisterLocale("zh", WordsLocale{
+		"昨天": "yesterday",
+		"明天": "tomorrow",
+		"下周": "next week",
+		"下午": "",
+		"点":  "pm",
+	})
+	n := New(time.Date(2019, 11, 20, 17, 51, 49, 0, time.UTC))
+	r, err := n.ParseNaturalInLocale("zh", "昨天下午3点")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(r.Begin, "2019-11-19 15:00:00", "zh yesterday 3pm")
+	if r, err = n.ParseNaturalInLocale("zh", "下周"); err != nil {
+		t.Fatal(err)
+	}
+	assert(r.Begin, "2019-11-24 00:00:00", "zh next week")
+	// only whole words are replaced
+	if s := (WordsLocale{"mon": "monday"}).Normalize("month mon"); strings.Fields(s)[0] != "month" || strings.Fields(s)[1] != "monday" {
+		t.Errorf("unexpected normalized %q", s)
+	}
+	if _, err = n.ParseNaturalInLocale("xx", "today"); err != ErrUnknownLocale {
+		t.Fatal(err)
+	}
+	if r, err = n.ParseNatural("Contains"); err == nil {
+		t.Fatal("should fail")
+	}
+	r, _ = n.ParseNatural("today")
+	if !r.Contains(n.Time) || r.Contains(n.AddDate(0, 0, 1)) {
+		t.Error("Contains")
+	}
+}
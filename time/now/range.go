@@ -0,0 +1,36 @@
+package now
+
+import "time"
+
+// EachDay beginning of each day from the day of begin to the day of end, both included, in location of begin
+func EachDay(begin, end time.Time) (ts []time.Time) {
+	return each(New(begin).BeginningOfDay(), end, func(t time.Time) time.Time { return t.AddDate(0, 0, 1) })
+}
+
+// EachBusinessDay same as EachDay, but only business days of BusinessCalendar
+func EachBusinessDay(begin, end time.Time) (ts []time.Time) {
+	for _, t := range EachDay(begin, end) {
+		if BusinessCalendar.IsBusinessDay(t) {
+			ts = append(ts, t)
+		}
+	}
+	return
+}
+
+// EachWeek beginning of each week from the week of begin to the week of end, both included, see WeekStartDay
+func EachWeek(begin, end time.Time) (ts []time.Time) {
+	return each(New(begin).BeginningOfWeek(), end, func(t time.Time) time.Time { return t.AddDate(0, 0, 7) })
+}
+
+// EachMonth beginning of each month from the month of begin to the month of end, both included
+func EachMonth(begin, end time.Time) (ts []time.Time) {
+	return each(New(begin).BeginningOfMonth(), end, func(t time.Time) time.Time { return t.AddDate(0, 1, 0) })
+}
+
+func each(t, end time.Time, next func(time.Time) time.Time) (ts []time.Time) {
+	for !t.After(end) {
+		ts = append(ts, t)
+		t = next(t)
+	}
+	return
+}